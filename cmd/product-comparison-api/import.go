@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/goccy/go-json"
+	"github.com/mmedinam1600/product-comparison-api/internal/data"
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"go.uber.org/zap"
+)
+
+// importBatchSize is the number of items sent to PostgreSQL per upsert, all within the import transaction
+const importBatchSize = 500
+
+// runImport implements the "import" subcommand: it reads a supplier feed (CSV, NDJSON or JSON),
+// reports the invalid rows and writes the valid items as a JSON catalog or into PostgreSQL.
+// Either destination is replaced all at once: a feed that fails partway leaves it untouched.
+//
+//	product-comparison-api import -in feed.csv -out data/items.json
+//	product-comparison-api import -in feed.ndjson -database-url postgres://...
+func runImport(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	in := flags.String("in", "-", "feed file to read, - for stdin")
+	formatName := flags.String("format", "", "feed format: json | ndjson | csv (default: from the file extension)")
+	out := flags.String("out", "", "write the items as a JSON catalog to this file, - for stdout")
+	databaseURL := flags.String("database-url", "", "upsert the items into this PostgreSQL database")
+	strict := flags.Bool("strict", false, "exit with status 1 when any row is rejected")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if (*out == "") == (*databaseURL == "") {
+		fmt.Fprintln(os.Stderr, "import: exactly one of -out or -database-url is required")
+		return 2
+	}
+
+	format, err := data.ParseFeedFormat(*formatName, *in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import: %v\n", err)
+		return 2
+	}
+
+	input := io.Reader(os.Stdin)
+	if *in != "-" {
+		file, err := os.Open(*in)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "import: %v\n", err)
+			return 1
+		}
+		defer file.Close()
+		input = file
+	}
+
+	var sink itemSink
+	if *out != "" {
+		sink, err = newJSONSink(*out)
+	} else {
+		sink, err = newPostgresSink(*databaseURL)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import: %v\n", err)
+		return 1
+	}
+
+	report, err := data.ImportFeed(input, format, sink.Write)
+	if err == nil {
+		err = sink.Commit()
+	} else {
+		sink.Abort()
+	}
+
+	for _, rowErr := range report.Errors {
+		fmt.Fprintf(os.Stderr, "rejected %v\n", rowErr)
+	}
+	fmt.Fprintf(os.Stderr, "imported %d items, rejected %d rows\n", report.Imported, len(report.Errors))
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import: %v\n", err)
+		return 1
+	}
+	if *strict && len(report.Errors) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// itemSink receives the valid items of a feed as they are read.
+// They reach the destination on Commit; Abort discards them.
+type itemSink interface {
+	Write(item domain.Item) error
+	Commit() error
+	Abort()
+}
+
+// jsonSink streams the items as a JSON array without buffering the whole catalog.
+// A file is written next to its destination and renamed over it on Commit, so the file
+// catalog never loads a truncated array.
+type jsonSink struct {
+	file   *os.File
+	path   string // Destination of the file; empty for stdout
+	writer *bufio.Writer
+	count  int
+}
+
+func newJSONSink(path string) (*jsonSink, error) {
+	sink := &jsonSink{file: os.Stdout}
+	if path != "-" {
+		file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+		if err != nil {
+			return nil, err
+		}
+		sink.file, sink.path = file, path
+	}
+	sink.writer = bufio.NewWriter(sink.file)
+
+	if _, err := sink.writer.WriteString("["); err != nil {
+		sink.Abort()
+		return nil, err
+	}
+	return sink, nil
+}
+
+func (s *jsonSink) Write(item domain.Item) error {
+	encoded, err := json.MarshalIndent(item, "  ", "  ")
+	if err != nil {
+		return err
+	}
+
+	separator := "\n  "
+	if s.count > 0 {
+		separator = ",\n  "
+	}
+	s.count++
+
+	if _, err := s.writer.WriteString(separator); err != nil {
+		return err
+	}
+	_, err = s.writer.Write(encoded)
+	return err
+}
+
+func (s *jsonSink) Commit() error {
+	if _, err := s.writer.WriteString("\n]\n"); err != nil {
+		s.Abort()
+		return err
+	}
+	if err := s.writer.Flush(); err != nil {
+		s.Abort()
+		return err
+	}
+	if s.path == "" {
+		return nil
+	}
+
+	if err := s.file.Chmod(0o644); err != nil {
+		s.Abort()
+		return err
+	}
+	if err := s.file.Close(); err != nil {
+		_ = os.Remove(s.file.Name())
+		return err
+	}
+	if err := os.Rename(s.file.Name(), s.path); err != nil {
+		_ = os.Remove(s.file.Name())
+		return err
+	}
+	return nil
+}
+
+// Abort drops the temporary file. Output already sent to stdout stays an unterminated array.
+func (s *jsonSink) Abort() {
+	if s.path == "" {
+		_ = s.writer.Flush()
+		return
+	}
+	_ = s.file.Close()
+	_ = os.Remove(s.file.Name())
+}
+
+// postgresSink upserts the items in batches, all of them in one transaction
+type postgresSink struct {
+	repo    *data.PostgresCatalogRepo
+	imp     *data.ItemImport
+	pending []domain.Item
+}
+
+func newPostgresSink(databaseURL string) (*postgresSink, error) {
+	ctx := context.Background()
+	repo, err := data.NewPostgresCatalogRepo(ctx, data.PostgresConfig{
+		URL:     databaseURL,
+		Migrate: true,
+	}, zap.NewNop())
+	if err != nil {
+		return nil, err
+	}
+
+	imp, err := repo.BeginImport(ctx)
+	if err != nil {
+		repo.Close()
+		return nil, err
+	}
+	return &postgresSink{repo: repo, imp: imp, pending: make([]domain.Item, 0, importBatchSize)}, nil
+}
+
+func (s *postgresSink) Write(item domain.Item) error {
+	s.pending = append(s.pending, item)
+	if len(s.pending) < importBatchSize {
+		return nil
+	}
+	return s.flush()
+}
+
+func (s *postgresSink) flush() error {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	err := s.imp.Upsert(context.Background(), s.pending)
+	s.pending = s.pending[:0]
+	return err
+}
+
+func (s *postgresSink) Commit() error {
+	defer s.repo.Close()
+	defer s.imp.Rollback(context.Background())
+
+	if err := s.flush(); err != nil {
+		return err
+	}
+	return s.imp.Commit(context.Background())
+}
+
+func (s *postgresSink) Abort() {
+	s.imp.Rollback(context.Background())
+	s.repo.Close()
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+)
+
+// writeFeed writes a feed file in dir and returns its path
+func writeFeed(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write feed: %v", err)
+	}
+	return path
+}
+
+// assertOnlyFiles fails when dir holds other files than the expected ones (e.g. a leftover temp file)
+func assertOnlyFiles(t *testing.T, dir string, expected ...string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read dir: %v", err)
+	}
+	if len(entries) != len(expected) {
+		names := make([]string, len(entries))
+		for i, entry := range entries {
+			names[i] = entry.Name()
+		}
+		t.Errorf("Expected files %v, got %v", expected, names)
+	}
+}
+
+func TestRunImport_WritesCatalog(t *testing.T) {
+	dir := t.TempDir()
+	feed := writeFeed(t, dir, "feed.ndjson", `{"id": "a", "name": "A", "price": 10, "rating": 4}
+{"id": "b", "price": 10}
+{"id": "c", "name": "C", "price": 20, "rating": 5}
+`)
+	out := filepath.Join(dir, "items.json")
+
+	if status := runImport([]string{"-in", feed, "-out", out}); status != 0 {
+		t.Fatalf("Expected status 0, got %d", status)
+	}
+
+	content, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("failed to read catalog: %v", err)
+	}
+	var items []domain.Item
+	if err := json.Unmarshal(content, &items); err != nil {
+		t.Fatalf("Expected a valid JSON catalog, got %v:\n%s", err, content)
+	}
+	if len(items) != 2 || items[0].ID != "a" || items[1].ID != "c" {
+		t.Errorf("Expected items a and c, got %+v", items)
+	}
+	if info, err := os.Stat(out); err != nil || info.Mode().Perm() != 0o644 {
+		t.Errorf("Expected a 0644 catalog, got %v (%v)", info.Mode().Perm(), err)
+	}
+	assertOnlyFiles(t, dir, "feed.ndjson", "items.json")
+
+	// The rejected row fails a strict import, which still writes the valid items
+	if status := runImport([]string{"-in", feed, "-out", out, "-strict"}); status != 1 {
+		t.Errorf("Expected status 1 in strict mode, got %d", status)
+	}
+}
+
+func TestRunImport_FailedFeedKeepsCatalog(t *testing.T) {
+	dir := t.TempDir()
+	// The array breaks after the first item: the import stops there
+	feed := writeFeed(t, dir, "feed.json", `[{"id": "a", "name": "A", "price": 10, "rating": 4}, {"id": "b", "name": `)
+	out := writeFeed(t, dir, "items.json", `[{"id": "old", "name": "Old", "price": 1, "rating": 1}]`)
+
+	if status := runImport([]string{"-in", feed, "-out", out}); status != 1 {
+		t.Fatalf("Expected status 1, got %d", status)
+	}
+
+	content, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("failed to read catalog: %v", err)
+	}
+	if string(content) != `[{"id": "old", "name": "Old", "price": 1, "rating": 1}]` {
+		t.Errorf("Expected the previous catalog untouched, got %s", content)
+	}
+	assertOnlyFiles(t, dir, "feed.json", "items.json")
+}
+
+func TestRunImport_InvalidFlags(t *testing.T) {
+	feed := writeFeed(t, t.TempDir(), "feed.csv", "id,name,price,rating\n")
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "No destination", args: []string{"-in", feed}},
+		{name: "Two destinations", args: []string{"-in", feed, "-out", "-", "-database-url", "postgres://localhost/db"}},
+		{name: "Unknown format", args: []string{"-in", feed, "-out", "-", "-format", "xml"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := runImport(tt.args); status != 2 {
+				t.Errorf("Expected status 2, got %d", status)
+			}
+		})
+	}
+}
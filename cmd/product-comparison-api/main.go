@@ -15,6 +15,11 @@ import (
 )
 
 func main() {
+	// Subcommands run instead of the server
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		os.Exit(runImport(os.Args[2:]))
+	}
+
 	cfg := config.Load()
 
 	application, err := app.Bootstrap(cfg)
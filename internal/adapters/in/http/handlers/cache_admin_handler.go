@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mmedinam1600/product-comparison-api/internal/cache"
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"github.com/mmedinam1600/product-comparison-api/internal/tenant"
+	"go.uber.org/zap"
+)
+
+// Names of the caches in the admin routes
+const (
+	RequestCacheName     = "request"
+	IdempotencyCacheName = "idempotency"
+)
+
+// Size of the key samples: by default and at most
+const (
+	defaultKeySample = 100
+	maxKeySample     = 1000
+)
+
+// CacheAdminHandler manages the cache administration requests: stats, key samples and purges,
+// so on-call can clear a bad entry without restarting the process
+type CacheAdminHandler struct {
+	requestCache     cache.RequestCache
+	idempotencyCache cache.IdempotencyCache
+	tenants          *tenant.Registry
+	logger           *zap.Logger
+}
+
+// NewCacheAdminHandler creates a new instance of the handler
+func NewCacheAdminHandler(requestCache cache.RequestCache, idempotencyCache cache.IdempotencyCache, tenants *tenant.Registry, logger *zap.Logger) *CacheAdminHandler {
+	return &CacheAdminHandler{
+		requestCache:     requestCache,
+		idempotencyCache: idempotencyCache,
+		tenants:          tenants,
+		logger:           logger,
+	}
+}
+
+// CacheStatsResponse structures the response of the stats endpoint: the stats by cache name
+type CacheStatsResponse struct {
+	Data     map[string]cache.Stats `json:"data"`
+	Metadata interface{}            `json:"metadata"`
+	Error    *domain.ErrorResponse  `json:"error"`
+}
+
+// CacheKeysMetadata describes a key sample
+type CacheKeysMetadata struct {
+	Cache string `json:"cache"`
+	Count int    `json:"count"`
+	Limit int    `json:"limit"`
+}
+
+// CacheKeysResponse structures the response of the keys endpoint
+type CacheKeysResponse struct {
+	Data     []string              `json:"data"`
+	Metadata *CacheKeysMetadata    `json:"metadata"`
+	Error    *domain.ErrorResponse `json:"error"`
+}
+
+// CachePurge is the result of a purge
+type CachePurge struct {
+	Cache  string `json:"cache"`
+	Purged int    `json:"purged"`
+}
+
+// CachePurgeResponse structures the response of the purge endpoint
+type CachePurgeResponse struct {
+	Data     *CachePurge           `json:"data"`
+	Metadata interface{}           `json:"metadata"`
+	Error    *domain.ErrorResponse `json:"error"`
+}
+
+// Stats manages GET /api/admin/caches
+func (h *CacheAdminHandler) Stats(c *gin.Context) {
+	stats := make(map[string]cache.Stats)
+	for _, name := range []string{RequestCacheName, IdempotencyCacheName} {
+		if administered, ok := h.administered(name); ok {
+			stats[name] = administered.Stats(c.Request.Context())
+		}
+	}
+
+	c.JSON(http.StatusOK, CacheStatsResponse{Data: stats, Metadata: nil, Error: nil})
+}
+
+// Keys manages GET /api/admin/caches/:cache/keys
+func (h *CacheAdminHandler) Keys(c *gin.Context) {
+	name := c.Param("cache")
+	administered, ok := h.administered(name)
+	if !ok {
+		c.JSON(http.StatusNotFound, CacheKeysResponse{Error: unknownCache(c, name)})
+		return
+	}
+
+	limit := defaultKeySample
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxKeySample {
+			c.JSON(http.StatusBadRequest, CacheKeysResponse{Error: localizedError(c, &domain.ErrorResponse{
+				ErrorCode: domain.ErrorCodeInvalidRequest,
+				Message:   "limit must be an integer between 1 and " + strconv.Itoa(maxKeySample) + ".",
+			})})
+			return
+		}
+		limit = parsed
+	}
+
+	keys := administered.Keys(c.Request.Context(), limit)
+	c.JSON(http.StatusOK, CacheKeysResponse{
+		Data:     keys,
+		Metadata: &CacheKeysMetadata{Cache: name, Count: len(keys), Limit: limit},
+		Error:    nil,
+	})
+}
+
+// Purge manages DELETE /api/admin/caches/:cache. Exactly one of the query parameters selects
+// the entries: key (repeatable, as listed by Keys), item (repeatable, request cache only, in the
+// catalog of the tenant parameter or the default tenant) or all=true.
+func (h *CacheAdminHandler) Purge(c *gin.Context) {
+	name := c.Param("cache")
+	administered, ok := h.administered(name)
+	if !ok {
+		c.JSON(http.StatusNotFound, CachePurgeResponse{Error: unknownCache(c, name)})
+		return
+	}
+
+	ctx := c.Request.Context()
+	keys, items, all := c.QueryArray("key"), c.QueryArray("item"), c.Query("all") == "true"
+	selectors := 0
+	for _, selected := range []bool{len(keys) > 0, len(items) > 0, all} {
+		if selected {
+			selectors++
+		}
+	}
+	if selectors != 1 {
+		c.JSON(http.StatusBadRequest, CachePurgeResponse{Error: localizedError(c, &domain.ErrorResponse{
+			ErrorCode: domain.ErrorCodeInvalidRequest,
+			Message:   "Pass exactly one of key, item or all=true.",
+		})})
+		return
+	}
+
+	var purged int
+	switch {
+	case all:
+		purged = administered.PurgeAll(ctx)
+	case len(keys) > 0:
+		purged = administered.Purge(ctx, keys)
+	default:
+		itemKeys, errResp := h.itemKeys(c, name, items)
+		if errResp != nil {
+			c.JSON(errResp.ErrorCode.HTTPStatusCode(), CachePurgeResponse{Error: localizedError(c, errResp)})
+			return
+		}
+		purged = h.requestCache.InvalidateItems(ctx, itemKeys)
+	}
+
+	h.logger.Info("cache purged",
+		zap.String("cache", name),
+		zap.Strings("keys", keys),
+		zap.Strings("items", items),
+		zap.Bool("all", all),
+		zap.Int("purged", purged),
+		zap.String("client_ip", c.ClientIP()),
+	)
+
+	c.JSON(http.StatusOK, CachePurgeResponse{
+		Data:     &CachePurge{Cache: name, Purged: purged},
+		Metadata: nil,
+		Error:    nil,
+	})
+}
+
+// itemKeys returns the request cache index keys of the items in the catalog of the tenant parameter
+func (h *CacheAdminHandler) itemKeys(c *gin.Context, name string, items []string) ([]string, *domain.ErrorResponse) {
+	if name != RequestCacheName {
+		return nil, &domain.ErrorResponse{
+			ErrorCode: domain.ErrorCodeInvalidRequest,
+			Message:   "Only the request cache can be purged by item.",
+		}
+	}
+
+	t, found, err := h.tenants.Resolve(c.Query("tenant"), "")
+	var unknown *tenant.UnknownTenantError
+	if errors.As(err, &unknown) {
+		return nil, &domain.ErrorResponse{
+			ErrorCode: domain.ErrorCodeUnknownTenant,
+			Message:   "Unknown tenant '" + unknown.ID + "'.",
+		}
+	}
+	if !found {
+		return nil, &domain.ErrorResponse{
+			ErrorCode: domain.ErrorCodeMissingField,
+			Message:   "Missing tenant parameter.",
+		}
+	}
+
+	itemKeys := make([]string, len(items))
+	for i, id := range items {
+		itemKeys[i] = cache.NamespacedKey(t.ID, id)
+	}
+	return itemKeys, nil
+}
+
+// administered returns the cache of the name, when it supports administration
+func (h *CacheAdminHandler) administered(name string) (cache.Administered, bool) {
+	var administered cache.Administered
+	var ok bool
+	switch name {
+	case RequestCacheName:
+		administered, ok = h.requestCache.(cache.Administered)
+	case IdempotencyCacheName:
+		administered, ok = h.idempotencyCache.(cache.Administered)
+	}
+	return administered, ok
+}
+
+// unknownCache is the error of a cache name that does not exist or cannot be administered
+func unknownCache(c *gin.Context, name string) *domain.ErrorResponse {
+	return localizedError(c, &domain.ErrorResponse{
+		ErrorCode: domain.ErrorCodeUnknownCache,
+		Message:   "Unknown cache '" + name + "'.",
+	})
+}
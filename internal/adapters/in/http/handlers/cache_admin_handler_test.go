@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"github.com/mmedinam1600/product-comparison-api/internal/adapters/in/http/middleware"
+	"github.com/mmedinam1600/product-comparison-api/internal/cache"
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"go.uber.org/zap"
+)
+
+const testAdminToken = "secret"
+
+// newAdminTestEngine routes the admin endpoints like the router does, over memory caches holding
+// the comparisons ab, bc and cd of the default tenant and one idempotency entry
+func newAdminTestEngine(t *testing.T) *gin.Engine {
+	t.Helper()
+	logger := zap.NewNop()
+
+	requestCache, err := cache.NewMemoryRequestCache(1<<20, cache.TTL{Soft: time.Minute}, logger)
+	if err != nil {
+		t.Fatalf("NewMemoryRequestCache() error = %v", err)
+	}
+	t.Cleanup(requestCache.Close)
+	idempotencyCache, err := cache.NewMemoryIdempotencyCache(1<<20, time.Minute, logger)
+	if err != nil {
+		t.Fatalf("NewMemoryIdempotencyCache() error = %v", err)
+	}
+	t.Cleanup(idempotencyCache.Close)
+
+	// Restore stores synchronously, so the entries are visible right away
+	expiresAt := time.Now().Add(time.Minute)
+	entry := func(key string, items ...string) cache.RequestSnapshotEntry {
+		itemKeys := make([]string, len(items))
+		for i, id := range items {
+			itemKeys[i] = cache.NamespacedKey("default", id)
+		}
+		return cache.RequestSnapshotEntry{
+			Key:       cache.NamespacedKey("default", key),
+			ItemIDs:   itemKeys,
+			Response:  cache.CachedResponse{Body: []byte(`{}`), StoredAt: time.Now()},
+			ExpiresAt: expiresAt,
+		}
+	}
+	keep := func(cache.CachedResponse) bool { return true }
+	if restored := requestCache.Restore([]cache.RequestSnapshotEntry{entry("ab", "a", "b"), entry("bc", "b", "c"), entry("cd", "c", "d")}, keep); restored != 3 {
+		t.Fatalf("Expected 3 seeded comparisons, got %d", restored)
+	}
+	idempotencyCache.Restore([]cache.IdempotencySnapshotEntry{{Key: "default:retry", Entry: cache.IdempotentEntry{Response: []byte(`{}`)}, ExpiresAt: expiresAt}})
+
+	handler := NewCacheAdminHandler(requestCache, idempotencyCache, newTestTenants(t, nil, nil), logger)
+	engine := gin.New()
+	admin := engine.Group("/api/admin", middleware.AdminAuthMiddleware(testAdminToken, logger))
+	admin.GET("/caches", handler.Stats)
+	admin.GET("/caches/:cache/keys", handler.Keys)
+	admin.DELETE("/caches/:cache", handler.Purge)
+	return engine
+}
+
+// serveAdmin sends an admin request with the test token
+func serveAdmin(engine *gin.Engine, method, target string) (int, CachePurgeResponse, []byte) {
+	recorder := serve(engine, method, target, map[string]string{"Authorization": "Bearer " + testAdminToken})
+	var response CachePurgeResponse
+	_ = json.Unmarshal(recorder.Body.Bytes(), &response)
+	return recorder.Code, response, recorder.Body.Bytes()
+}
+
+func TestCacheAdmin_RejectsMissingOrInvalidToken(t *testing.T) {
+	engine := newAdminTestEngine(t)
+
+	tests := []struct {
+		name          string
+		authorization string
+	}{
+		{name: "No header", authorization: ""},
+		{name: "Wrong token", authorization: "Bearer other"},
+		{name: "Not a bearer token", authorization: testAdminToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := map[string]string{}
+			if tt.authorization != "" {
+				headers["Authorization"] = tt.authorization
+			}
+			recorder := serve(engine, http.MethodDelete, "/api/admin/caches/request?all=true", headers)
+			if recorder.Code != http.StatusUnauthorized {
+				t.Errorf("Expected 401, got %d", recorder.Code)
+			}
+			if recorder.Header().Get("WWW-Authenticate") == "" {
+				t.Error("Expected a WWW-Authenticate challenge")
+			}
+			if code := decodeError(t, recorder.Body.Bytes()); code != domain.ErrorCodeUnauthorized {
+				t.Errorf("Expected %s, got %s", domain.ErrorCodeUnauthorized, code)
+			}
+		})
+	}
+
+	// Nothing was purged
+	code, _, body := serveAdmin(engine, http.MethodGet, "/api/admin/caches/request/keys")
+	var keys CacheKeysResponse
+	if err := json.Unmarshal(body, &keys); err != nil || code != http.StatusOK || len(keys.Data) != 3 {
+		t.Errorf("Expected the 3 comparisons to remain, got %d: %s", code, body)
+	}
+}
+
+func TestCacheAdmin_Purge(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		status   int
+		expected int
+	}{
+		{name: "By key", target: "/api/admin/caches/request?key=default:ab&key=default:unknown", status: http.StatusOK, expected: 1},
+		{name: "By item", target: "/api/admin/caches/request?item=b", status: http.StatusOK, expected: 2},
+		{name: "By item of a tenant", target: "/api/admin/caches/request?item=c&tenant=default", status: http.StatusOK, expected: 2},
+		{name: "All", target: "/api/admin/caches/request?all=true", status: http.StatusOK, expected: 3},
+		{name: "Idempotency by key", target: "/api/admin/caches/idempotency?key=default:retry", status: http.StatusOK, expected: 1},
+		{name: "Idempotency by item", target: "/api/admin/caches/idempotency?item=a", status: http.StatusBadRequest},
+		{name: "No selector", target: "/api/admin/caches/request", status: http.StatusBadRequest},
+		{name: "Two selectors", target: "/api/admin/caches/request?key=default:ab&all=true", status: http.StatusBadRequest},
+		{name: "Unknown tenant", target: "/api/admin/caches/request?item=a&tenant=other", status: http.StatusNotFound},
+		{name: "Unknown cache", target: "/api/admin/caches/other?all=true", status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := newAdminTestEngine(t)
+
+			code, response, body := serveAdmin(engine, http.MethodDelete, tt.target)
+			if code != tt.status {
+				t.Fatalf("Expected %d, got %d: %s", tt.status, code, body)
+			}
+			if tt.status != http.StatusOK {
+				if response.Error == nil {
+					t.Errorf("Expected an error envelope, got %s", body)
+				}
+				return
+			}
+			if response.Data == nil || response.Data.Purged != tt.expected {
+				t.Errorf("Expected %d purged entries, got %s", tt.expected, body)
+			}
+		})
+	}
+}
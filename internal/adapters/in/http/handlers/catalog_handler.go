@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"go.uber.org/zap"
+)
+
+// CatalogHandler manages the catalog introspection requests
+type CatalogHandler struct {
+	logger *zap.Logger
+}
+
+// NewCatalogHandler creates a new instance of the handler
+func NewCatalogHandler(logger *zap.Logger) *CatalogHandler {
+	return &CatalogHandler{
+		logger: logger,
+	}
+}
+
+// CatalogVersionsMetadata describes the list of versions
+type CatalogVersionsMetadata struct {
+	Current *domain.CatalogVersion `json:"current"`
+	Count   int                    `json:"count"`
+}
+
+// CatalogVersionsResponse structures the response of the versions endpoint
+type CatalogVersionsResponse struct {
+	Data     []domain.CatalogVersion  `json:"data"`
+	Metadata *CatalogVersionsMetadata `json:"metadata"`
+	Error    *domain.ErrorResponse    `json:"error"`
+}
+
+// Versions manages GET /api/v1/catalog/versions
+func (h *CatalogHandler) Versions(c *gin.Context) {
+	versions, current := requestTenant(c).Catalog.Versions(c.Request.Context())
+
+	c.JSON(http.StatusOK, CatalogVersionsResponse{
+		Data: versions,
+		Metadata: &CatalogVersionsMetadata{
+			Current: current,
+			Count:   len(versions),
+		},
+		Error: nil,
+	})
+}
+
+// CatalogChangesMetadata describes the list of changes
+type CatalogChangesMetadata struct {
+	Count int `json:"count"`
+}
+
+// CatalogChangesResponse structures the response of the changes endpoint
+type CatalogChangesResponse struct {
+	Data     []domain.CatalogDiff    `json:"data"`
+	Metadata *CatalogChangesMetadata `json:"metadata"`
+	Error    *domain.ErrorResponse   `json:"error"`
+}
+
+// Changes manages GET /api/v1/catalog/changes
+func (h *CatalogHandler) Changes(c *gin.Context) {
+	changes, errResp := requestTenant(c).Catalog.Changes(c.Request.Context(), c.Query("since"))
+	if errResp != nil {
+		c.JSON(errResp.ErrorCode.HTTPStatusCode(), CatalogChangesResponse{Error: localizedError(c, errResp)})
+		return
+	}
+
+	c.JSON(http.StatusOK, CatalogChangesResponse{
+		Data:     changes,
+		Metadata: &CatalogChangesMetadata{Count: len(changes)},
+		Error:    nil,
+	})
+}
@@ -1,34 +1,46 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
 	"github.com/mmedinam1600/product-comparison-api/internal/cache"
+	"github.com/mmedinam1600/product-comparison-api/internal/data"
 	"github.com/mmedinam1600/product-comparison-api/internal/domain"
-	"github.com/mmedinam1600/product-comparison-api/internal/service"
+	"github.com/mmedinam1600/product-comparison-api/internal/i18n"
+	"github.com/mmedinam1600/product-comparison-api/internal/metrics"
+	"github.com/mmedinam1600/product-comparison-api/internal/tenant"
 	"go.uber.org/zap"
 )
 
 // CompareHandler manages the comparison requests
 type CompareHandler struct {
-	compareService   service.CompareService
-	requestCache     *cache.RequestCache
-	idempotencyCache *cache.IdempotencyCache
+	requestCache     cache.RequestCache
+	idempotencyCache cache.IdempotencyCache
+	compressor       *cache.Compressor           // Precompresses the cached responses
+	popular          *cache.PopularComparisons   // Counts the cached comparisons, to warm the cache up
+	flights          cache.Coalescer[comparison] // Comparisons being computed, by cache key
 	logger           *zap.Logger
 }
 
 // NewCompareHandler creates a new instance of the handler
 func NewCompareHandler(
-	compareService service.CompareService,
-	requestCache *cache.RequestCache,
-	idempotencyCache *cache.IdempotencyCache,
+	requestCache cache.RequestCache,
+	idempotencyCache cache.IdempotencyCache,
+	compressor *cache.Compressor,
+	popular *cache.PopularComparisons,
 	logger *zap.Logger,
 ) *CompareHandler {
 	return &CompareHandler{
-		compareService:   compareService,
 		requestCache:     requestCache,
 		idempotencyCache: idempotencyCache,
+		compressor:       compressor,
+		popular:          popular,
 		logger:           logger,
 	}
 }
@@ -42,8 +54,6 @@ type CompareResponse struct {
 
 // Compare manages POST /api/v1/items/compare
 func (h *CompareHandler) Compare(c *gin.Context) {
-	ctx := c.Request.Context()
-
 	// Parse request
 	var req domain.CompareRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -51,65 +61,103 @@ func (h *CompareHandler) Compare(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, CompareResponse{
 			Data:     nil,
 			Metadata: nil,
-			Error: &domain.ErrorResponse{
+			Error: localizedError(c, &domain.ErrorResponse{
 				ErrorCode: domain.ErrorCodeMissingField,
 				Message:   "Missing mandatory field 'ids'",
-			},
+			}),
+		})
+		return
+	}
+
+	h.respond(c, req)
+}
+
+// CompareByQuery manages GET /api/v1/items/compare, the same comparison with its parameters
+// in the query string, so browsers and CDNs can cache and revalidate it
+func (h *CompareHandler) CompareByQuery(c *gin.Context) {
+	req, errResp := parseCompareQuery(c)
+	if errResp != nil {
+		h.logger.Warn("invalid compare query", zap.String("message", errResp.Message))
+		c.JSON(errResp.ErrorCode.HTTPStatusCode(), CompareResponse{
+			Data:     nil,
+			Metadata: nil,
+			Error:    localizedError(c, errResp),
 		})
 		return
 	}
 
+	h.respond(c, req)
+}
+
+// respond compares the products of the request, through the request cache, and writes the response
+func (h *CompareHandler) respond(c *gin.Context, req domain.CompareRequest) {
+	ctx := c.Request.Context()
+	t := requestTenant(c)
+	localizer := i18n.FromContext(ctx)
+
 	h.logger.Info("compare request received",
+		zap.String("tenant", t.ID),
 		zap.Int("ids_count", len(req.Ids)),
 		zap.Bool("has_fields", req.Fields != nil),
 	)
 
-	// Generate cache key based on IDs
-	cacheKey := h.compareService.GenerateCacheKey(req.Ids)
-
-	// Try to get from the request cache
-	if cached, found := h.requestCache.Get(ctx, cacheKey); found {
-		h.logger.Info("returning cached response", zap.String("cache_key", cacheKey))
-		c.Header("Cache-Status", "hit")
-		c.JSON(http.StatusOK, CompareResponse{
-			Data:     cached.Data.(*domain.CompareResult),
-			Metadata: cached.Metadata.(*domain.Metadata),
-			Error:    nil,
-		})
-		return
-	}
+	cacheKey := comparisonKey(t, localizer, req)
 
-	c.Header("Cache-Status", "miss")
+	// Comparisons pinned to a past catalog version bypass the request cache,
+	// whose entries always belong to the live catalog
+	useCache := !req.PinsCatalog()
 
-	// Execute comparison
-	result, metadata, errResp := h.compareService.Compare(ctx, req)
+	var outcome comparison
+	if !useCache {
+		h.setCacheStatus(c, metrics.CacheBypass)
+		outcome = h.compare(ctx, t, req, cacheKey, false)
+	} else if cached, found := h.requestCache.Get(ctx, cacheKey); found {
+		// Try to get from the request cache
+		if cached.Stale {
+			// Past the soft TTL: answered right away while a single background execution refreshes it
+			h.logger.Info("returning stale cached response", zap.String("cache_key", cacheKey))
+			h.setCacheStatus(c, metrics.CacheStale)
+			h.flights.Start(cacheKey, func() comparison {
+				return h.compare(context.WithoutCancel(ctx), t, req, cacheKey, true)
+			})
+		} else {
+			h.logger.Info("returning cached response", zap.String("cache_key", cacheKey))
+			h.setCacheStatus(c, metrics.CacheHit)
+		}
+		outcome = comparison{response: cached}
+	} else {
+		// Concurrent misses of the same key share one execution. It is detached from the request
+		// that runs it, so that client disconnecting does not fail the requests waiting for it.
+		var coalesced bool
+		outcome, coalesced = h.flights.Do(cacheKey, func() comparison {
+			return h.compare(context.WithoutCancel(ctx), t, req, cacheKey, true)
+		})
+		if coalesced {
+			h.logger.Debug("coalesced with a concurrent comparison", zap.String("cache_key", cacheKey))
+			h.setCacheStatus(c, metrics.CacheCoalesced)
+		} else {
+			h.setCacheStatus(c, metrics.CacheMiss)
+		}
+	}
 
-	if errResp != nil {
+	if outcome.errResp != nil {
 		// Business error
-		statusCode := errResp.ErrorCode.HTTPStatusCode()
+		statusCode := outcome.errResp.ErrorCode.HTTPStatusCode()
 		h.logger.Info("comparison failed",
-			zap.String("error_code", string(errResp.ErrorCode)),
+			zap.String("error_code", string(outcome.errResp.ErrorCode)),
 			zap.Int("status", statusCode),
 		)
 		c.JSON(statusCode, CompareResponse{
 			Data:     nil,
 			Metadata: nil,
-			Error:    errResp,
+			Error:    localizer.Error(outcome.errResp),
 		})
 		return
 	}
 
-	// Success: cache the result
-	h.requestCache.Set(ctx, cacheKey, cache.CachedResponse{
-		Data:     &result,
-		Metadata: &metadata,
-	})
-
-	// Prepare final response
-	response := CompareResponse{
-		Data:     &result,
-		Metadata: &metadata,
-		Error:    nil,
+	// Cached comparisons count towards the popular ones, precomputed by the cache warm-up
+	if useCache {
+		h.popular.Record(t.ID, localizer.Locale(), req)
 	}
 
 	// If there is an idempotency key, save in the idempotency cache
@@ -117,11 +165,183 @@ func (h *CompareHandler) Compare(c *gin.Context) {
 		if bodyHash, hashExists := c.Get("body_hash"); hashExists {
 			h.idempotencyCache.Set(ctx, idempotencyKey.(string), cache.IdempotentEntry{
 				BodyHash: bodyHash.(string),
-				Response: response,
+				Response: outcome.response.Body,
 			})
 			h.logger.Debug("saved idempotent response", zap.String("key", idempotencyKey.(string)))
 		}
 	}
 
-	c.JSON(http.StatusOK, response)
+	h.write(c, outcome.response)
+}
+
+// comparisonKey generates the cache key from every input that changes the result (IDs, fields, currency,
+// pinned catalog version, ...), namespaced by tenant so storefronts never share entries, and by locale since
+// entries hold the encoded body
+func comparisonKey(t *tenant.Tenant, localizer *i18n.Localizer, req domain.CompareRequest) string {
+	return cache.NamespacedKey(t.ID, cache.NamespacedKey(localizer.Locale(), t.Compare.GenerateCacheKey(req)))
+}
+
+// comparison is the outcome of a comparison, shared by the requests coalesced with it
+type comparison struct {
+	response cache.CachedResponse
+	errResp  *domain.ErrorResponse
+}
+
+// compare executes the comparison and encodes its response once, in the locale of the context.
+// With store, the response is also precompressed and cached.
+func (h *CompareHandler) compare(ctx context.Context, t *tenant.Tenant, req domain.CompareRequest, cacheKey string, store bool) comparison {
+	result, metadata, errResp := t.Compare.Compare(ctx, req)
+	if errResp != nil {
+		return comparison{errResp: errResp}
+	}
+
+	localizer := i18n.FromContext(ctx)
+	data, localizedMetadata := localizeComparison(localizer, &result, &metadata)
+	body, err := json.Marshal(CompareResponse{Data: data, Metadata: localizedMetadata})
+	if err != nil {
+		h.logger.Error("failed to encode comparison", zap.String("cache_key", cacheKey), zap.Error(err))
+		return comparison{errResp: &domain.ErrorResponse{
+			ErrorCode: domain.ErrorCodeInternal,
+			Message:   "Failed to encode the comparison.",
+		}}
+	}
+
+	// The cache key covers every input of the result and the locale, the ETag also the catalog version
+	response := cache.CachedResponse{
+		Body: body,
+		ETag: entityTag(metadata.CatalogVersion, localizer.Locale(), cacheKey),
+	}
+	if metadata.CatalogVersion != nil {
+		response.CatalogVersion = metadata.CatalogVersion.ID
+		response.CatalogContent = metadata.CatalogVersion.ContentHash
+	}
+	if !store {
+		return comparison{response: response}
+	}
+	if superseded(t, req, &metadata) {
+		// A version published meanwhile may already have evicted these items: storing the
+		// comparison now would serve its old content until the TTL
+		h.logger.Debug("comparison not cached, catalog version superseded",
+			zap.String("cache_key", cacheKey),
+			zap.String("catalog_version", response.CatalogVersion),
+		)
+		return comparison{response: response}
+	}
+
+	if response.Encoded, err = h.compressor.Compress(body); err != nil {
+		// Cached without variants: hits are sent uncompressed
+		h.logger.Warn("failed to compress comparison", zap.String("cache_key", cacheKey), zap.Error(err))
+	}
+	h.requestCache.Set(ctx, cacheKey, cacheIndex(t.ID, &metadata), response)
+
+	return comparison{response: response}
+}
+
+// write sends an encoded comparison as it is: the variant the client accepts, under the ETag of
+// that variant, or 304 when If-None-Match already names it
+func (h *CompareHandler) write(c *gin.Context, response cache.CachedResponse) {
+	if h.compressor.Enabled() {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+	}
+
+	encoding, body := negotiateEncoding(c.GetHeader("Accept-Encoding"), response)
+	if matchETag(c, encodedETag(response.ETag, encoding)) {
+		return
+	}
+
+	if encoding != "" {
+		c.Header("Content-Encoding", encoding)
+	}
+	c.Header("Content-Length", strconv.Itoa(len(body)))
+	c.Data(http.StatusOK, cache.ContentTypeJSON, body)
+}
+
+// superseded reports whether a live comparison was computed from a catalog version that is no
+// longer live. Pinned comparisons are keyed by their version, so they never are.
+func superseded(t *tenant.Tenant, req domain.CompareRequest, metadata *domain.Metadata) bool {
+	if req.CatalogVersion != nil || req.AsOf != nil || metadata.CatalogVersion == nil {
+		return false
+	}
+	versioned, ok := t.Repo.(data.VersionedCatalog)
+	return ok && versioned.CurrentSnapshot().Version.ID != metadata.CatalogVersion.ID
+}
+
+// cacheIndex lists the items a cached comparison is indexed by, so a catalog change evicts it
+// (IDs namespaced like the key). Requested parents are indexed too, so a new or removed variant
+// evicts their expansion, and so are the items named by warnings (e.g. excluded ones that may
+// come back in stock).
+func cacheIndex(tenantID string, metadata *domain.Metadata) []string {
+	itemKeys := make([]string, 0, len(metadata.Order)+len(metadata.Variants))
+	for _, id := range metadata.Order {
+		itemKeys = append(itemKeys, cache.NamespacedKey(tenantID, id))
+	}
+	for parentID := range metadata.Variants {
+		itemKeys = append(itemKeys, cache.NamespacedKey(tenantID, parentID))
+	}
+	for _, warning := range metadata.ComparePolicy.Warnings {
+		for _, id := range warning.Items {
+			itemKeys = append(itemKeys, cache.NamespacedKey(tenantID, id))
+		}
+	}
+	if metadata.Conversion != nil {
+		itemKeys = append(itemKeys, cache.ExchangeRatesTag)
+	}
+	return itemKeys
+}
+
+// setCacheStatus reports the cache status in the Cache-Status header and the request metrics
+func (h *CompareHandler) setCacheStatus(c *gin.Context, status string) {
+	c.Header("Cache-Status", status)
+	metrics.CompareRequests.WithLabelValues(status).Inc()
+}
+
+// parseCompareQuery reads a comparison from the query string: ids and fields are comma-separated
+// (or repeated), the other parameters are named like the fields of the JSON body
+func parseCompareQuery(c *gin.Context) (domain.CompareRequest, *domain.ErrorResponse) {
+	req := domain.CompareRequest{
+		Ids:         splitQueryList(c.QueryArray("ids")),
+		Currency:    c.Query("currency"),
+		Unavailable: domain.UnavailablePolicy(c.Query("unavailable")),
+	}
+	if len(req.Ids) == 0 {
+		return req, &domain.ErrorResponse{
+			ErrorCode: domain.ErrorCodeMissingField,
+			Message:   "Missing mandatory field 'ids'",
+		}
+	}
+
+	if raw, present := c.GetQueryArray("fields"); present {
+		fields := splitQueryList(raw)
+		req.Fields = &fields
+	}
+
+	if version := c.Query("catalog_version"); version != "" {
+		req.CatalogVersion = &version
+	}
+
+	if raw := c.Query("as_of"); raw != "" {
+		asOf, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return req, &domain.ErrorResponse{
+				ErrorCode: domain.ErrorCodeInvalidRequest,
+				Message:   "as_of must be an RFC 3339 timestamp.",
+			}
+		}
+		req.AsOf = &asOf
+	}
+
+	return req, nil
+}
+
+// splitQueryList splits comma-separated query values, dropping empty entries
+func splitQueryList(values []string) []string {
+	list := make([]string, 0, len(values))
+	for _, value := range values {
+		for _, entry := range strings.Split(value, ",") {
+			if entry = strings.TrimSpace(entry); entry != "" {
+				list = append(list, entry)
+			}
+		}
+	}
+	return list
 }
@@ -0,0 +1,497 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"github.com/mmedinam1600/product-comparison-api/internal/adapters/in/http/middleware"
+	"github.com/mmedinam1600/product-comparison-api/internal/cache"
+	"github.com/mmedinam1600/product-comparison-api/internal/data"
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"github.com/mmedinam1600/product-comparison-api/internal/i18n"
+	"github.com/mmedinam1600/product-comparison-api/internal/service"
+	"github.com/mmedinam1600/product-comparison-api/internal/tenant"
+	"go.uber.org/zap"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// fakeRequestCache is a RequestCache over a map, so tests see their entries right away
+type fakeRequestCache struct {
+	mu      sync.Mutex
+	entries map[string]cache.CachedResponse
+	stale   bool        // Get reports every entry as past the soft TTL
+	sets    chan string // Receives the key of every Set, when not nil
+}
+
+func newFakeRequestCache() *fakeRequestCache {
+	return &fakeRequestCache{entries: make(map[string]cache.CachedResponse), sets: make(chan string, 16)}
+}
+
+func (f *fakeRequestCache) Get(ctx context.Context, key string) (cache.CachedResponse, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	response, found := f.entries[key]
+	response.Stale = found && f.stale
+	return response, found
+}
+
+func (f *fakeRequestCache) Set(ctx context.Context, key string, itemIDs []string, response cache.CachedResponse) {
+	f.mu.Lock()
+	f.entries[key] = response
+	f.mu.Unlock()
+	f.sets <- key
+}
+
+func (f *fakeRequestCache) InvalidateItems(ctx context.Context, itemIDs []string) int { return 0 }
+
+func (f *fakeRequestCache) Close() {}
+
+func (f *fakeRequestCache) setStale(stale bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stale = stale
+}
+
+// testItems are the two items of the test catalog, with descriptions long enough for their
+// comparison to be precompressed
+func testItems() []domain.Item {
+	return []domain.Item{
+		{ID: "a", Name: "Mouse A", Description: strings.Repeat("A wireless mouse. ", 60), Price: 10, Rating: 4},
+		{ID: "b", Name: "Mouse B", Description: strings.Repeat("A wired mouse. ", 60), Price: 20, Rating: 4.5},
+	}
+}
+
+// writeTestCatalog writes the items as a JSON catalog file
+func writeTestCatalog(t *testing.T, path string, items []domain.Item) {
+	t.Helper()
+	content, err := json.Marshal(items)
+	if err != nil {
+		t.Fatalf("failed to encode catalog: %v", err)
+	}
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatalf("failed to write catalog: %v", err)
+	}
+}
+
+// newTestCatalog loads a versioned catalog of the test items and returns it with its file
+func newTestCatalog(t *testing.T) (*data.FileCatalogRepo, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "items.json")
+	writeTestCatalog(t, path, testItems())
+
+	repo, err := data.NewFileCatalogRepo(path, data.FeedFormatJSON, 3, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewFileCatalogRepo() error = %v", err)
+	}
+	return repo, path
+}
+
+// newTestTenants registers the default tenant over the given catalog, or over a new test catalog when nil
+func newTestTenants(t *testing.T, repo *data.FileCatalogRepo, compare service.CompareService) *tenant.Registry {
+	t.Helper()
+	if repo == nil {
+		repo, _ = newTestCatalog(t)
+	}
+	if compare == nil {
+		compare = service.NewCompareService(repo, zap.NewNop())
+	}
+	tenants, err := tenant.NewRegistry([]*tenant.Tenant{{ID: tenant.DefaultID, Repo: repo, Compare: compare}}, tenant.DefaultID)
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+	return tenants
+}
+
+// newCompareTestEngine routes the compare endpoints like the router does, over the given catalog (a new
+// one when nil) and with the given codings precompressed
+func newCompareTestEngine(t *testing.T, repo *data.FileCatalogRepo, compare service.CompareService, requestCache cache.RequestCache, encodings ...string) *gin.Engine {
+	t.Helper()
+	logger := zap.NewNop()
+
+	bundle, err := i18n.NewBundle("en", []string{"es-MX"})
+	if err != nil {
+		t.Fatalf("NewBundle() error = %v", err)
+	}
+	compressor, err := cache.NewCompressor(encodings)
+	if err != nil {
+		t.Fatalf("NewCompressor() error = %v", err)
+	}
+	popular, err := cache.NewPopularComparisons("", 10, logger)
+	if err != nil {
+		t.Fatalf("NewPopularComparisons() error = %v", err)
+	}
+	idempotencyCache, err := cache.NewMemoryIdempotencyCache(1<<20, time.Minute, logger)
+	if err != nil {
+		t.Fatalf("NewMemoryIdempotencyCache() error = %v", err)
+	}
+	t.Cleanup(idempotencyCache.Close)
+
+	handler := NewCompareHandler(requestCache, idempotencyCache, compressor, popular, logger)
+	engine := gin.New()
+	items := engine.Group("/api/v1/items", middleware.LocaleMiddleware(bundle), middleware.TenantMiddleware(newTestTenants(t, repo, compare), "", logger))
+	items.POST("/compare", handler.Compare)
+	items.GET("/compare", handler.CompareByQuery)
+	return engine
+}
+
+// serve sends a request to the engine and returns the recorded response
+func serve(engine *gin.Engine, method, target string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+	return recorder
+}
+
+// decodeError returns the error code of an error envelope
+func decodeError(t *testing.T, body []byte) domain.ErrorCode {
+	t.Helper()
+	var response CompareResponse
+	if err := json.Unmarshal(body, &response); err != nil || response.Error == nil {
+		t.Fatalf("Expected an error envelope, got %s", body)
+	}
+	return response.Error.ErrorCode
+}
+
+func TestCompareHandler_IfNoneMatch(t *testing.T) {
+	engine := newCompareTestEngine(t, nil, nil, newFakeRequestCache())
+	const target = "/api/v1/items/compare?ids=a,b"
+
+	first := serve(engine, http.MethodGet, target, nil)
+	if first.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", first.Code, first.Body)
+	}
+	etag := first.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("Expected an ETag for a versioned catalog")
+	}
+
+	revalidated := serve(engine, http.MethodGet, target, map[string]string{"If-None-Match": etag})
+	if revalidated.Code != http.StatusNotModified || revalidated.Body.Len() != 0 {
+		t.Errorf("Expected 304 without body, got %d: %s", revalidated.Code, revalidated.Body)
+	}
+	if revalidated.Header().Get("ETag") != etag {
+		t.Errorf("Expected the 304 to repeat the ETag %s, got %s", etag, revalidated.Header().Get("ETag"))
+	}
+
+	// A weak validator of the same tag also matches; another tag does not
+	if weak := serve(engine, http.MethodGet, target, map[string]string{"If-None-Match": "W/" + etag}); weak.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 for the weak validator, got %d", weak.Code)
+	}
+	if other := serve(engine, http.MethodGet, target, map[string]string{"If-None-Match": `"other"`}); other.Code != http.StatusOK {
+		t.Errorf("Expected 200 for another ETag, got %d", other.Code)
+	}
+
+	// Another locale is another representation
+	if localized := serve(engine, http.MethodGet, target, map[string]string{"If-None-Match": etag, "Accept-Language": "es-MX"}); localized.Code != http.StatusOK {
+		t.Errorf("Expected 200 for another locale, got %d", localized.Code)
+	}
+}
+
+func TestEncodedETag(t *testing.T) {
+	tests := []struct {
+		name     string
+		etag     string
+		encoding string
+		expected string
+	}{
+		{name: "Identity keeps the tag", etag: `"abc"`, encoding: "", expected: `"abc"`},
+		{name: "Gzip variant", etag: `"abc"`, encoding: cache.EncodingGzip, expected: `"abc-gzip"`},
+		{name: "Brotli variant", etag: `"abc"`, encoding: cache.EncodingBrotli, expected: `"abc-br"`},
+		{name: "Unversioned content", etag: "", encoding: cache.EncodingGzip, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := encodedETag(tt.etag, tt.encoding); got != tt.expected {
+				t.Errorf("encodedETag(%q, %q) = %q, want %q", tt.etag, tt.encoding, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCompareHandler_CompareByQuery_InvalidQuery(t *testing.T) {
+	engine := newCompareTestEngine(t, nil, nil, newFakeRequestCache())
+
+	tests := []struct {
+		name     string
+		query    string
+		status   int
+		expected domain.ErrorCode
+	}{
+		{name: "No ids", query: "", status: http.StatusBadRequest, expected: domain.ErrorCodeMissingField},
+		{name: "Empty ids", query: "ids=", status: http.StatusBadRequest, expected: domain.ErrorCodeMissingField},
+		{name: "Only separators", query: "ids=,%20,", status: http.StatusBadRequest, expected: domain.ErrorCodeMissingField},
+		{name: "Invalid as_of", query: "ids=a,b&as_of=yesterday", status: http.StatusBadRequest, expected: domain.ErrorCodeInvalidRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := serve(engine, http.MethodGet, "/api/v1/items/compare?"+tt.query, nil)
+			if recorder.Code != tt.status {
+				t.Errorf("Expected %d, got %d: %s", tt.status, recorder.Code, recorder.Body)
+			}
+			if code := decodeError(t, recorder.Body.Bytes()); code != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, code)
+			}
+		})
+	}
+}
+
+func TestParseCompareQuery(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/?ids=a,b&ids=c&fields=price,&currency=mxn&as_of=2026-01-15T12:00:00Z", nil)
+
+	req, errResp := parseCompareQuery(c)
+	if errResp != nil {
+		t.Fatalf("Unexpected error: %s", errResp.Message)
+	}
+	if strings.Join(req.Ids, ",") != "a,b,c" {
+		t.Errorf("Expected ids a,b,c, got %v", req.Ids)
+	}
+	if req.Fields == nil || strings.Join(*req.Fields, ",") != "price" {
+		t.Errorf("Expected fields [price], got %v", req.Fields)
+	}
+	if req.Currency != "mxn" {
+		t.Errorf("Expected currency mxn, got %s", req.Currency)
+	}
+	if req.AsOf == nil || !req.AsOf.Equal(time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected as_of 2026-01-15T12:00:00Z, got %v", req.AsOf)
+	}
+}
+
+// gatedCompare counts the comparisons of a service; while held, they wait to be released
+type gatedCompare struct {
+	service.CompareService
+	calls   atomic.Int32
+	held    atomic.Bool
+	release chan struct{}
+}
+
+func (g *gatedCompare) Compare(ctx context.Context, req domain.CompareRequest) (domain.CompareResult, domain.Metadata, *domain.ErrorResponse) {
+	g.calls.Add(1)
+	if g.held.Load() {
+		<-g.release
+	}
+	return g.CompareService.Compare(ctx, req)
+}
+
+func TestCompareHandler_StaleHitRefreshesOnce(t *testing.T) {
+	requestCache := newFakeRequestCache()
+	repo, _ := newTestCatalog(t)
+	compare := &gatedCompare{CompareService: service.NewCompareService(repo, zap.NewNop()), release: make(chan struct{})}
+	engine := newCompareTestEngine(t, repo, compare, requestCache)
+	const target = "/api/v1/items/compare?ids=a,b"
+
+	if miss := serve(engine, http.MethodGet, target, nil); miss.Header().Get("Cache-Status") != "miss" {
+		t.Fatalf("Expected a miss, got %q", miss.Header().Get("Cache-Status"))
+	}
+	<-requestCache.sets
+
+	// Past the soft TTL: every request is answered from the cache while one refresh is held
+	requestCache.setStale(true)
+	compare.held.Store(true)
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recorder := serve(engine, http.MethodGet, target, nil)
+			if recorder.Code != http.StatusOK || recorder.Header().Get("Cache-Status") != "stale" {
+				t.Errorf("Expected a stale 200, got %d (%q)", recorder.Code, recorder.Header().Get("Cache-Status"))
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The refresh runs in the background: give it time to start, and any extra one time to show up
+	deadline := time.Now().Add(5 * time.Second)
+	for compare.calls.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if calls := compare.calls.Load(); calls != 2 {
+		t.Errorf("Expected the miss and a single refresh, got %d comparisons", calls)
+	}
+
+	close(compare.release)
+	select {
+	case <-requestCache.sets:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the refresh to store the comparison")
+	}
+	if calls := compare.calls.Load(); calls != 2 {
+		t.Errorf("Expected a single refresh, got %d comparisons", calls)
+	}
+}
+
+// publishingCompare publishes a new catalog version right after every comparison, before the
+// handler stores it
+type publishingCompare struct {
+	service.CompareService
+	repo    *data.FileCatalogRepo
+	path    string
+	price   float64
+	publish bool
+}
+
+func (p *publishingCompare) Compare(ctx context.Context, req domain.CompareRequest) (domain.CompareResult, domain.Metadata, *domain.ErrorResponse) {
+	result, metadata, errResp := p.CompareService.Compare(ctx, req)
+	if !p.publish {
+		return result, metadata, errResp
+	}
+
+	previous := p.repo.CurrentSnapshot().Version.ID
+	p.price += 100
+	items := testItems()
+	items[1].Price = p.price
+	content, _ := json.Marshal(items)
+	_ = os.WriteFile(p.path, content, 0o644)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for p.repo.CurrentSnapshot().Version.ID == previous && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	return result, metadata, errResp
+}
+
+func TestCompareHandler_SkipsStoreOfSupersededVersion(t *testing.T) {
+	requestCache := newFakeRequestCache()
+	repo, path := newTestCatalog(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go repo.Watch(ctx, time.Millisecond)
+
+	compare := &publishingCompare{CompareService: service.NewCompareService(repo, zap.NewNop()), repo: repo, path: path, price: 20, publish: true}
+	engine := newCompareTestEngine(t, repo, compare, requestCache)
+	const target = "/api/v1/items/compare?ids=a,b"
+
+	// The comparison is still answered, but computed from the version the publish replaced
+	first := serve(engine, http.MethodGet, target, nil)
+	if first.Code != http.StatusOK || first.Header().Get("Cache-Status") != "miss" {
+		t.Fatalf("Expected a 200 miss, got %d (%q): %s", first.Code, first.Header().Get("Cache-Status"), first.Body)
+	}
+	select {
+	case key := <-requestCache.sets:
+		t.Fatalf("Expected the superseded comparison not to be stored, got %s", key)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Once the catalog is quiet, the comparison of the live version is computed again and stored
+	compare.publish = false
+	second := serve(engine, http.MethodGet, target, nil)
+	if second.Header().Get("Cache-Status") != "miss" {
+		t.Errorf("Expected another miss, got %q", second.Header().Get("Cache-Status"))
+	}
+	select {
+	case <-requestCache.sets:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the live comparison to be stored")
+	}
+	if !strings.Contains(second.Body.String(), `"b":120`) {
+		t.Error("Expected the comparison of the published price 120")
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	response := cache.CachedResponse{
+		Body:    []byte("identity"),
+		Encoded: map[string][]byte{cache.EncodingGzip: []byte("gzip"), cache.EncodingBrotli: []byte("br")},
+	}
+	gzipOnly := cache.CachedResponse{Body: []byte("identity"), Encoded: map[string][]byte{cache.EncodingGzip: []byte("gzip")}}
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		response       cache.CachedResponse
+		expected       string
+	}{
+		{name: "No header", acceptEncoding: "", response: response, expected: ""},
+		{name: "Brotli preferred", acceptEncoding: "gzip, br", response: response, expected: cache.EncodingBrotli},
+		{name: "Only gzip accepted", acceptEncoding: "gzip", response: response, expected: cache.EncodingGzip},
+		{name: "Brotli refused", acceptEncoding: "br;q=0, gzip;q=0.5", response: response, expected: cache.EncodingGzip},
+		{name: "Wildcard", acceptEncoding: "*", response: response, expected: cache.EncodingBrotli},
+		{name: "Variant not stored", acceptEncoding: "br", response: gzipOnly, expected: ""},
+		{name: "Unsupported coding", acceptEncoding: "deflate", response: response, expected: ""},
+		{name: "Uncompressed entry", acceptEncoding: "gzip, br", response: cache.CachedResponse{Body: []byte("identity")}, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoding, body := negotiateEncoding(tt.acceptEncoding, tt.response)
+			if encoding != tt.expected {
+				t.Errorf("Expected encoding %q, got %q", tt.expected, encoding)
+			}
+			want := tt.response.Body
+			if encoding != "" {
+				want = tt.response.Encoded[encoding]
+			}
+			if !bytes.Equal(body, want) {
+				t.Errorf("Expected the %q body, got %q", tt.expected, body)
+			}
+		})
+	}
+}
+
+func TestCompareHandler_SendsPrecompressedVariant(t *testing.T) {
+	engine := newCompareTestEngine(t, nil, nil, newFakeRequestCache(), cache.EncodingGzip, cache.EncodingBrotli)
+	const target = "/api/v1/items/compare?ids=a,b"
+
+	identity := serve(engine, http.MethodGet, target, nil)
+	if identity.Code != http.StatusOK || identity.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("Expected an uncompressed 200, got %d (%q)", identity.Code, identity.Header().Get("Content-Encoding"))
+	}
+
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		cache.EncodingGzip:   func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		cache.EncodingBrotli: func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+	}
+	for encoding, decoder := range decoders {
+		t.Run(encoding, func(t *testing.T) {
+			recorder := serve(engine, http.MethodGet, target, map[string]string{"Accept-Encoding": encoding})
+			if recorder.Header().Get("Cache-Status") != "hit" {
+				t.Errorf("Expected a hit, got %q", recorder.Header().Get("Cache-Status"))
+			}
+			if got := recorder.Header().Get("Content-Encoding"); got != encoding {
+				t.Fatalf("Expected Content-Encoding %s, got %q", encoding, got)
+			}
+			if !strings.Contains(strings.Join(recorder.Header().Values("Vary"), ","), "Accept-Encoding") {
+				t.Errorf("Expected Vary to list Accept-Encoding, got %v", recorder.Header().Values("Vary"))
+			}
+			if etag := recorder.Header().Get("ETag"); etag != encodedETag(identity.Header().Get("ETag"), encoding) {
+				t.Errorf("Expected the ETag of the %s variant, got %s", encoding, etag)
+			}
+
+			reader, err := decoder(recorder.Body)
+			if err != nil {
+				t.Fatalf("failed to open the %s body: %v", encoding, err)
+			}
+			decoded, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("failed to decode the %s body: %v", encoding, err)
+			}
+			if !bytes.Equal(decoded, identity.Body.Bytes()) {
+				t.Errorf("Expected the %s body to decode to the identity body", encoding)
+			}
+		})
+	}
+}
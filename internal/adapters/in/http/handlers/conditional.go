@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"github.com/mmedinam1600/product-comparison-api/internal/i18n"
+)
+
+// notModified sets a strong ETag derived from the catalog version, the locale of the response
+// and the inputs that select the content, and answers 304 when If-None-Match already names it.
+// Responses of an unversioned catalog get no ETag: nothing tells when their content changes.
+func notModified(c *gin.Context, version *domain.CatalogVersion, inputs ...string) bool {
+	return matchETag(c, entityTag(version, i18n.FromContext(c.Request.Context()).Locale(), inputs...))
+}
+
+// entityTag derives the strong ETag of a response from the catalog version, the locale and the inputs;
+// "" for an unversioned catalog
+func entityTag(version *domain.CatalogVersion, locale string, inputs ...string) string {
+	if version == nil {
+		return ""
+	}
+
+	hash := sha256.New()
+	for _, part := range append([]string{version.ID, locale}, inputs...) {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// encodedETag returns the ETag of a content-coded variant: the bytes differ, so the strong
+// validator must too ("<tag>-gzip"). The identity body keeps the plain ETag.
+func encodedETag(etag, encoding string) string {
+	if etag == "" || encoding == "" {
+		return etag
+	}
+	return strings.TrimSuffix(etag, `"`) + "-" + encoding + `"`
+}
+
+// matchETag sets the ETag, if any, and answers 304 when If-None-Match already names it
+func matchETag(c *gin.Context, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	c.Header("ETag", etag)
+
+	if !etagMatches(c.GetHeader("If-None-Match"), etag) {
+		return false
+	}
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// requestNotModified is notModified for the read endpoints, whose content is selected by
+// the tenant, the path and the query string (in canonical order)
+func requestNotModified(c *gin.Context, version *domain.CatalogVersion) bool {
+	return notModified(c, version, requestTenant(c).ID, c.Request.URL.Path, c.Request.URL.Query().Encode())
+}
+
+// etagMatches reports whether an If-None-Match header names the ETag; as the header requires,
+// weak validators (W/"...") match their strong counterpart
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/mmedinam1600/product-comparison-api/internal/cache"
+)
+
+// encodingPreference lists the precompressed codings the server prefers, smallest first
+var encodingPreference = []string{cache.EncodingBrotli, cache.EncodingGzip}
+
+// negotiateEncoding picks the variant of the response to send: the first coding of
+// encodingPreference the Accept-Encoding header accepts (q > 0, by name or "*") and the
+// response has. Returns "" and the identity body when none applies.
+func negotiateEncoding(acceptEncoding string, response cache.CachedResponse) (string, []byte) {
+	if acceptEncoding == "" || len(response.Encoded) == 0 {
+		return "", response.Body
+	}
+
+	accepted := parseAcceptEncoding(acceptEncoding)
+	for _, encoding := range encodingPreference {
+		body, available := response.Encoded[encoding]
+		if !available {
+			continue
+		}
+		quality, listed := accepted[encoding]
+		if !listed {
+			quality, listed = accepted["*"]
+		}
+		if listed && quality > 0 {
+			return encoding, body
+		}
+	}
+	return "", response.Body
+}
+
+// parseAcceptEncoding returns the quality of every coding of an Accept-Encoding header;
+// codings with an invalid q are ignored
+func parseAcceptEncoding(header string) map[string]float64 {
+	accepted := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			q, err := strconv.ParseFloat(value, 64)
+			if err != nil || q < 0 || q > 1 {
+				continue
+			}
+			quality = q
+		}
+		if coding = strings.ToLower(strings.TrimSpace(coding)); coding != "" {
+			accepted[coding] = quality
+		}
+	}
+	return accepted
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+)
+
+// parseItemFilter builds an item filter from the query string:
+//
+//	price.min=10&price.max=50            numeric range on a root field (price, rating)
+//	spec.wireless=true                   equality on a specification
+//	spec.switch_type=red,blue            any of several values (also repeatable)
+//	spec.sensor_dpi.min=8000             numeric range on a specification
+//	availability=in_stock,backorder      availability status
+//	in_stock=true                        only items in stock
+//
+// Parameters that are not filters (limit, cursor, sort, ...) are ignored.
+func parseItemFilter(query url.Values) (domain.ItemFilter, error) {
+	filter := domain.ItemFilter{
+		Equals: make(map[string][]string),
+		Min:    make(map[string]float64),
+		Max:    make(map[string]float64),
+	}
+
+	for key, values := range query {
+		base, bound := key, ""
+		if strings.HasSuffix(key, ".min") || strings.HasSuffix(key, ".max") {
+			base, bound = key[:len(key)-4], key[len(key)-3:]
+		}
+
+		var field string
+		switch {
+		case base == "price" || base == "rating":
+			field = base
+		case base == "availability":
+			field = "availability.status"
+		case base == "in_stock":
+			field = "availability.in_stock"
+		case strings.HasPrefix(base, "spec.") && len(base) > len("spec."):
+			field = "specifications." + strings.TrimPrefix(base, "spec.")
+		default:
+			continue
+		}
+
+		if bound == "" {
+			for _, value := range values {
+				for _, option := range strings.Split(value, ",") {
+					if option = strings.TrimSpace(option); option != "" {
+						filter.Equals[field] = append(filter.Equals[field], option)
+					}
+				}
+			}
+			continue
+		}
+
+		number, err := strconv.ParseFloat(values[len(values)-1], 64)
+		if err != nil {
+			return domain.ItemFilter{}, fmt.Errorf("%s must be a number", key)
+		}
+		if bound == "min" {
+			filter.Min[field] = number
+		} else {
+			filter.Max[field] = number
+		}
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,349 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"github.com/mmedinam1600/product-comparison-api/internal/i18n"
+	"go.uber.org/zap"
+)
+
+// ItemHandler manages the item read requests
+type ItemHandler struct {
+	logger *zap.Logger
+}
+
+// NewItemHandler creates a new instance of the handler
+func NewItemHandler(logger *zap.Logger) *ItemHandler {
+	return &ItemHandler{
+		logger: logger,
+	}
+}
+
+// ItemMetadata describes the catalog an item was read from
+type ItemMetadata struct {
+	CatalogVersion *domain.CatalogVersion `json:"catalog_version,omitempty"`
+	Locale         string                 `json:"locale"`
+}
+
+// ItemResponse structures the response of the single item endpoint
+type ItemResponse struct {
+	Data     *domain.Item          `json:"data"`
+	Metadata *ItemMetadata         `json:"metadata"`
+	Error    *domain.ErrorResponse `json:"error"`
+}
+
+// ItemListMetadata describes a page of the item listing
+type ItemListMetadata struct {
+	Count          int                    `json:"count"`
+	Limit          int                    `json:"limit"`
+	Sort           domain.ItemSort        `json:"sort"`
+	NextCursor     *string                `json:"next_cursor"`
+	CatalogVersion *domain.CatalogVersion `json:"catalog_version,omitempty"`
+	Locale         string                 `json:"locale"`
+}
+
+// ItemListResponse structures the response of the listing endpoint
+type ItemListResponse struct {
+	Data     []domain.Item         `json:"data"`
+	Metadata *ItemListMetadata     `json:"metadata"`
+	Error    *domain.ErrorResponse `json:"error"`
+}
+
+// FacetMetadata describes the catalog the facets were computed from
+type FacetMetadata struct {
+	CatalogVersion *domain.CatalogVersion `json:"catalog_version,omitempty"`
+}
+
+// FacetResponse structures the response of the facets endpoint
+type FacetResponse struct {
+	Data     *domain.FacetSet      `json:"data"`
+	Metadata *FacetMetadata        `json:"metadata"`
+	Error    *domain.ErrorResponse `json:"error"`
+}
+
+// SearchMetadata describes the results of a search
+type SearchMetadata struct {
+	Query          string                 `json:"query"`
+	Count          int                    `json:"count"`
+	CatalogVersion *domain.CatalogVersion `json:"catalog_version,omitempty"`
+	Locale         string                 `json:"locale"`
+}
+
+// SearchResponse structures the response of the search endpoint
+type SearchResponse struct {
+	Data     []domain.SearchHit    `json:"data"`
+	Metadata *SearchMetadata       `json:"metadata"`
+	Error    *domain.ErrorResponse `json:"error"`
+}
+
+// PriceHistoryResponse structures the response of the price history endpoint
+type PriceHistoryResponse struct {
+	Data     *domain.PriceHistory  `json:"data"`
+	Metadata interface{}           `json:"metadata"`
+	Error    *domain.ErrorResponse `json:"error"`
+}
+
+// ExportErrorResponse structures the errors of the export endpoint, whose successful response
+// is the exported file itself
+type ExportErrorResponse struct {
+	Data     interface{}           `json:"data"`     // Always null
+	Metadata interface{}           `json:"metadata"` // Always null
+	Error    *domain.ErrorResponse `json:"error"`
+}
+
+// Get manages GET /api/v1/items/:id
+func (h *ItemHandler) Get(c *gin.Context) {
+	item, version, errResp := requestTenant(c).Items.Get(c.Request.Context(), c.Param("id"))
+	if errResp != nil {
+		c.JSON(errResp.ErrorCode.HTTPStatusCode(), ItemResponse{
+			Data:     nil,
+			Metadata: nil,
+			Error:    localizedError(c, errResp),
+		})
+		return
+	}
+
+	if requestNotModified(c, version) {
+		return
+	}
+
+	localizer := i18n.FromContext(c.Request.Context())
+	item = localizer.Item(item)
+	c.JSON(http.StatusOK, ItemResponse{
+		Data:     &item,
+		Metadata: &ItemMetadata{CatalogVersion: version, Locale: localizer.Locale()},
+		Error:    nil,
+	})
+}
+
+// List manages GET /api/v1/items
+func (h *ItemHandler) List(c *gin.Context) {
+	query, errResp := parseItemListQuery(c)
+	if errResp != nil {
+		c.JSON(errResp.ErrorCode.HTTPStatusCode(), ItemListResponse{Error: localizedError(c, errResp)})
+		return
+	}
+
+	page, version, errResp := requestTenant(c).Items.List(c.Request.Context(), query)
+	if errResp != nil {
+		h.logger.Info("item listing rejected", zap.String("error_code", string(errResp.ErrorCode)))
+		c.JSON(errResp.ErrorCode.HTTPStatusCode(), ItemListResponse{Error: localizedError(c, errResp)})
+		return
+	}
+
+	if requestNotModified(c, version) {
+		return
+	}
+
+	localizer := i18n.FromContext(c.Request.Context())
+	metadata := &ItemListMetadata{
+		Count:          len(page.Items),
+		Limit:          page.Limit,
+		Sort:           page.Sort,
+		CatalogVersion: version,
+		Locale:         localizer.Locale(),
+	}
+	if page.NextCursor != "" {
+		metadata.NextCursor = &page.NextCursor
+	}
+
+	c.JSON(http.StatusOK, ItemListResponse{
+		Data:     localizer.Items(page.Items),
+		Metadata: metadata,
+		Error:    nil,
+	})
+}
+
+// Facets manages GET /api/v1/items/facets
+func (h *ItemHandler) Facets(c *gin.Context) {
+	query, errResp := parseFacetQuery(c)
+	if errResp != nil {
+		c.JSON(errResp.ErrorCode.HTTPStatusCode(), FacetResponse{Error: localizedError(c, errResp)})
+		return
+	}
+
+	set, version, errResp := requestTenant(c).Items.Facets(c.Request.Context(), query)
+	if errResp != nil {
+		c.JSON(errResp.ErrorCode.HTTPStatusCode(), FacetResponse{Error: localizedError(c, errResp)})
+		return
+	}
+
+	if requestNotModified(c, version) {
+		return
+	}
+
+	c.JSON(http.StatusOK, FacetResponse{
+		Data:     &set,
+		Metadata: &FacetMetadata{CatalogVersion: version},
+		Error:    nil,
+	})
+}
+
+// Search manages GET /api/v1/items/search
+func (h *ItemHandler) Search(c *gin.Context) {
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, SearchResponse{Error: localizedError(c, &domain.ErrorResponse{
+				ErrorCode: domain.ErrorCodeInvalidRequest,
+				Message:   "limit must be a positive integer.",
+			})})
+			return
+		}
+		limit = parsed
+	}
+
+	query := c.Query("q")
+	hits, version, errResp := requestTenant(c).Items.Search(c.Request.Context(), query, limit)
+	if errResp != nil {
+		c.JSON(errResp.ErrorCode.HTTPStatusCode(), SearchResponse{Error: localizedError(c, errResp)})
+		return
+	}
+
+	if requestNotModified(c, version) {
+		return
+	}
+
+	localizer := i18n.FromContext(c.Request.Context())
+	for i := range hits {
+		hits[i].Item = localizer.Item(hits[i].Item)
+	}
+
+	c.JSON(http.StatusOK, SearchResponse{
+		Data: hits,
+		Metadata: &SearchMetadata{
+			Query:          query,
+			Count:          len(hits),
+			CatalogVersion: version,
+			Locale:         localizer.Locale(),
+		},
+		Error: nil,
+	})
+}
+
+// PriceHistory manages GET /api/v1/items/:id/price-history
+func (h *ItemHandler) PriceHistory(c *gin.Context) {
+	history, errResp := requestTenant(c).Items.PriceHistory(c.Request.Context(), c.Param("id"))
+	if errResp != nil {
+		c.JSON(errResp.ErrorCode.HTTPStatusCode(), PriceHistoryResponse{
+			Data:     nil,
+			Metadata: nil,
+			Error:    localizedError(c, errResp),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, PriceHistoryResponse{
+		Data:     &history,
+		Metadata: nil,
+		Error:    nil,
+	})
+}
+
+// Export manages GET /api/v1/items/export. The feed is streamed as the response body,
+// so errors while writing can only be logged.
+func (h *ItemHandler) Export(c *gin.Context) {
+	filter, err := parseItemFilter(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ExportErrorResponse{Error: localizedError(c, &domain.ErrorResponse{
+			ErrorCode: domain.ErrorCodeInvalidRequest,
+			Message:   "Invalid filter: " + err.Error() + ".",
+		})})
+		return
+	}
+
+	export, errResp := requestTenant(c).Items.Export(c.Request.Context(), c.Query("format"), filter)
+	if errResp != nil {
+		c.JSON(errResp.ErrorCode.HTTPStatusCode(), ExportErrorResponse{Error: localizedError(c, errResp)})
+		return
+	}
+
+	if requestNotModified(c, export.Version) {
+		return
+	}
+
+	filename := "catalog." + export.Format
+	if export.Version != nil {
+		c.Header("X-Catalog-Version", export.Version.ID)
+		c.Header("X-Catalog-Content-Hash", export.Version.ContentHash)
+		filename = "catalog-" + export.Version.ID + "." + export.Format
+	}
+	c.Header("X-Item-Count", strconv.Itoa(export.Count))
+	c.Header("Content-Type", export.ContentType)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	if err := export.Write(c.Writer); err != nil {
+		h.logger.Warn("catalog export interrupted", zap.String("format", export.Format), zap.Error(err))
+	}
+}
+
+// parseItemListQuery reads limit, cursor, sort and the filters of the listing
+func parseItemListQuery(c *gin.Context) (domain.ItemListQuery, *domain.ErrorResponse) {
+	query := domain.ItemListQuery{
+		Sort:   domain.ItemSort(c.Query("sort")),
+		Cursor: c.Query("cursor"),
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return query, &domain.ErrorResponse{
+				ErrorCode: domain.ErrorCodeInvalidRequest,
+				Message:   "limit must be a positive integer.",
+			}
+		}
+		query.Limit = limit
+	}
+
+	filter, err := parseItemFilter(c.Request.URL.Query())
+	if err != nil {
+		return query, &domain.ErrorResponse{
+			ErrorCode: domain.ErrorCodeInvalidRequest,
+			Message:   "Invalid filter: " + err.Error() + ".",
+		}
+	}
+	query.Filter = filter
+
+	return query, nil
+}
+
+// parseFacetQuery reads the facet fields, the histogram buckets and the filters
+func parseFacetQuery(c *gin.Context) (domain.FacetQuery, *domain.ErrorResponse) {
+	query := domain.FacetQuery{}
+
+	if raw := c.Query("fields"); raw != "" {
+		for _, field := range strings.Split(raw, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				query.Fields = append(query.Fields, field)
+			}
+		}
+	}
+
+	if raw := c.Query("buckets"); raw != "" {
+		buckets, err := strconv.Atoi(raw)
+		if err != nil || buckets < 1 {
+			return query, &domain.ErrorResponse{
+				ErrorCode: domain.ErrorCodeInvalidRequest,
+				Message:   "buckets must be a positive integer.",
+			}
+		}
+		query.Buckets = buckets
+	}
+
+	filter, err := parseItemFilter(c.Request.URL.Query())
+	if err != nil {
+		return query, &domain.ErrorResponse{
+			ErrorCode: domain.ErrorCodeInvalidRequest,
+			Message:   "Invalid filter: " + err.Error() + ".",
+		}
+	}
+	query.Filter = filter
+
+	return query, nil
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/goccy/go-json"
+	"github.com/mmedinam1600/product-comparison-api/internal/adapters/in/http/middleware"
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"github.com/mmedinam1600/product-comparison-api/internal/i18n"
+	"github.com/mmedinam1600/product-comparison-api/internal/service"
+	"github.com/mmedinam1600/product-comparison-api/internal/tenant"
+	"go.uber.org/zap"
+)
+
+// newItemTestEngine routes the export endpoint like the router does, over the test catalog
+func newItemTestEngine(t *testing.T) *gin.Engine {
+	t.Helper()
+	logger := zap.NewNop()
+
+	bundle, err := i18n.NewBundle("en", []string{"es-MX"})
+	if err != nil {
+		t.Fatalf("NewBundle() error = %v", err)
+	}
+	repo, _ := newTestCatalog(t)
+	tenants, err := tenant.NewRegistry([]*tenant.Tenant{{ID: tenant.DefaultID, Repo: repo, Items: service.NewItemService(repo, nil, logger)}}, tenant.DefaultID)
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+
+	handler := NewItemHandler(logger)
+	engine := gin.New()
+	items := engine.Group("/api/v1/items", middleware.LocaleMiddleware(bundle), middleware.TenantMiddleware(tenants, "", logger))
+	items.GET("/export", handler.Export)
+	return engine
+}
+
+func TestItemHandler_ExportErrors(t *testing.T) {
+	engine := newItemTestEngine(t)
+
+	tests := []struct {
+		name     string
+		query    string
+		expected domain.ErrorCode
+	}{
+		{name: "Unknown format", query: "format=xml", expected: domain.ErrorCodeInvalidRequest},
+		{name: "Invalid filter", query: "price.min=cheap", expected: domain.ErrorCodeInvalidRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := serve(engine, http.MethodGet, "/api/v1/items/export?"+tt.query, nil)
+			if recorder.Code != http.StatusBadRequest {
+				t.Fatalf("Expected 400, got %d: %s", recorder.Code, recorder.Body)
+			}
+			if !strings.HasPrefix(recorder.Header().Get("Content-Type"), "application/json") || recorder.Header().Get("Content-Disposition") != "" {
+				t.Errorf("Expected a JSON error, not a file, got headers %v", recorder.Header())
+			}
+
+			// The envelope of every error: no listing fields, data and metadata null
+			var envelope map[string]json.RawMessage
+			if err := json.Unmarshal(recorder.Body.Bytes(), &envelope); err != nil {
+				t.Fatalf("Expected a JSON envelope, got %s", recorder.Body)
+			}
+			if len(envelope) != 3 || string(envelope["data"]) != "null" || string(envelope["metadata"]) != "null" {
+				t.Errorf("Expected only null data and metadata besides the error, got %s", recorder.Body)
+			}
+			if code := decodeError(t, recorder.Body.Bytes()); code != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, code)
+			}
+		})
+	}
+
+	// A valid export is the file itself
+	recorder := serve(engine, http.MethodGet, "/api/v1/items/export?format=csv", nil)
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Header().Get("Content-Disposition"), ".csv") {
+		t.Errorf("Expected a CSV attachment, got %d (%v)", recorder.Code, recorder.Header())
+	}
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"github.com/mmedinam1600/product-comparison-api/internal/i18n"
+)
+
+// localizeComparison returns copies of a comparison in the locale of the request.
+// The comparison is localized before it is encoded, so each locale caches its own entry
+// (see comparisonKey).
+func localizeComparison(l *i18n.Localizer, result *domain.CompareResult, metadata *domain.Metadata) (*domain.CompareResult, *domain.Metadata) {
+	localizedResult := *result
+	localizedResult.Items = l.Items(result.Items)
+
+	localizedMetadata := *metadata
+	localizedMetadata.Summary = l.Message(metadata.Summary)
+	localizedMetadata.Locale = l.Locale()
+	if len(metadata.ComparePolicy.Warnings) > 0 {
+		localizedMetadata.ComparePolicy.Warnings = make([]domain.Warning, len(metadata.ComparePolicy.Warnings))
+		for i, warning := range metadata.ComparePolicy.Warnings {
+			warning.Message = l.Message(warning.Message)
+			localizedMetadata.ComparePolicy.Warnings[i] = warning
+		}
+	}
+
+	return &localizedResult, &localizedMetadata
+}
+
+// localizedError returns the error with its message in the locale of the request
+func localizedError(c *gin.Context, errResp *domain.ErrorResponse) *domain.ErrorResponse {
+	return i18n.FromContext(c.Request.Context()).Error(errResp)
+}
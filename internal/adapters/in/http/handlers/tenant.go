@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/mmedinam1600/product-comparison-api/internal/tenant"
+)
+
+// requestTenant returns the tenant resolved by middleware.TenantMiddleware.
+// Its absence is a wiring error of the router, not a client error.
+func requestTenant(c *gin.Context) *tenant.Tenant {
+	t, ok := tenant.FromContext(c.Request.Context())
+	if !ok {
+		panic("handlers: tenant middleware is not installed")
+	}
+	return t
+}
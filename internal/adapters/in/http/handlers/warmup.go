@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/mmedinam1600/product-comparison-api/internal/cache"
+	"github.com/mmedinam1600/product-comparison-api/internal/i18n"
+	"github.com/mmedinam1600/product-comparison-api/internal/tenant"
+	"go.uber.org/zap"
+)
+
+// Warm precomputes comparisons into the request cache, in order, until ctx is done. Each runs
+// what a miss runs (coalesced with concurrent requests of the same key), so the first requests
+// after a deploy or a catalog reload are hits. Comparisons of unknown tenants, already fresh in
+// the cache or that fail are skipped. Returns the number of comparisons computed.
+func (h *CompareHandler) Warm(ctx context.Context, comparisons []cache.PopularComparison, tenants *tenant.Registry, locales *i18n.Bundle) int {
+	warmed := 0
+	for _, popular := range comparisons {
+		if ctx.Err() != nil {
+			break
+		}
+
+		t, err := tenants.Get(popular.Tenant)
+		if err != nil {
+			h.logger.Debug("skipped warm-up of an unknown tenant", zap.String("tenant", popular.Tenant))
+			continue
+		}
+		localizer := locales.Localizer(popular.Locale)
+		localized := i18n.NewContext(ctx, localizer)
+		req := popular.Request
+
+		cacheKey := comparisonKey(t, localizer, req)
+		if cached, found := h.requestCache.Get(localized, cacheKey); found && !cached.Stale {
+			continue
+		}
+
+		// Bounded by ctx between comparisons only: one cancelled midway would fail the requests coalesced with it
+		outcome, _ := h.flights.Do(cacheKey, func() comparison {
+			return h.compare(context.WithoutCancel(localized), t, req, cacheKey, true)
+		})
+		if outcome.errResp != nil {
+			h.logger.Debug("skipped warm-up of a failing comparison",
+				zap.String("cache_key", cacheKey),
+				zap.String("error_code", string(outcome.errResp.ErrorCode)),
+			)
+			continue
+		}
+		warmed++
+	}
+	return warmed
+}
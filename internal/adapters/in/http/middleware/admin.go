@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"go.uber.org/zap"
+)
+
+// AdminAuthMiddleware lets through the requests that carry the admin token
+// as "Authorization: Bearer <token>"
+func AdminAuthMiddleware(token string, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sent, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+			logger.Warn("unauthorized admin request",
+				zap.String("path", c.Request.URL.Path),
+				zap.String("client_ip", c.ClientIP()),
+			)
+			c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+			abortWithError(c, http.StatusUnauthorized, domain.ErrorResponse{
+				ErrorCode: domain.ErrorCodeUnauthorized,
+				Message:   "Missing or invalid admin token.",
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CacheControlMiddleware sets the Cache-Control header of every response: the value configured
+// for its route path (e.g. "/api/v1/items/:id") or defaultValue. Error responses are never stored.
+func CacheControlMiddleware(defaultValue string, routes map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, exists := routes[c.FullPath()]
+		if !exists {
+			value = defaultValue
+		}
+		if value != "" {
+			c.Header("Cache-Control", value)
+		}
+		c.Writer = &cacheControlWriter{ResponseWriter: c.Writer}
+		c.Next()
+	}
+}
+
+// cacheControlWriter replaces the Cache-Control header of error responses with no-store
+type cacheControlWriter struct {
+	gin.ResponseWriter
+}
+
+func (w *cacheControlWriter) WriteHeader(code int) {
+	if code >= http.StatusBadRequest {
+		w.Header().Set("Cache-Control", "no-store")
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
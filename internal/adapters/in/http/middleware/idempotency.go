@@ -8,13 +8,14 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/mmedinam1600/product-comparison-api/internal/cache"
 	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"github.com/mmedinam1600/product-comparison-api/internal/tenant"
 	"go.uber.org/zap"
 )
 
 const IdempotencyKeyHeader = "Idempotency-Key"
 
 // IdempotencyMiddleware manages the idempotency of requests
-func IdempotencyMiddleware(idempotencyCache *cache.IdempotencyCache, logger *zap.Logger) gin.HandlerFunc {
+func IdempotencyMiddleware(idempotencyCache cache.IdempotencyCache, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Check if there is an Idempotency-Key header
 		idempotencyKey := c.GetHeader(IdempotencyKeyHeader)
@@ -24,19 +25,19 @@ func IdempotencyMiddleware(idempotencyCache *cache.IdempotencyCache, logger *zap
 			return
 		}
 
+		// Keys are namespaced by tenant so storefronts never share responses
+		if t, ok := tenant.FromContext(c.Request.Context()); ok {
+			idempotencyKey = cache.NamespacedKey(t.ID, idempotencyKey)
+		}
+
 		// Read the body of the request
 		body, err := io.ReadAll(c.Request.Body)
 		if err != nil {
 			logger.Error("failed to read request body", zap.Error(err))
-			c.JSON(http.StatusBadRequest, gin.H{
-				"data":     nil,
-				"metadata": nil,
-				"error": domain.ErrorResponse{
-					ErrorCode: domain.ErrorCodeInvalidRequest,
-					Message:   "Failed to read request body.",
-				},
+			abortWithError(c, http.StatusBadRequest, domain.ErrorResponse{
+				ErrorCode: domain.ErrorCodeInvalidRequest,
+				Message:   "Failed to read request body.",
 			})
-			c.Abort()
 			return
 		}
 
@@ -56,21 +57,16 @@ func IdempotencyMiddleware(idempotencyCache *cache.IdempotencyCache, logger *zap
 					zap.String("expected_hash", entry.BodyHash),
 					zap.String("received_hash", bodyHash),
 				)
-				c.JSON(http.StatusConflict, gin.H{
-					"data":     nil,
-					"metadata": nil,
-					"error": domain.ErrorResponse{
-						ErrorCode: domain.ErrorCodeConflict,
-						Message:   "Request with same Idempotency-Key but different body already exists.",
-					},
+				abortWithError(c, http.StatusConflict, domain.ErrorResponse{
+					ErrorCode: domain.ErrorCodeConflict,
+					Message:   "Request with same Idempotency-Key but different body already exists.",
 				})
-				c.Abort()
 				return
 			}
 
-			// Body equal → return cached response
+			// Body equal → return cached response, byte for byte
 			logger.Info("returning cached idempotent response", zap.String("key", idempotencyKey))
-			c.JSON(http.StatusOK, entry.Response)
+			c.Data(http.StatusOK, cache.ContentTypeJSON, entry.Response)
 			c.Abort()
 			return
 		}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/mmedinam1600/product-comparison-api/internal/i18n"
+)
+
+// LocaleMiddleware negotiates the locale of the request from Accept-Language
+// and stores its localizer in the request context
+func LocaleMiddleware(bundle *i18n.Bundle) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		localizer := bundle.Localizer(c.GetHeader("Accept-Language"))
+
+		c.Header("Content-Language", localizer.Locale())
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Request = c.Request.WithContext(i18n.NewContext(c.Request.Context(), localizer))
+		c.Next()
+	}
+}
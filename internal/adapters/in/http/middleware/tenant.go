@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"github.com/mmedinam1600/product-comparison-api/internal/i18n"
+	"github.com/mmedinam1600/product-comparison-api/internal/tenant"
+	"go.uber.org/zap"
+)
+
+// DefaultTenantHeader is the header that names the tenant when none is configured
+const DefaultTenantHeader = "X-Tenant-ID"
+
+// TenantMiddleware resolves the tenant of the request from the header or the host
+// and stores it in the request context
+func TenantMiddleware(registry *tenant.Registry, header string, logger *zap.Logger) gin.HandlerFunc {
+	if header == "" {
+		header = DefaultTenantHeader
+	}
+
+	return func(c *gin.Context) {
+		// The tenant header selects the content, like Accept-Language
+		c.Writer.Header().Add("Vary", header)
+		t, found, err := registry.Resolve(c.GetHeader(header), c.Request.Host)
+
+		var unknown *tenant.UnknownTenantError
+		if errors.As(err, &unknown) {
+			logger.Warn("unknown tenant", zap.String("tenant", unknown.ID))
+			abortWithError(c, http.StatusNotFound, domain.ErrorResponse{
+				ErrorCode: domain.ErrorCodeUnknownTenant,
+				Message:   "Unknown tenant '" + unknown.ID + "'.",
+			})
+			return
+		}
+		if !found {
+			abortWithError(c, http.StatusBadRequest, domain.ErrorResponse{
+				ErrorCode: domain.ErrorCodeMissingField,
+				Message:   "Missing tenant header '" + header + "'.",
+			})
+			return
+		}
+
+		c.Header(header, t.ID)
+		c.Request = c.Request.WithContext(tenant.NewContext(c.Request.Context(), t))
+		c.Next()
+	}
+}
+
+// abortWithError writes the error envelope, in the locale of the request, and stops the chain
+func abortWithError(c *gin.Context, status int, errResp domain.ErrorResponse) {
+	c.JSON(status, gin.H{
+		"data":     nil,
+		"metadata": nil,
+		"error":    i18n.FromContext(c.Request.Context()).Error(&errResp),
+	})
+	c.Abort()
+}
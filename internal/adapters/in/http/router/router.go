@@ -7,14 +7,27 @@ import (
 	"github.com/mmedinam1600/product-comparison-api/internal/adapters/in/http/handlers"
 	"github.com/mmedinam1600/product-comparison-api/internal/adapters/in/http/middleware"
 	"github.com/mmedinam1600/product-comparison-api/internal/cache"
+	"github.com/mmedinam1600/product-comparison-api/internal/i18n"
+	"github.com/mmedinam1600/product-comparison-api/internal/metrics"
+	"github.com/mmedinam1600/product-comparison-api/internal/tenant"
 	"go.uber.org/zap"
 )
 
 type Options struct {
-	Mode             string                   // "debug" | "release" | "test"
-	CompareHandler   *handlers.CompareHandler // Handler for comparison
-	IdempotencyCache *cache.IdempotencyCache  // Idempotency cache
-	Logger           *zap.Logger              // Logger
+	Mode               string                      // "debug" | "release" | "test"
+	CompareHandler     *handlers.CompareHandler    // Handler for comparison
+	CatalogHandler     *handlers.CatalogHandler    // Handler for catalog introspection
+	ItemHandler        *handlers.ItemHandler       // Handler for item reads
+	CacheAdminHandler  *handlers.CacheAdminHandler // Handler for cache administration
+	AdminToken         string                      // Bearer token of the admin routes; empty disables them
+	IdempotencyCache   cache.IdempotencyCache      // Idempotency cache
+	Tenants            *tenant.Registry            // Storefronts served by the deployment
+	TenantHeader       string                      // Header that names the tenant
+	Locales            *i18n.Bundle                // Locales negotiated from Accept-Language
+	CacheControl       string                      // Cache-Control of the v1 responses
+	CacheControlRoutes map[string]string           // Cache-Control by route path, overriding CacheControl
+	Ready              func() bool                 // Readiness (false while the cache warms up); nil is always ready
+	Logger             *zap.Logger                 // Logger
 }
 
 func NewEngine(opts Options) *gin.Engine {
@@ -24,6 +37,9 @@ func NewEngine(opts Options) *gin.Engine {
 	// Logger every request and add error recovery handler
 	router.Use(gin.Logger(), gin.Recovery())
 
+	// Prometheus metrics (scraped by deployment/prometheus)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
 	// API base group
 	api := router.Group("/api")
 
@@ -32,17 +48,97 @@ func NewEngine(opts Options) *gin.Engine {
 		c.JSON(http.StatusOK, gin.H{"status": "OK"})
 	})
 
+	// Readiness probe: 503 until the startup warm-up of the request cache finishes
+	api.GET("/ready", func(c *gin.Context) {
+		if opts.Ready != nil && !opts.Ready() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "WARMING_UP"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "OK"})
+	})
+
 	// V1 API group
+	// Every v1 route answers in the negotiated locale and reads the catalog of the tenant of the request
 	v1 := api.Group("/v1")
+	v1.Use(
+		middleware.CacheControlMiddleware(opts.CacheControl, opts.CacheControlRoutes),
+		middleware.LocaleMiddleware(opts.Locales),
+		middleware.TenantMiddleware(opts.Tenants, opts.TenantHeader, opts.Logger),
+	)
 	{
-		// Items group w middleware idempotency
+		// Items group
 		items := v1.Group("/items")
-		items.Use(middleware.IdempotencyMiddleware(opts.IdempotencyCache, opts.Logger))
 		{
-			// POST /api/v1/items/compare
-			items.POST("/compare", opts.CompareHandler.Compare)
+			// POST /api/v1/items/compare, the only route idempotent by body: reads have none to hash
+			items.POST("/compare", middleware.IdempotencyMiddleware(opts.IdempotencyCache, opts.Logger), opts.CompareHandler.Compare)
+
+			// GET /api/v1/items/compare
+			items.GET("/compare", opts.CompareHandler.CompareByQuery)
+
+			// GET /api/v1/items
+			items.GET("", opts.ItemHandler.List)
+
+			// GET /api/v1/items/facets
+			items.GET("/facets", opts.ItemHandler.Facets)
+
+			// GET /api/v1/items/search
+			items.GET("/search", opts.ItemHandler.Search)
+
+			// GET /api/v1/items/export
+			items.GET("/export", opts.ItemHandler.Export)
+
+			// GET /api/v1/items/:id
+			items.GET("/:id", opts.ItemHandler.Get)
+
+			// GET /api/v1/items/:id/price-history
+			items.GET("/:id/price-history", opts.ItemHandler.PriceHistory)
+		}
+
+		catalog := v1.Group("/catalog")
+		{
+			// GET /api/v1/catalog/versions
+			catalog.GET("/versions", opts.CatalogHandler.Versions)
+
+			// GET /api/v1/catalog/changes
+			catalog.GET("/changes", opts.CatalogHandler.Changes)
+		}
+	}
+
+	// Admin API group: operators only, never stored by caches
+	if opts.AdminToken != "" {
+		admin := api.Group("/admin")
+		admin.Use(
+			middleware.CacheControlMiddleware("no-store", nil),
+			middleware.AdminAuthMiddleware(opts.AdminToken, opts.Logger),
+		)
+		{
+			// GET /api/admin/caches
+			admin.GET("/caches", opts.CacheAdminHandler.Stats)
+
+			// GET /api/admin/caches/:cache/keys
+			admin.GET("/caches/:cache/keys", opts.CacheAdminHandler.Keys)
+
+			// DELETE /api/admin/caches/:cache
+			admin.DELETE("/caches/:cache", opts.CacheAdminHandler.Purge)
+		}
+	}
+
+	// A Cache-Control override of a path that is not routed is a typo in the configuration
+	for path := range opts.CacheControlRoutes {
+		if !routed(router, path) {
+			opts.Logger.Warn("Cache-Control configured for an unknown route", zap.String("path", path))
 		}
 	}
 
 	return router
 }
+
+// routed reports whether any method is routed at the path
+func routed(router *gin.Engine, path string) bool {
+	for _, route := range router.Routes() {
+		if route.Path == path {
+			return true
+		}
+	}
+	return false
+}
@@ -1,22 +1,44 @@
 package app
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"sync/atomic"
+	"time"
 
 	"github.com/mmedinam1600/product-comparison-api/internal/adapters/in/http/handlers"
 	"github.com/mmedinam1600/product-comparison-api/internal/adapters/in/http/router"
 	"github.com/mmedinam1600/product-comparison-api/internal/cache"
 	"github.com/mmedinam1600/product-comparison-api/internal/data"
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"github.com/mmedinam1600/product-comparison-api/internal/i18n"
+	"github.com/mmedinam1600/product-comparison-api/internal/metrics"
 	"github.com/mmedinam1600/product-comparison-api/internal/service"
 	"github.com/mmedinam1600/product-comparison-api/internal/shared/config"
+	"github.com/mmedinam1600/product-comparison-api/internal/tenant"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
 
 type App struct {
 	HTTPServer       *http.Server
 	Logger           *zap.Logger
-	RequestCache     *cache.RequestCache
-	IdempotencyCache *cache.IdempotencyCache
+	Tenants          *tenant.Registry
+	RequestCache     cache.RequestCache
+	IdempotencyCache cache.IdempotencyCache
+
+	// redisClient is shared by the caches when CACHE_BACKEND=redis
+	redisClient *redis.Client
+
+	// popular counts the cached comparisons; saved on Shutdown for the warm-up of the next start
+	popular *cache.PopularComparisons
+
+	// cacheSnapshotFile receives the in-process caches on Shutdown; empty when disabled
+	cacheSnapshotFile string
+
+	// stopBackground cancels the background workers (e.g. catalog hot reload)
+	stopBackground context.CancelFunc
 }
 
 // Bootstrap initializes all the components of the application
@@ -39,44 +61,121 @@ func Bootstrap(cfg config.Config) (*App, error) {
 		zap.String("port", cfg.Port),
 	)
 
-	// === 2. Initialize Catalog Repository ===
-	catalogRepo, err := data.NewFileCatalogRepo(cfg.DataFile, logger)
+	// Background workers live until Shutdown
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+
+	// === 2. Initialize Exchange Rates (optional, shared by every storefront) ===
+	var rates *data.FileExchangeRates
+	var rateSource data.ExchangeRateSource // Stays nil (not a nil *FileExchangeRates) when disabled
+	if cfg.ExchangeRatesFile != "" {
+		rates, err = data.NewFileExchangeRates(cfg.ExchangeRatesFile, logger)
+		if err != nil {
+			stopBackground()
+			logger.Fatal("failed to initialize exchange rates", zap.Error(err))
+			return nil, err
+		}
+		if cfg.ExchangeRatesReloadInterval > 0 {
+			go rates.Watch(backgroundCtx, cfg.ExchangeRatesReloadInterval)
+		}
+		rateSource = rates
+	}
+
+	// === 3. Initialize Tenants (catalog, price history and services of each storefront) ===
+	tenants, err := newTenantRegistry(backgroundCtx, cfg, rateSource, logger)
 	if err != nil {
-		logger.Fatal("failed to initialize catalog repository", zap.Error(err))
+		stopBackground()
+		logger.Fatal("failed to initialize tenants", zap.Error(err))
 		return nil, err
 	}
 
-	// === 3. Initialize Request Cache ===
-	requestCache, err := cache.NewRequestCache(cfg.CacheSize, cfg.CacheTTL, logger)
+	// === 4. Initialize Request and Idempotency Caches (CACHE_BACKEND) ===
+	requestCache, idempotencyCache, redisClient, err := newCaches(backgroundCtx, cfg, logger)
 	if err != nil {
-		logger.Fatal("failed to initialize request cache", zap.Error(err))
+		logger.Fatal("failed to initialize caches", zap.Error(err))
 		return nil, err
 	}
 
-	// === 4. Initialize Idempotency Cache ===
-	idempotencyCache, err := cache.NewIdempotencyCache(cfg.IdempotencySize, cfg.IdempotencyTTL, logger)
+	// Entries the previous process saved on shutdown (in-process caches only: Redis outlives the process)
+	cacheSnapshotFile := cfg.CacheSnapshotFile
+	if cacheSnapshotFile != "" {
+		_, requestsSnapshot := requestCache.(cache.RequestSnapshotter)
+		_, idempotencySnapshot := idempotencyCache.(cache.IdempotencySnapshotter)
+		if requestsSnapshot || idempotencySnapshot {
+			restoreCacheSnapshot(cacheSnapshotFile, requestCache, idempotencyCache, tenants, logger)
+		} else {
+			logger.Warn("CACHE_SNAPSHOT_FILE ignored: the cache backend outlives the process", zap.String("backend", cfg.CacheBackend))
+			cacheSnapshotFile = ""
+		}
+	}
+
+	compressor, err := cache.NewCompressor(cfg.CacheCompression)
+	if err != nil {
+		logger.Fatal("invalid CACHE_COMPRESSION", zap.Error(err))
+		return nil, err
+	}
+	popular, err := cache.NewPopularComparisons(cfg.WarmupFile, cfg.WarmupMaxEntries, logger)
 	if err != nil {
-		logger.Fatal("failed to initialize idempotency cache", zap.Error(err))
+		logger.Fatal("failed to initialize popular comparisons", zap.Error(err))
 		return nil, err
 	}
+	if cfg.WarmupFile != "" && cfg.WarmupSaveInterval > 0 {
+		go popular.Persist(backgroundCtx, cfg.WarmupSaveInterval)
+	}
 
-	// === 5. Initialize Compare Service ===
-	compareService := service.NewCompareService(catalogRepo, logger)
+	// === 5. Evict the cached comparisons of the items changed by a catalog reload ===
+	for _, t := range tenants.All() {
+		if versioned, ok := t.Repo.(data.VersionedCatalog); ok {
+			invalidateOnCatalogChange(backgroundCtx, t.ID, versioned, requestCache, logger.With(zap.String("tenant", t.ID)))
+		}
+	}
+
+	// ...and the converted comparisons when the exchange rates change
+	if rates != nil {
+		rates.OnReload(func(*domain.ExchangeRates) {
+			evicted := requestCache.InvalidateItems(backgroundCtx, []string{cache.ExchangeRatesTag})
+			logger.Info("exchange rates changed", zap.Int("evicted_cache_entries", evicted))
+		})
+	}
 
 	// === 6. Inicializar Handler ===
 	compareHandler := handlers.NewCompareHandler(
-		compareService,
 		requestCache,
 		idempotencyCache,
+		compressor,
+		popular,
 		logger,
 	)
+	catalogHandler := handlers.NewCatalogHandler(logger)
+	itemHandler := handlers.NewItemHandler(logger)
+	cacheAdminHandler := handlers.NewCacheAdminHandler(requestCache, idempotencyCache, tenants, logger)
+	if cfg.AdminToken == "" {
+		logger.Info("admin API disabled: ADMIN_TOKEN is not set")
+	}
+
+	locales, err := i18n.NewBundle(cfg.DefaultLocale, cfg.SupportedLocales)
+	if err != nil {
+		logger.Fatal("failed to load locales", zap.Error(err))
+		return nil, err
+	}
+	logger.Info("locales loaded", zap.Strings("supported", locales.Supported()))
 
 	// === 7. Create HTTP Engine ===
+	ready := new(atomic.Bool)
 	engine := router.NewEngine(router.Options{
-		Mode:             cfg.GinMode,
-		CompareHandler:   compareHandler,
-		IdempotencyCache: idempotencyCache,
-		Logger:           logger,
+		Mode:               cfg.GinMode,
+		CompareHandler:     compareHandler,
+		CatalogHandler:     catalogHandler,
+		ItemHandler:        itemHandler,
+		CacheAdminHandler:  cacheAdminHandler,
+		AdminToken:         cfg.AdminToken,
+		IdempotencyCache:   idempotencyCache,
+		Tenants:            tenants,
+		TenantHeader:       cfg.TenantHeader,
+		Locales:            locales,
+		CacheControl:       cfg.HTTPCacheControl,
+		CacheControlRoutes: cfg.HTTPCacheControlRoutes,
+		Ready:              ready.Load,
+		Logger:             logger,
 	})
 
 	// === 8. Configure HTTP Server with timeouts ===
@@ -88,13 +187,38 @@ func Bootstrap(cfg config.Config) (*App, error) {
 		IdleTimeout:  cfg.IdleTimeout,
 	}
 
+	// === 9. Warm the request cache up with the popular comparisons ===
+	// At startup /api/ready answers 503 until it finishes. After a catalog reload (which evicts the
+	// comparisons of the changed items) it recomputes the evicted ones in the background: all replicas
+	// reload at once, none leaves the pool.
+	go func() {
+		warmRequestCache(backgroundCtx, cfg.WarmupTimeout, popular.Top(), compareHandler, tenants, locales, logger)
+		ready.Store(true)
+	}()
+	for _, t := range tenants.All() {
+		if versioned, ok := t.Repo.(data.VersionedCatalog); ok {
+			versioned.OnPublish(func(previous, _ *data.Snapshot) {
+				if previous == nil {
+					return
+				}
+				go warmRequestCache(backgroundCtx, cfg.WarmupTimeout, tenantComparisons(popular.Top(), t.ID),
+					compareHandler, tenants, locales, logger.With(zap.String("tenant", t.ID)))
+			})
+		}
+	}
+
 	logger.Info("application initialized successfully")
 
 	return &App{
-		HTTPServer:       httpServer,
-		Logger:           logger,
-		RequestCache:     requestCache,
-		IdempotencyCache: idempotencyCache,
+		HTTPServer:        httpServer,
+		Logger:            logger,
+		Tenants:           tenants,
+		RequestCache:      requestCache,
+		IdempotencyCache:  idempotencyCache,
+		redisClient:       redisClient,
+		popular:           popular,
+		cacheSnapshotFile: cacheSnapshotFile,
+		stopBackground:    stopBackground,
 	}, nil
 }
 
@@ -102,6 +226,20 @@ func Bootstrap(cfg config.Config) (*App, error) {
 func (a *App) Shutdown() {
 	a.Logger.Info("shutting down application")
 
+	if a.stopBackground != nil {
+		a.stopBackground()
+	}
+
+	if a.popular != nil {
+		if err := a.popular.Save(); err != nil {
+			a.Logger.Error("failed to persist popular comparisons", zap.Error(err))
+		}
+	}
+
+	if a.cacheSnapshotFile != "" {
+		a.saveCacheSnapshot()
+	}
+
 	if a.RequestCache != nil {
 		a.RequestCache.Close()
 	}
@@ -110,5 +248,329 @@ func (a *App) Shutdown() {
 		a.IdempotencyCache.Close()
 	}
 
+	if a.redisClient != nil {
+		_ = a.redisClient.Close()
+	}
+
+	if a.Tenants != nil {
+		a.Tenants.Close()
+	}
+
 	_ = a.Logger.Sync()
 }
+
+// saveCacheSnapshot writes the entries of the in-process caches for the next start to restore.
+// It runs once the HTTP server has drained, so no request adds entries meanwhile.
+func (a *App) saveCacheSnapshot() {
+	snapshot := cache.Snapshot{SavedAt: time.Now()}
+	if snapshotter, ok := a.RequestCache.(cache.RequestSnapshotter); ok {
+		snapshot.Requests = snapshotter.Snapshot()
+	}
+	if snapshotter, ok := a.IdempotencyCache.(cache.IdempotencySnapshotter); ok {
+		snapshot.Idempotency = snapshotter.Snapshot()
+	}
+
+	if err := cache.WriteSnapshot(a.cacheSnapshotFile, snapshot); err != nil {
+		a.Logger.Error("failed to save cache snapshot", zap.String("file", a.cacheSnapshotFile), zap.Error(err))
+		return
+	}
+	a.Logger.Info("cache snapshot saved",
+		zap.String("file", a.cacheSnapshotFile),
+		zap.Int("requests", len(snapshot.Requests)),
+		zap.Int("idempotency", len(snapshot.Idempotency)),
+	)
+}
+
+// restoreCacheSnapshot loads the entries saved by the previous process, dropping the expired ones.
+// Comparisons are kept only when computed from the content of a live catalog version: a changed
+// feed may have changed their items. The content hash is compared rather than the version ID,
+// which embeds the load time and so differs after every restart. An unreadable snapshot leaves
+// the caches empty.
+func restoreCacheSnapshot(filePath string, requestCache cache.RequestCache, idempotencyCache cache.IdempotencyCache, tenants *tenant.Registry, logger *zap.Logger) {
+	snapshot, err := cache.ReadSnapshot(filePath)
+	if err != nil {
+		logger.Warn("cache snapshot skipped", zap.String("file", filePath), zap.Error(err))
+		return
+	}
+	if snapshot.SavedAt.IsZero() {
+		return // First start: nothing saved yet
+	}
+
+	liveContent := make(map[string]bool)
+	for _, t := range tenants.All() {
+		if versioned, ok := t.Repo.(data.VersionedCatalog); ok {
+			liveContent[versioned.CurrentSnapshot().Version.ContentHash] = true
+		}
+	}
+
+	requests, idempotency := 0, 0
+	if snapshotter, ok := requestCache.(cache.RequestSnapshotter); ok {
+		requests = snapshotter.Restore(snapshot.Requests, func(response cache.CachedResponse) bool {
+			return response.CatalogContent != "" && liveContent[response.CatalogContent]
+		})
+	}
+	if snapshotter, ok := idempotencyCache.(cache.IdempotencySnapshotter); ok {
+		idempotency = snapshotter.Restore(snapshot.Idempotency)
+	}
+
+	logger.Info("cache snapshot restored",
+		zap.String("file", filePath),
+		zap.Time("saved_at", snapshot.SavedAt),
+		zap.Int("requests", requests),
+		zap.Int("requests_dropped", len(snapshot.Requests)-requests),
+		zap.Int("idempotency", idempotency),
+		zap.Int("idempotency_dropped", len(snapshot.Idempotency)-idempotency),
+	)
+}
+
+// invalidateOnCatalogChange diffs every published version against the previous one,
+// logs the changes and evicts the request cache entries of the affected items
+func invalidateOnCatalogChange(ctx context.Context, tenantID string, catalog data.VersionedCatalog, requestCache cache.RequestCache, logger *zap.Logger) {
+	catalog.OnPublish(func(previous, current *data.Snapshot) {
+		if previous == nil {
+			return
+		}
+		diff := data.DiffSnapshots(previous, current)
+
+		affected := diff.AffectedIDs()
+		itemKeys := make([]string, len(affected))
+		for i, id := range affected {
+			itemKeys[i] = cache.NamespacedKey(tenantID, id)
+		}
+		evicted := requestCache.InvalidateItems(ctx, itemKeys)
+
+		logger.Info("catalog changed",
+			zap.String("from_version", diff.FromVersion),
+			zap.String("to_version", diff.ToVersion),
+			zap.Int("added", len(diff.Added)),
+			zap.Int("removed", len(diff.Removed)),
+			zap.Int("changed", len(diff.Changed)),
+			zap.Int("evicted_cache_entries", evicted),
+		)
+		logger.Debug("catalog changed items",
+			zap.Strings("added", diff.Added),
+			zap.Strings("removed", diff.Removed),
+			zap.Strings("changed", diff.Changed),
+		)
+	})
+}
+
+// warmRequestCache precomputes the popular comparisons, most requested first, for up to timeout
+func warmRequestCache(ctx context.Context, timeout time.Duration, comparisons []cache.PopularComparison, compareHandler *handlers.CompareHandler, tenants *tenant.Registry, locales *i18n.Bundle, logger *zap.Logger) {
+	if len(comparisons) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	started := time.Now()
+	warmed := compareHandler.Warm(ctx, comparisons, tenants, locales)
+	logger.Info("request cache warmed up",
+		zap.Int("popular", len(comparisons)),
+		zap.Int("computed", warmed),
+		zap.Duration("duration", time.Since(started)),
+		zap.Bool("timed_out", ctx.Err() != nil),
+	)
+}
+
+// tenantComparisons keeps the comparisons of a tenant
+func tenantComparisons(comparisons []cache.PopularComparison, tenantID string) []cache.PopularComparison {
+	kept := make([]cache.PopularComparison, 0, len(comparisons))
+	for _, comparison := range comparisons {
+		if comparison.Tenant == tenantID {
+			kept = append(kept, comparison)
+		}
+	}
+	return kept
+}
+
+// newCaches builds the request and idempotency caches of CACHE_BACKEND.
+// The Redis client, returned for Shutdown to close it, is nil with the memory backend.
+func newCaches(ctx context.Context, cfg config.Config, logger *zap.Logger) (cache.RequestCache, cache.IdempotencyCache, *redis.Client, error) {
+	requestTTL := cache.TTL{Soft: cfg.CacheTTL, Hard: cfg.CacheHardTTL}
+	warnDeprecatedCacheSizes(cfg, logger)
+
+	switch cfg.CacheBackend {
+	case "memory":
+		requestCache, err := cache.NewMemoryRequestCache(cfg.CacheMaxBytes, requestTTL, logger)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to initialize request cache: %w", err)
+		}
+		idempotencyCache, err := cache.NewMemoryIdempotencyCache(cfg.IdempotencyMaxBytes, cfg.IdempotencyTTL, logger)
+		if err != nil {
+			requestCache.Close()
+			return nil, nil, nil, fmt.Errorf("failed to initialize idempotency cache: %w", err)
+		}
+		metrics.RegisterCacheMemory("request", requestCache.UsedBytes, requestCache.MaxBytes)
+		metrics.RegisterCacheMemory("idempotency", idempotencyCache.UsedBytes, idempotencyCache.MaxBytes)
+		return requestCache, idempotencyCache, nil, nil
+	case "redis":
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		client, err := cache.NewRedisClient(ctx, cfg.RedisURL)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return cache.NewRedisRequestCache(client, cfg.RedisKeyPrefix, requestTTL, logger),
+			cache.NewRedisIdempotencyCache(client, cfg.RedisKeyPrefix, cfg.IdempotencyTTL, logger),
+			client, nil
+	default:
+		return nil, nil, nil, fmt.Errorf("unknown cache backend %q", cfg.CacheBackend)
+	}
+}
+
+// warnDeprecatedCacheSizes logs the entry counts that are still set: the caches are bounded in bytes now
+func warnDeprecatedCacheSizes(cfg config.Config, logger *zap.Logger) {
+	if cfg.CacheSize != 0 {
+		logger.Warn("CACHE_SIZE is deprecated and ignored: the request cache is bounded by CACHE_MAX_BYTES",
+			zap.Int("cache_size", cfg.CacheSize),
+			zap.Int64("cache_max_bytes", cfg.CacheMaxBytes),
+		)
+	}
+	if cfg.IdempotencySize != 0 {
+		logger.Warn("IDEMPOTENCY_SIZE is deprecated and ignored: the idempotency cache is bounded by IDEMPOTENCY_MAX_BYTES",
+			zap.Int("idempotency_size", cfg.IdempotencySize),
+			zap.Int64("idempotency_max_bytes", cfg.IdempotencyMaxBytes),
+		)
+	}
+}
+
+// newTenantRegistry builds the storefronts listed in TENANTS_FILE, or a single default one
+// from the catalog settings when no file is configured
+func newTenantRegistry(ctx context.Context, cfg config.Config, rates data.ExchangeRateSource, logger *zap.Logger) (*tenant.Registry, error) {
+	specs := tenant.Specs{
+		Default: tenant.DefaultID,
+		Tenants: []tenant.Spec{{
+			ID:               tenant.DefaultID,
+			DataFile:         cfg.DataFile,
+			URL:              cfg.CatalogURL,
+			Format:           cfg.CatalogFormat,
+			PriceHistoryFile: cfg.PriceHistoryFile,
+		}},
+	}
+	sourceOf := func(tenant.Spec) string { return cfg.CatalogSource }
+
+	if cfg.TenantsFile != "" {
+		if cfg.CatalogSource == "postgres" {
+			return nil, fmt.Errorf("TENANTS_FILE does not support CATALOG_SOURCE=postgres")
+		}
+		loaded, err := tenant.LoadSpecs(cfg.TenantsFile)
+		if err != nil {
+			return nil, err
+		}
+		specs = loaded
+		sourceOf = tenant.Spec.CatalogSource
+	}
+
+	tenants := make([]*tenant.Tenant, 0, len(specs.Tenants))
+	for _, spec := range specs.Tenants {
+		t, err := newTenant(ctx, spec, sourceOf(spec), cfg, rates, logger.With(zap.String("tenant", spec.ID)))
+		if err != nil {
+			return nil, fmt.Errorf("tenant %q: %w", spec.ID, err)
+		}
+		tenants = append(tenants, t)
+	}
+
+	registry, err := tenant.NewRegistry(tenants, specs.Default)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("tenants initialized",
+		zap.Int("count", len(tenants)),
+		zap.String("default", specs.Default),
+	)
+
+	return registry, nil
+}
+
+// newTenant builds the catalog repository, the price history and the services of a storefront
+func newTenant(ctx context.Context, spec tenant.Spec, source string, cfg config.Config, rates data.ExchangeRateSource, logger *zap.Logger) (*tenant.Tenant, error) {
+	catalogRepo, err := newCatalogRepository(ctx, source, spec, cfg, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize catalog repository: %w", err)
+	}
+
+	// Price history (versioned catalogs only)
+	var priceHistory *data.PriceHistory
+	if versioned, ok := catalogRepo.(data.VersionedCatalog); ok {
+		priceHistory, err = data.NewPriceHistory(spec.PriceHistoryFile, cfg.PriceHistoryRetention, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize price history: %w", err)
+		}
+		priceHistory.Record(versioned.CurrentSnapshot())
+		versioned.OnPublish(func(_, current *data.Snapshot) {
+			priceHistory.Record(current)
+		})
+	}
+
+	if watcher, ok := catalogRepo.(data.Watcher); ok && cfg.CatalogReloadInterval > 0 {
+		go watcher.Watch(ctx, cfg.CatalogReloadInterval)
+		logger.Info("catalog hot reload enabled", zap.Duration("interval", cfg.CatalogReloadInterval))
+	}
+
+	currency := spec.Currency
+	if currency == "" {
+		currency = domain.NormalizeCurrency(cfg.CatalogCurrency)
+		if currency == "" {
+			return nil, fmt.Errorf("invalid CATALOG_CURRENCY %q", cfg.CatalogCurrency)
+		}
+	}
+
+	return &tenant.Tenant{
+		ID:           spec.ID,
+		Hosts:        spec.Hosts,
+		Repo:         catalogRepo,
+		PriceHistory: priceHistory,
+		Compare: service.NewCompareService(catalogRepo, logger,
+			service.WithPriceHistory(priceHistory),
+			service.WithMetricOverrides(spec.MetricOverrides),
+			service.WithCurrency(currency),
+			service.WithExchangeRates(rates),
+		),
+		Items:   service.NewItemService(catalogRepo, priceHistory, logger),
+		Catalog: service.NewCatalogService(catalogRepo, logger),
+	}, nil
+}
+
+// newCatalogRepository builds the catalog repository of a source ("file", "http" or "postgres")
+func newCatalogRepository(ctx context.Context, source string, spec tenant.Spec, cfg config.Config, logger *zap.Logger) (data.CatalogRepository, error) {
+	switch source {
+	case "file":
+		format, err := data.ParseFeedFormat(spec.Format, spec.DataFile)
+		if err != nil {
+			return nil, err
+		}
+		return data.NewFileCatalogRepo(spec.DataFile, format, cfg.CatalogVersions, logger)
+	case "http":
+		var format data.FeedFormat
+		if spec.Format != "" {
+			parsed, err := data.ParseFeedFormat(spec.Format, "")
+			if err != nil {
+				return nil, err
+			}
+			format = parsed
+		}
+		return data.NewHTTPCatalogRepo(ctx, data.HTTPCatalogConfig{
+			URL:           spec.URL,
+			Format:        format,
+			Authorization: cfg.CatalogAuthorization,
+			Timeout:       cfg.CatalogHTTPTimeout,
+			MaxBackoff:    cfg.CatalogMaxBackoff,
+		}, cfg.CatalogVersions, logger)
+	case "postgres":
+		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		return data.NewPostgresCatalogRepo(ctx, data.PostgresConfig{
+			URL:             cfg.DatabaseURL,
+			MaxConns:        cfg.DBMaxConns,
+			MinConns:        cfg.DBMinConns,
+			MaxConnLifetime: cfg.DBMaxConnLifetime,
+			MaxConnIdleTime: cfg.DBMaxConnIdleTime,
+			Migrate:         cfg.DBMigrate,
+		}, logger)
+	default:
+		return nil, fmt.Errorf("unknown catalog source %q", source)
+	}
+}
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mmedinam1600/product-comparison-api/internal/cache"
+	"github.com/mmedinam1600/product-comparison-api/internal/data"
+	"github.com/mmedinam1600/product-comparison-api/internal/shared/config"
+	"github.com/mmedinam1600/product-comparison-api/internal/tenant"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// loadTestTenants loads the catalog file as the default tenant, like a fresh start does
+func loadTestTenants(t *testing.T, catalogPath string) (*tenant.Registry, *data.FileCatalogRepo) {
+	t.Helper()
+	repo, err := data.NewFileCatalogRepo(catalogPath, data.FeedFormatJSON, 3, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewFileCatalogRepo() error = %v", err)
+	}
+	tenants, err := tenant.NewRegistry([]*tenant.Tenant{{ID: tenant.DefaultID, Repo: repo}}, tenant.DefaultID)
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+	return tenants, repo
+}
+
+func TestRestoreCacheSnapshot_SameFeedAfterRestart(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop()
+	dir := t.TempDir()
+	catalogPath := filepath.Join(dir, "items.json")
+	if err := os.WriteFile(catalogPath, []byte(`[{"id": "a", "name": "A", "price": 10, "rating": 4}, {"id": "b", "name": "B", "price": 20, "rating": 5}]`), 0o644); err != nil {
+		t.Fatalf("failed to write catalog: %v", err)
+	}
+
+	// The previous process computed ab from this feed, and cd from a feed that has changed since
+	_, previous := loadTestTenants(t, catalogPath)
+	version := previous.CurrentSnapshot().Version
+	expiresAt := time.Now().Add(time.Minute)
+	snapshotPath := filepath.Join(dir, "snapshot.json")
+	err := cache.WriteSnapshot(snapshotPath, cache.Snapshot{
+		SavedAt: time.Now(),
+		Requests: []cache.RequestSnapshotEntry{
+			{
+				Key:       "default:ab",
+				ItemIDs:   []string{"default:a", "default:b"},
+				Response:  cache.CachedResponse{Body: []byte(`{"data":"ab"}`), CatalogVersion: "20200101T000000Z-" + version.ContentHash[:8], CatalogContent: version.ContentHash, StoredAt: time.Now()},
+				ExpiresAt: expiresAt,
+			},
+			{
+				Key:       "default:cd",
+				ItemIDs:   []string{"default:c", "default:d"},
+				Response:  cache.CachedResponse{Body: []byte(`{"data":"cd"}`), CatalogVersion: "20200101T000000Z-00000000", CatalogContent: "changed", StoredAt: time.Now()},
+				ExpiresAt: expiresAt,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+
+	// A fresh load of the same feed publishes another version ID over the same content
+	tenants, _ := loadTestTenants(t, catalogPath)
+	requestCache, err := cache.NewMemoryRequestCache(1<<20, cache.TTL{Soft: time.Minute}, logger)
+	if err != nil {
+		t.Fatalf("NewMemoryRequestCache() error = %v", err)
+	}
+	defer requestCache.Close()
+	idempotencyCache, err := cache.NewMemoryIdempotencyCache(1<<20, time.Minute, logger)
+	if err != nil {
+		t.Fatalf("NewMemoryIdempotencyCache() error = %v", err)
+	}
+	defer idempotencyCache.Close()
+
+	restoreCacheSnapshot(snapshotPath, requestCache, idempotencyCache, tenants, logger)
+
+	if cached, found := requestCache.Get(ctx, "default:ab"); !found || string(cached.Body) != `{"data":"ab"}` {
+		t.Errorf("Expected ab to be restored over the reloaded feed, got %s (found %v)", cached.Body, found)
+	}
+	if _, found := requestCache.Get(ctx, "default:cd"); found {
+		t.Error("Expected cd, computed from other content, to be dropped")
+	}
+}
+
+func TestWarnDeprecatedCacheSizes(t *testing.T) {
+	core, logs := observer.New(zapcore.WarnLevel)
+	cfg := config.Config{CacheMaxBytes: 1 << 20, CacheSize: 1000, IdempotencyMaxBytes: 1 << 20, IdempotencySize: 1000}
+
+	warnDeprecatedCacheSizes(cfg, zap.New(core))
+	for _, field := range []string{"cache_size", "idempotency_size"} {
+		if logs.FilterFieldKey(field).Len() != 1 {
+			t.Errorf("Expected one deprecation warning with %s, got %v", field, logs.All())
+		}
+	}
+
+	// Unset sizes stay silent
+	core, logs = observer.New(zapcore.WarnLevel)
+	warnDeprecatedCacheSizes(config.Config{CacheMaxBytes: 1 << 20, IdempotencyMaxBytes: 1 << 20}, zap.New(core))
+	if logs.Len() != 0 {
+		t.Errorf("Expected no warning, got %v", logs.All())
+	}
+}
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/dgraph-io/ristretto/v2"
+)
+
+// Administered is implemented by the caches the admin API can inspect and purge,
+// so a bad entry can be cleared without restarting the process
+type Administered interface {
+	// Stats returns the activity of the cache since the process started
+	Stats(ctx context.Context) Stats
+	// Keys returns up to limit keys of live entries, sorted
+	Keys(ctx context.Context, limit int) []string
+	// Purge deletes the entries of the keys. Returns how many existed.
+	Purge(ctx context.Context, keys []string) int
+	// PurgeAll deletes every entry. Returns how many there were.
+	PurgeAll(ctx context.Context) int
+}
+
+// Stats is the activity of a cache since the process started.
+// Redis caches count the hits and misses of this replica only, and report no evictions:
+// the server expires their entries itself.
+type Stats struct {
+	Entries       int     `json:"entries"` // -1 when Redis cannot be scanned
+	Hits          uint64  `json:"hits"`
+	Misses        uint64  `json:"misses"`
+	HitRatio      float64 `json:"hit_ratio"`      // Hits per lookup
+	Added         uint64  `json:"added"`          // Entries stored
+	Evicted       uint64  `json:"evicted"`        // Entries evicted to make room; expired, purged and invalidated ones are not counted
+	EvictionRatio float64 `json:"eviction_ratio"` // Evicted per added entry
+	UsedBytes     int64   `json:"used_bytes,omitempty"`
+	MaxBytes      int64   `json:"max_bytes,omitempty"`
+}
+
+// newStats fills the ratios of the counters
+func newStats(entries int, hits, misses, added, evicted uint64) Stats {
+	stats := Stats{Entries: entries, Hits: hits, Misses: misses, Added: added, Evicted: evicted}
+	if lookups := hits + misses; lookups > 0 {
+		stats.HitRatio = float64(hits) / float64(lookups)
+	}
+	if added > 0 {
+		stats.EvictionRatio = float64(evicted) / float64(added)
+	}
+	return stats
+}
+
+// countEvictions returns an OnEvict callback that counts the entries evicted to make room.
+// Ristretto also passes the expired entries it cleans up, which are left out.
+func countEvictions[V any](evicted *atomic.Uint64) func(*ristretto.Item[V]) {
+	return func(item *ristretto.Item[V]) {
+		if item.Expiration.IsZero() || time.Now().Before(item.Expiration) {
+			evicted.Add(1)
+		}
+	}
+}
+
+// sampleKeys returns up to limit keys of the set, sorted
+func sampleKeys(keys map[string]int, limit int) []string {
+	sample := make([]string, 0, min(len(keys), limit))
+	for key := range keys {
+		if len(sample) == limit {
+			break
+		}
+		sample = append(sample, key)
+	}
+	sort.Strings(sample)
+	return sample
+}
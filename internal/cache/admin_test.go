@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/ristretto/v2"
+	"github.com/goccy/go-json"
+	"go.uber.org/zap"
+)
+
+func TestMemoryRequestCache_Administered(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewMemoryRequestCache(1<<20, TTL{Soft: time.Minute}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewMemoryRequestCache() error = %v", err)
+	}
+	defer c.Close()
+
+	for _, key := range []string{"default:en:ab", "default:en:cd", "default:en:ef"} {
+		c.Set(ctx, key, []string{key}, CachedResponse{Body: json.RawMessage(`{"data":null}`)})
+	}
+	c.cache.Wait()
+	c.Get(ctx, "default:en:ab")
+	c.Get(ctx, "default:en:zz")
+
+	stats := c.Stats(ctx)
+	if stats.Entries != 3 || stats.Hits != 1 || stats.Misses != 1 || stats.HitRatio != 0.5 || stats.Added != 3 {
+		t.Errorf("Stats() = %+v, want 3 entries, 1 hit, 1 miss and 3 added", stats)
+	}
+	if keys := c.Keys(ctx, 2); len(keys) != 2 || keys[0] > keys[1] {
+		t.Errorf("Expected a sorted sample of 2 keys, got %v", keys)
+	}
+
+	// Only existing keys count as purged, and purged keys leave the item index too
+	if purged := c.Purge(ctx, []string{"default:en:ab", "default:en:zz"}); purged != 1 {
+		t.Errorf("Expected 1 purged entry, got %d", purged)
+	}
+	if evicted := c.InvalidateItems(ctx, []string{"default:en:ab"}); evicted != 0 {
+		t.Errorf("Expected the purged entry to leave the item index, got %d evicted", evicted)
+	}
+	if purged := c.PurgeAll(ctx); purged != 2 {
+		t.Errorf("Expected the 2 remaining entries purged, got %d", purged)
+	}
+	c.cache.Wait()
+	if keys := c.Keys(ctx, 10); len(keys) != 0 {
+		t.Errorf("Expected an empty cache, got keys %v", keys)
+	}
+	if stats := c.Stats(ctx); stats.Evicted != 0 || stats.EvictionRatio != 0 {
+		t.Errorf("Expected purged entries not to count as evicted, got %+v", stats)
+	}
+}
+
+func TestCountEvictions(t *testing.T) {
+	var evicted atomic.Uint64
+	onEvict := countEvictions[requestEntry](&evicted)
+
+	onEvict(&ristretto.Item[requestEntry]{})                                              // No TTL: evicted for room
+	onEvict(&ristretto.Item[requestEntry]{Expiration: time.Now().Add(time.Minute)})       // Still fresh: evicted for room
+	onEvict(&ristretto.Item[requestEntry]{Expiration: time.Now().Add(-time.Millisecond)}) // Cleaned up once expired
+
+	if count := evicted.Load(); count != 2 {
+		t.Errorf("Expected 2 evictions for room, got %d", count)
+	}
+}
+
+func TestMemoryIdempotencyCache_Administered(t *testing.T) {
+	ctx := context.Background()
+	c, err := NewMemoryIdempotencyCache(1<<20, time.Minute, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewMemoryIdempotencyCache() error = %v", err)
+	}
+	defer c.Close()
+
+	c.Set(ctx, "default:key-1", IdempotentEntry{BodyHash: "hash-1"})
+	c.Set(ctx, "default:key-2", IdempotentEntry{BodyHash: "hash-2"})
+	c.cache.Wait()
+
+	if keys := c.Keys(ctx, 10); !reflect.DeepEqual(keys, []string{"default:key-1", "default:key-2"}) {
+		t.Errorf("Keys() = %v", keys)
+	}
+	if purged := c.Purge(ctx, []string{"default:key-1"}); purged != 1 {
+		t.Errorf("Expected 1 purged entry, got %d", purged)
+	}
+	if _, found := c.Get(ctx, "default:key-1"); found {
+		t.Error("Expected the purged key to miss")
+	}
+	if purged := c.PurgeAll(ctx); purged != 1 {
+		t.Errorf("Expected the remaining entry purged, got %d", purged)
+	}
+}
+
+func TestRedisCaches_Administered(t *testing.T) {
+	server, client := newTestRedis(t)
+	ctx := context.Background()
+	requests := NewRedisRequestCache(client, "test:", TTL{Soft: time.Minute}, zap.NewNop())
+	idempotency := NewRedisIdempotencyCache(client, "test:", time.Minute, zap.NewNop())
+
+	requests.Set(ctx, "default:en:ab", []string{"default:a", "default:b"}, CachedResponse{Body: json.RawMessage(`{}`)})
+	requests.Set(ctx, "default:en:cd", []string{"default:c", "default:d"}, CachedResponse{Body: json.RawMessage(`{}`)})
+	idempotency.Set(ctx, "default:key-1", IdempotentEntry{BodyHash: "hash-1"})
+	requests.Get(ctx, "default:en:ab")
+	requests.Get(ctx, "default:en:zz")
+
+	// Each cache lists and counts its own keys only, without the prefix
+	if stats := requests.Stats(ctx); stats.Entries != 2 || stats.Hits != 1 || stats.Misses != 1 || stats.Added != 2 {
+		t.Errorf("Stats() = %+v, want 2 entries, 1 hit, 1 miss and 2 added", stats)
+	}
+	if keys := requests.Keys(ctx, 10); !reflect.DeepEqual(keys, []string{"default:en:ab", "default:en:cd"}) {
+		t.Errorf("Keys() = %v", keys)
+	}
+	if keys := requests.Keys(ctx, 1); len(keys) != 1 {
+		t.Errorf("Expected the sample limited to 1 key, got %v", keys)
+	}
+	if keys := idempotency.Keys(ctx, 10); !reflect.DeepEqual(keys, []string{"default:key-1"}) {
+		t.Errorf("Keys() = %v", keys)
+	}
+
+	if purged := requests.Purge(ctx, []string{"default:en:ab", "default:en:zz"}); purged != 1 {
+		t.Errorf("Expected 1 purged entry, got %d", purged)
+	}
+	// Purging everything drops the item index too, and leaves the idempotency keys alone
+	if purged := requests.PurgeAll(ctx); purged != 1 {
+		t.Errorf("Expected the remaining entry purged, got %d", purged)
+	}
+	if keys := server.Keys(); !reflect.DeepEqual(keys, []string{"test:idempotency:default:key-1"}) {
+		t.Errorf("Expected only the idempotency key left, got %v", keys)
+	}
+	if purged := idempotency.PurgeAll(ctx); purged != 1 {
+		t.Errorf("Expected the idempotency entry purged, got %d", purged)
+	}
+}
@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// ContentTypeJSON is the Content-Type of the cached bodies
+const ContentTypeJSON = "application/json; charset=utf-8"
+
+// RequestCache stores comparison responses.
+// Every entry is indexed by the items it involves, so a catalog change can evict
+// exactly the responses that include a changed item.
+type RequestCache interface {
+	// Get gets a response from the cache
+	Get(ctx context.Context, key string) (CachedResponse, bool)
+	// Set stores a response in the cache, indexed by the items it involves
+	Set(ctx context.Context, key string, itemIDs []string, response CachedResponse)
+	// InvalidateItems evicts every response that involves any of the items.
+	// Returns the number of evicted entries.
+	InvalidateItems(ctx context.Context, itemIDs []string) int
+	// Close releases the cache
+	Close()
+}
+
+// IdempotencyCache stores the responses of requests sent with an Idempotency-Key
+type IdempotencyCache interface {
+	// Get gets an idempotency entry
+	Get(ctx context.Context, key string) (IdempotentEntry, bool)
+	// Set stores an idempotency entry
+	Set(ctx context.Context, key string, entry IdempotentEntry)
+	// Close releases the cache
+	Close()
+}
+
+// CachedResponse is a response as it is sent: the encoded JSON body and its precompressed
+// variants, so a hit is written without encoding or compressing anything
+type CachedResponse struct {
+	Body           json.RawMessage   `json:"body"`                      // Encoded JSON body
+	Encoded        map[string][]byte `json:"encoded,omitempty"`         // Content-Encoding → compressed body
+	ETag           string            `json:"etag,omitempty"`            // Strong ETag of the body; empty when the content is unversioned
+	CatalogVersion string            `json:"catalog_version,omitempty"` // ID of the catalog version it was computed from
+	CatalogContent string            `json:"catalog_content,omitempty"` // Content hash of that version, the same across reloads of the same feed
+	StoredAt       time.Time         `json:"stored_at"`                 // Set by the cache
+	Stale          bool              `json:"-"`                         // Set by Get once the soft TTL has passed
+}
+
+// size returns the bytes held by the response
+func (r CachedResponse) size() int64 {
+	size := len(r.Body) + len(r.ETag)
+	for encoding, body := range r.Encoded {
+		size += len(encoding) + len(body)
+	}
+	return int64(size)
+}
+
+// TTL sets how long a cached response is fresh (Soft) and how long it is kept (Hard).
+// Between both it is served stale while it is recomputed; a Hard TTL not above Soft disables that.
+type TTL struct {
+	Soft time.Duration
+	Hard time.Duration
+}
+
+// keep returns how long an entry stays in the cache
+func (t TTL) keep() time.Duration {
+	return max(t.Soft, t.Hard)
+}
+
+// stale reports whether an entry stored at storedAt is past the soft TTL
+func (t TTL) stale(storedAt time.Time) bool {
+	return time.Since(storedAt) >= t.Soft
+}
+
+// IdempotentEntry represents an idempotency entry
+type IdempotentEntry struct {
+	BodyHash string          `json:"body_hash"` // Hash of the original request body
+	Response json.RawMessage `json:"response"`  // Encoded body of the original response, replayed byte for byte
+}
+
+// size returns the bytes held by the entry
+func (e IdempotentEntry) size() int64 {
+	return int64(len(e.BodyHash) + len(e.Response))
+}
+
+// HashBody generates a SHA-256 hash of the body
+func HashBody(body []byte) string {
+	hash := sha256.Sum256(body)
+	return hex.EncodeToString(hash[:])
+}
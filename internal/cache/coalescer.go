@@ -0,0 +1,30 @@
+package cache
+
+import (
+	"golang.org/x/sync/singleflight"
+)
+
+// Coalescer shares one execution of a computation among the concurrent callers of the same key,
+// so a popular entry that expires is recomputed once instead of once per request
+type Coalescer[T any] struct {
+	group singleflight.Group
+}
+
+// Do runs fn, unless a call with the same key is already running: then it waits for that call
+// and returns its value. coalesced reports whether the value came from another caller's execution.
+func (c *Coalescer[T]) Do(key string, fn func() T) (value T, coalesced bool) {
+	executed := false
+	shared, _, _ := c.group.Do(key, func() (interface{}, error) {
+		executed = true
+		return fn(), nil
+	})
+	return shared.(T), !executed
+}
+
+// Start runs fn in the background, unless a call with the same key is already running.
+// Callers of Do with the key wait for it meanwhile.
+func (c *Coalescer[T]) Start(key string, fn func() T) {
+	c.group.DoChan(key, func() (interface{}, error) {
+		return fn(), nil
+	})
+}
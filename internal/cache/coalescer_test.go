@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoalescer_SharesConcurrentExecutions(t *testing.T) {
+	var c Coalescer[string]
+	var executions atomic.Int32
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	// The first caller holds the execution until every other caller is waiting for it
+	const callers = 10
+	var wg sync.WaitGroup
+	values := make([]string, callers)
+	coalesced := make([]bool, callers)
+	run := func(i int) {
+		defer wg.Done()
+		values[i], coalesced[i] = c.Do("key", func() string {
+			executions.Add(1)
+			close(started)
+			<-release
+			return "value"
+		})
+	}
+
+	wg.Add(1)
+	go run(0)
+	<-started
+	wg.Add(callers - 1)
+	for i := 1; i < callers; i++ {
+		go run(i)
+	}
+	time.Sleep(50 * time.Millisecond) // Let the other callers reach Do
+	close(release)
+	wg.Wait()
+
+	if executions.Load() != 1 {
+		t.Errorf("Expected 1 execution, got %d", executions.Load())
+	}
+	for i := range values {
+		if values[i] != "value" {
+			t.Errorf("Caller %d got %q, want the shared value", i, values[i])
+		}
+		if coalesced[i] != (i != 0) {
+			t.Errorf("Caller %d coalesced = %v, want %v", i, coalesced[i], i != 0)
+		}
+	}
+}
+
+func TestCoalescer_SequentialAndDistinctKeysExecute(t *testing.T) {
+	var c Coalescer[int]
+	executions := 0
+	fn := func() int {
+		executions++
+		return executions
+	}
+
+	// Nothing is remembered once a call returns, and different keys never share
+	for _, key := range []string{"a", "a", "b"} {
+		if _, coalesced := c.Do(key, fn); coalesced {
+			t.Errorf("Expected call of %s not to be coalesced", key)
+		}
+	}
+	if executions != 3 {
+		t.Errorf("Expected 3 executions, got %d", executions)
+	}
+}
+
+func TestCoalescer_StartIsJoinedByDo(t *testing.T) {
+	var c Coalescer[string]
+	var executions atomic.Int32
+	release := make(chan struct{})
+	fn := func() string {
+		executions.Add(1)
+		<-release
+		return "refreshed"
+	}
+
+	// A running background call is not started twice, and Do waits for it
+	c.Start("key", fn)
+	c.Start("key", fn)
+	done := make(chan struct{})
+	var value string
+	var coalesced bool
+	go func() {
+		defer close(done)
+		value, coalesced = c.Do("key", fn)
+	}()
+	time.Sleep(50 * time.Millisecond) // Let Do join the background call
+	close(release)
+	<-done
+
+	if executions.Load() != 1 {
+		t.Errorf("Expected 1 execution, got %d", executions.Load())
+	}
+	if value != "refreshed" || !coalesced {
+		t.Errorf("Expected Do to share the background value, got %q (coalesced %v)", value, coalesced)
+	}
+}
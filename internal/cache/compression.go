@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Content codings a cached body can be precompressed in (values of Content-Encoding)
+const (
+	EncodingGzip   = "gzip"
+	EncodingBrotli = "br"
+)
+
+// minCompressBytes is the smallest body worth compressing: below it the variants save
+// a few bytes of a response that fits in one packet anyway
+const minCompressBytes = 1 << 10
+
+// Compressor precompresses the bodies of cached responses, once per entry, so a hit
+// sends compressed bytes without compressing them again
+type Compressor struct {
+	encodings []string
+}
+
+// NewCompressor creates a compressor for the content codings ("gzip", "br"); none disables compression
+func NewCompressor(encodings []string) (*Compressor, error) {
+	for _, encoding := range encodings {
+		if encoding != EncodingGzip && encoding != EncodingBrotli {
+			return nil, fmt.Errorf("unsupported content encoding %q (supported: %s, %s)", encoding, EncodingGzip, EncodingBrotli)
+		}
+	}
+	return &Compressor{encodings: encodings}, nil
+}
+
+// Enabled reports whether bodies are compressed, so responses vary by Accept-Encoding
+func (c *Compressor) Enabled() bool {
+	return len(c.encodings) > 0
+}
+
+// Compress returns the body compressed in every configured coding, by coding.
+// Bodies under minCompressBytes get no variants.
+func (c *Compressor) Compress(body []byte) (map[string][]byte, error) {
+	if len(body) < minCompressBytes || !c.Enabled() {
+		return nil, nil
+	}
+
+	variants := make(map[string][]byte, len(c.encodings))
+	for _, encoding := range c.encodings {
+		var buffer bytes.Buffer
+		var writer io.WriteCloser
+		switch encoding {
+		case EncodingGzip:
+			writer, _ = gzip.NewWriterLevel(&buffer, gzip.BestCompression)
+		case EncodingBrotli:
+			// Higher levels cost tens of milliseconds per comparison for little gain
+			writer = brotli.NewWriterLevel(&buffer, brotli.DefaultCompression)
+		}
+		if _, err := writer.Write(body); err != nil {
+			return nil, fmt.Errorf("failed to compress with %s: %w", encoding, err)
+		}
+		if err := writer.Close(); err != nil {
+			return nil, fmt.Errorf("failed to compress with %s: %w", encoding, err)
+		}
+		variants[encoding] = buffer.Bytes()
+	}
+	return variants, nil
+}
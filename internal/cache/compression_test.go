@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestCompressor_Compress(t *testing.T) {
+	body := []byte(`{"data":{"items":[` + strings.Repeat(`{"id":"item","price":10},`, 100) + `{}]}}`)
+
+	tests := []struct {
+		name      string
+		encodings []string
+		body      []byte
+		expected  []string
+	}{
+		{name: "Both codings", encodings: []string{EncodingGzip, EncodingBrotli}, body: body, expected: []string{EncodingGzip, EncodingBrotli}},
+		{name: "Only gzip", encodings: []string{EncodingGzip}, body: body, expected: []string{EncodingGzip}},
+		{name: "Disabled", encodings: nil, body: body, expected: nil},
+		{name: "Small body", encodings: []string{EncodingGzip, EncodingBrotli}, body: []byte(`{"data":null}`), expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compressor, err := NewCompressor(tt.encodings)
+			if err != nil {
+				t.Fatalf("NewCompressor() error = %v", err)
+			}
+
+			variants, err := compressor.Compress(tt.body)
+			if err != nil {
+				t.Fatalf("Compress() error = %v", err)
+			}
+			if len(variants) != len(tt.expected) {
+				t.Fatalf("Expected %d variants, got %d", len(tt.expected), len(variants))
+			}
+
+			for _, encoding := range tt.expected {
+				compressed, found := variants[encoding]
+				if !found {
+					t.Fatalf("Expected a %s variant", encoding)
+				}
+				if len(compressed) >= len(tt.body) {
+					t.Errorf("Expected the %s variant to be smaller than the body, got %d of %d bytes", encoding, len(compressed), len(tt.body))
+				}
+
+				var reader io.Reader
+				if encoding == EncodingGzip {
+					reader, err = gzip.NewReader(bytes.NewReader(compressed))
+					if err != nil {
+						t.Fatalf("gzip.NewReader() error = %v", err)
+					}
+				} else {
+					reader = brotli.NewReader(bytes.NewReader(compressed))
+				}
+				decompressed, err := io.ReadAll(reader)
+				if err != nil || !bytes.Equal(decompressed, tt.body) {
+					t.Errorf("Expected the %s variant to decompress to the body (error %v)", encoding, err)
+				}
+			}
+		})
+	}
+}
+
+func TestNewCompressor_RejectsUnknownEncoding(t *testing.T) {
+	if _, err := NewCompressor([]string{EncodingGzip, "deflate"}); err == nil {
+		t.Error("Expected an error for an unsupported content encoding")
+	}
+}
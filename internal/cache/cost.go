@@ -0,0 +1,27 @@
+package cache
+
+import (
+	"github.com/dgraph-io/ristretto/v2"
+)
+
+// Sized is implemented by the caches that hold their entries in process memory.
+// Entries are charged their encoded size, so both values are bytes.
+type Sized interface {
+	// UsedBytes returns the cost of the entries currently stored
+	UsedBytes() int64
+	// MaxBytes returns the configured budget; entries are evicted beyond it
+	MaxBytes() int64
+}
+
+// estimatedEntryBytes is the expected size of an entry, used to size the admission counters
+const estimatedEntryBytes = 4 << 10
+
+// numCounters returns the counters ristretto needs for a budget: 10 per entry expected when full
+func numCounters(maxBytes int64) int64 {
+	return max(maxBytes/estimatedEntryBytes*10, 1000)
+}
+
+// usedBytes returns the cost of the entries of a ristretto cache created with Metrics enabled
+func usedBytes[V any](c *ristretto.Cache[string, V]) int64 {
+	return int64(c.Metrics.CostAdded() - c.Metrics.CostEvicted())
+}
@@ -2,74 +2,210 @@ package cache
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dgraph-io/ristretto/v2"
 	"go.uber.org/zap"
 )
 
-// IdempotencyCache manages the idempotency cache
-type IdempotencyCache struct {
-	cache  *ristretto.Cache[string, IdempotentEntry]
+// MemoryIdempotencyCache implements IdempotencyCache in process, on top of ristretto
+type MemoryIdempotencyCache struct {
+	cache  *ristretto.Cache[string, idempotencyEntry]
 	logger *zap.Logger
 	ttl    time.Duration
+
+	evicted atomic.Uint64 // Entries evicted to make room
+
+	mu   sync.Mutex
+	keys map[string]int // Idempotency key → values stored under it that have not exited (ristretto cannot list them)
 }
 
-// IdempotentEntry represents an idempotency entry
-type IdempotentEntry struct {
-	BodyHash string      // Hash of the original request body
-	Response interface{} // Complete cached response
+// idempotencyEntry is the stored value: the entry plus what is needed to snapshot it
+type idempotencyEntry struct {
+	key       string
+	expiresAt time.Time
+	entry     IdempotentEntry
 }
 
-// NewIdempotencyCache creates a new instance of the idempotency cache
-func NewIdempotencyCache(maxSize int64, ttl time.Duration, logger *zap.Logger) (*IdempotencyCache, error) {
-	cache, err := ristretto.NewCache(&ristretto.Config[string, IdempotentEntry]{
-		NumCounters: maxSize * 10,
-		MaxCost:     maxSize,
+// NewMemoryIdempotencyCache creates a new instance of the idempotency cache, holding up to maxBytes of responses
+func NewMemoryIdempotencyCache(maxBytes int64, ttl time.Duration, logger *zap.Logger) (*MemoryIdempotencyCache, error) {
+	c := &MemoryIdempotencyCache{
+		logger: logger,
+		ttl:    ttl,
+		keys:   make(map[string]int),
+	}
+
+	cache, err := ristretto.NewCache(&ristretto.Config[string, idempotencyEntry]{
+		NumCounters: numCounters(maxBytes),
+		MaxCost:     maxBytes,
 		BufferItems: 64,
+		Metrics:     true,                                         // Tracks the bytes in use
+		OnEvict:     countEvictions[idempotencyEntry](&c.evicted), // Counts the evictions for room
+		OnExit:      c.forget,                                     // Evicted, expired, rejected, replaced or deleted
 	})
 	if err != nil {
 		return nil, err
 	}
+	c.cache = cache
 
 	logger.Info("idempotency cache initialized",
-		zap.Int64("max_size", maxSize),
+		zap.Int64("max_bytes", maxBytes),
 		zap.Duration("ttl", ttl),
 	)
 
-	return &IdempotencyCache{
-		cache:  cache,
-		logger: logger,
-		ttl:    ttl,
-	}, nil
+	return c, nil
 }
 
-// Get gets an idempotency entry
-func (c *IdempotencyCache) Get(ctx context.Context, key string) (IdempotentEntry, bool) {
+// Get implements IdempotencyCache.Get
+func (c *MemoryIdempotencyCache) Get(ctx context.Context, key string) (IdempotentEntry, bool) {
 	if value, found := c.cache.Get(key); found {
 		c.logger.Debug("idempotency hit", zap.String("key", key))
-		return value, true
+		return value.entry, true
 	}
 	c.logger.Debug("idempotency miss", zap.String("key", key))
 	return IdempotentEntry{}, false
 }
 
-// Set store an idempotency entry
-func (c *IdempotencyCache) Set(ctx context.Context, key string, entry IdempotentEntry) {
-	c.cache.SetWithTTL(key, entry, 1, c.ttl)
-	c.logger.Debug("idempotency set", zap.String("key", key), zap.Duration("ttl", c.ttl))
+// Set implements IdempotencyCache.Set
+func (c *MemoryIdempotencyCache) Set(ctx context.Context, key string, entry IdempotentEntry) {
+	if c.set(key, entry, c.ttl) {
+		c.logger.Debug("idempotency set", zap.String("key", key), zap.Int64("bytes", entry.size()), zap.Duration("ttl", c.ttl))
+	}
+}
+
+// set stores an entry for ttl. Returns false when ristretto drops it.
+func (c *MemoryIdempotencyCache) set(key string, entry IdempotentEntry, ttl time.Duration) bool {
+	value := idempotencyEntry{
+		key:       key,
+		expiresAt: time.Now().Add(ttl),
+		entry:     entry,
+	}
+
+	c.mu.Lock()
+	c.keys[key]++
+	c.mu.Unlock()
+
+	if !c.cache.SetWithTTL(key, value, entry.size(), ttl) {
+		// Dropped by a full buffer: it will never exit the cache, so forget it now
+		c.forget(value)
+		return false
+	}
+	return true
+}
+
+// forget removes an entry that left the cache from the key list once no value of its key is left
+// (see MemoryRequestCache.unindex)
+func (c *MemoryIdempotencyCache) forget(value idempotencyEntry) {
+	if value.key == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.keys[value.key]--; c.keys[value.key] <= 0 {
+		delete(c.keys, value.key)
+	}
+}
+
+// Stats implements Administered.Stats from ristretto's metrics
+func (c *MemoryIdempotencyCache) Stats(ctx context.Context) Stats {
+	c.mu.Lock()
+	entries := len(c.keys)
+	c.mu.Unlock()
+
+	metrics := c.cache.Metrics
+	stats := newStats(entries, metrics.Hits(), metrics.Misses(), metrics.KeysAdded(), c.evicted.Load())
+	stats.UsedBytes, stats.MaxBytes = c.UsedBytes(), c.MaxBytes()
+	return stats
+}
+
+// Keys implements Administered.Keys
+func (c *MemoryIdempotencyCache) Keys(ctx context.Context, limit int) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return sampleKeys(c.keys, limit)
+}
+
+// Purge implements Administered.Purge
+func (c *MemoryIdempotencyCache) Purge(ctx context.Context, keys []string) int {
+	purged := 0
+	for _, key := range keys {
+		c.mu.Lock()
+		_, exists := c.keys[key]
+		c.mu.Unlock()
+
+		// Del calls forget, so the lock must not be held here
+		if exists {
+			c.cache.Del(key)
+			purged++
+		}
+	}
+	return purged
+}
+
+// PurgeAll implements Administered.PurgeAll (see MemoryRequestCache.PurgeAll)
+func (c *MemoryIdempotencyCache) PurgeAll(ctx context.Context) int {
+	c.mu.Lock()
+	keys := make([]string, 0, len(c.keys))
+	for key := range c.keys {
+		keys = append(keys, key)
+	}
+	c.mu.Unlock()
+
+	return c.Purge(ctx, keys)
+}
+
+// Snapshot implements IdempotencySnapshotter.Snapshot
+func (c *MemoryIdempotencyCache) Snapshot() []IdempotencySnapshotEntry {
+	c.mu.Lock()
+	keys := make([]string, 0, len(c.keys))
+	for key := range c.keys {
+		keys = append(keys, key)
+	}
+	c.mu.Unlock()
+
+	entries := make([]IdempotencySnapshotEntry, 0, len(keys))
+	for _, key := range keys {
+		if value, found := c.cache.Get(key); found {
+			entries = append(entries, IdempotencySnapshotEntry{Key: key, Entry: value.entry, ExpiresAt: value.expiresAt})
+		}
+	}
+	return entries
+}
+
+// Restore implements IdempotencySnapshotter.Restore
+func (c *MemoryIdempotencyCache) Restore(entries []IdempotencySnapshotEntry) int {
+	restored := 0
+	for _, entry := range entries {
+		ttl := time.Until(entry.ExpiresAt)
+		if ttl <= 0 {
+			continue
+		}
+		if c.set(entry.Key, entry.Entry, ttl) {
+			if restored++; restored%restoreBatch == 0 {
+				c.cache.Wait()
+			}
+		}
+	}
+	c.cache.Wait()
+	return restored
+}
+
+// UsedBytes implements Sized.UsedBytes
+func (c *MemoryIdempotencyCache) UsedBytes() int64 {
+	return usedBytes(c.cache)
 }
 
-// HashBody generates a SHA-256 hash of the body
-func HashBody(body []byte) string {
-	hash := sha256.Sum256(body)
-	return hex.EncodeToString(hash[:])
+// MaxBytes implements Sized.MaxBytes
+func (c *MemoryIdempotencyCache) MaxBytes() int64 {
+	return c.cache.MaxCost()
 }
 
-// Close closes the cache
-func (c *IdempotencyCache) Close() {
+// Close implements IdempotencyCache.Close
+func (c *MemoryIdempotencyCache) Close() {
 	c.cache.Close()
 	c.logger.Info("idempotency cache closed")
 }
@@ -0,0 +1,11 @@
+package cache
+
+// NamespacedKey prefixes a cache key with its namespace (e.g. the tenant ID),
+// so entries of different namespaces never collide
+func NamespacedKey(namespace, key string) string {
+	return namespace + ":" + key
+}
+
+// ExchangeRatesTag indexes, like an item ID, the responses with converted prices,
+// so a new exchange-rate table evicts all of them
+const ExchangeRatesTag = "@exchange-rates"
@@ -0,0 +1,183 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"go.uber.org/zap"
+)
+
+// PopularComparison is a comparison request and how many times it was requested
+type PopularComparison struct {
+	Tenant  string                `json:"tenant"`
+	Locale  string                `json:"locale"`
+	Request domain.CompareRequest `json:"request"`
+	Count   int64                 `json:"count"`
+}
+
+// PopularComparisons counts the requests of the comparisons served through the request cache,
+// so the most requested ones can be precomputed into an empty cache (after a deploy or a catalog
+// reload). The top entries are persisted to an optional file that survives restarts.
+//
+// Up to twice the kept entries are tracked: beyond that the least requested are dropped and the
+// counts of the rest halved, so comparisons popular now overtake those that were popular before.
+type PopularComparisons struct {
+	logger   *zap.Logger
+	filePath string // Optional JSON file that survives restarts
+	limit    int    // Entries returned by Top and persisted
+
+	mu      sync.Mutex
+	entries map[string]*PopularComparison // Identity of the comparison → entry
+}
+
+// NewPopularComparisons creates the tracker, restoring the counts from filePath when the file exists.
+// A limit of 0 disables it.
+func NewPopularComparisons(filePath string, limit int, logger *zap.Logger) (*PopularComparisons, error) {
+	popular := &PopularComparisons{
+		logger:   logger,
+		filePath: filePath,
+		limit:    limit,
+		entries:  make(map[string]*PopularComparison),
+	}
+
+	if filePath == "" || limit == 0 {
+		return popular, nil
+	}
+
+	content, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return popular, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read popular comparisons: %w", err)
+	}
+	var saved []PopularComparison
+	if err := json.Unmarshal(content, &saved); err != nil {
+		return nil, fmt.Errorf("failed to parse popular comparisons: %w", err)
+	}
+	for i := range saved {
+		// Files written before requests were canonical may hold the same comparison twice
+		entry := saved[i]
+		entry.Request = entry.Request.Canonical()
+		identity := comparisonIdentity(entry.Tenant, entry.Locale, entry.Request)
+		if existing, exists := popular.entries[identity]; exists {
+			existing.Count += entry.Count
+			continue
+		}
+		popular.entries[identity] = &entry
+	}
+
+	logger.Info("popular comparisons restored",
+		zap.String("file", filePath),
+		zap.Int("comparisons", len(popular.entries)),
+	)
+
+	return popular, nil
+}
+
+// Record counts one request of a comparison in a locale. Equivalent requests (IDs in another
+// order, duplicated, currency in lower case...) count as the same comparison, kept in canonical form.
+func (p *PopularComparisons) Record(tenantID, locale string, req domain.CompareRequest) {
+	if p.limit == 0 {
+		return
+	}
+	req = req.Canonical()
+	identity := comparisonIdentity(tenantID, locale, req)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if entry, exists := p.entries[identity]; exists {
+		entry.Count++
+		return
+	}
+	p.entries[identity] = &PopularComparison{Tenant: tenantID, Locale: locale, Request: req, Count: 1}
+
+	if len(p.entries) > 2*p.limit {
+		for _, entry := range p.sorted()[p.limit:] {
+			delete(p.entries, comparisonIdentity(entry.Tenant, entry.Locale, entry.Request))
+		}
+		for _, entry := range p.entries {
+			entry.Count /= 2
+		}
+	}
+}
+
+// Top returns up to limit comparisons, most requested first
+func (p *PopularComparisons) Top() []PopularComparison {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sorted := p.sorted()
+	top := make([]PopularComparison, 0, min(len(sorted), p.limit))
+	for _, entry := range sorted[:min(len(sorted), p.limit)] {
+		top = append(top, *entry)
+	}
+	return top
+}
+
+// sorted returns the tracked entries, most requested first (ties by tenant, locale and IDs).
+// The lock must be held.
+func (p *PopularComparisons) sorted() []*PopularComparison {
+	sorted := make([]*PopularComparison, 0, len(p.entries))
+	for _, entry := range p.entries {
+		sorted = append(sorted, entry)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return comparisonIdentity(sorted[i].Tenant, sorted[i].Locale, sorted[i].Request) <
+			comparisonIdentity(sorted[j].Tenant, sorted[j].Locale, sorted[j].Request)
+	})
+	return sorted
+}
+
+// Persist saves the top comparisons every interval until ctx is done
+func (p *PopularComparisons) Persist(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.Save(); err != nil {
+				p.logger.Error("failed to persist popular comparisons", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Save writes the top comparisons to the file
+func (p *PopularComparisons) Save() error {
+	if p.filePath == "" || p.limit == 0 {
+		return nil
+	}
+
+	content, err := json.Marshal(p.Top())
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(p.filePath, ".popular-comparisons-*", content)
+}
+
+// comparisonIdentity identifies a comparison by its tenant, locale and canonical request parameters,
+// the same canonical form hashed by the cache key (see domain.CompareRequest.Canonical)
+func comparisonIdentity(tenantID, locale string, req domain.CompareRequest) string {
+	fields := "*"
+	if req.Fields != nil {
+		fields = strings.Join(*req.Fields, ",")
+	}
+	return strings.Join([]string{
+		tenantID, locale, strings.Join(req.Ids, ","), fields, req.Currency, string(req.Unavailable),
+	}, "\x00")
+}
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"go.uber.org/zap"
+)
+
+func TestPopularComparisons_Top(t *testing.T) {
+	popular, err := NewPopularComparisons("", 2, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewPopularComparisons() error = %v", err)
+	}
+
+	ab := domain.CompareRequest{Ids: []string{"a", "b"}}
+	cd := domain.CompareRequest{Ids: []string{"c", "d"}}
+	fields := []string{"price"}
+	abPrice := domain.CompareRequest{Ids: []string{"a", "b"}, Fields: &fields}
+
+	for i := 0; i < 3; i++ {
+		popular.Record("default", "en", ab)
+	}
+	popular.Record("default", "es-MX", ab)
+	popular.Record("default", "es-MX", ab)
+	popular.Record("default", "en", abPrice)
+	popular.Record("default", "en", cd)
+
+	top := popular.Top()
+	if len(top) != 2 {
+		t.Fatalf("Expected the limit of 2 comparisons, got %d", len(top))
+	}
+	// Locales and fields are told apart: the same IDs in es-MX are the second most requested
+	if top[0].Locale != "en" || top[0].Count != 3 || top[1].Locale != "es-MX" || top[1].Count != 2 {
+		t.Errorf("Expected ab in en (3) then ab in es-MX (2), got %+v", top)
+	}
+}
+
+func TestPopularComparisons_PrunesLeastRequested(t *testing.T) {
+	popular, err := NewPopularComparisons("", 1, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewPopularComparisons() error = %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		popular.Record("default", "en", domain.CompareRequest{Ids: []string{"a", "b"}})
+	}
+	popular.Record("default", "en", domain.CompareRequest{Ids: []string{"c", "d"}})
+	// A third comparison exceeds twice the limit: only the most requested is kept, its count halved
+	popular.Record("default", "en", domain.CompareRequest{Ids: []string{"e", "f"}})
+
+	if len(popular.entries) != 1 {
+		t.Fatalf("Expected 1 tracked comparison after pruning, got %d", len(popular.entries))
+	}
+	if top := popular.Top(); top[0].Request.Ids[0] != "a" || top[0].Count != 2 {
+		t.Errorf("Expected ab with a halved count of 2, got %+v", top)
+	}
+}
+
+func TestPopularComparisons_SaveAndRestore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "popular.json")
+	popular, err := NewPopularComparisons(path, 10, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewPopularComparisons() error = %v", err)
+	}
+
+	fields := []string{"price", "rating"}
+	req := domain.CompareRequest{Ids: []string{"a", "b"}, Fields: &fields, Currency: "MXN", Unavailable: domain.UnavailableExclude}
+	popular.Record("store-mx", "es-MX", req)
+	popular.Record("store-mx", "es-MX", req)
+	if err := popular.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	restored, err := NewPopularComparisons(path, 10, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewPopularComparisons() error = %v", err)
+	}
+	top := restored.Top()
+	if len(top) != 1 {
+		t.Fatalf("Expected 1 restored comparison, got %d", len(top))
+	}
+	got := top[0]
+	if got.Tenant != "store-mx" || got.Locale != "es-MX" || got.Count != 2 || got.Request.Currency != "MXN" ||
+		got.Request.Unavailable != domain.UnavailableExclude || got.Request.Fields == nil || len(*got.Request.Fields) != 2 {
+		t.Errorf("Restored comparison = %+v", got)
+	}
+
+	// Restored counts keep growing with the requests of the new process
+	restored.Record("store-mx", "es-MX", req)
+	if count := restored.Top()[0].Count; count != 3 {
+		t.Errorf("Expected the restored count to grow to 3, got %d", count)
+	}
+}
+
+func TestPopularComparisons_CountsEquivalentRequestsTogether(t *testing.T) {
+	popular, err := NewPopularComparisons("", 2, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewPopularComparisons() error = %v", err)
+	}
+
+	priceRating := []string{"rating", "price", "rating"}
+	popular.Record("default", "en", domain.CompareRequest{Ids: []string{"a", "b"}, Currency: "USD"})
+	popular.Record("default", "en", domain.CompareRequest{Ids: []string{"b", "a", "a"}, Currency: "usd"})
+	popular.Record("default", "en", domain.CompareRequest{Ids: []string{"a", "b"}, Currency: "USD", Unavailable: domain.UnavailableFlag})
+	popular.Record("default", "en", domain.CompareRequest{Ids: []string{"c", "d"}, Fields: &priceRating})
+	popular.Record("default", "en", domain.CompareRequest{Ids: []string{"d", "c"}, Fields: &[]string{"price", "rating"}})
+
+	top := popular.Top()
+	if len(top) != 2 {
+		t.Fatalf("Expected 2 comparisons, got %+v", top)
+	}
+	if top[0].Count != 3 || !reflect.DeepEqual(top[0].Request.Ids, []string{"a", "b"}) || top[0].Request.Currency != "USD" {
+		t.Errorf("Expected ab in USD requested 3 times, got %+v", top[0])
+	}
+	if top[1].Count != 2 || !reflect.DeepEqual(*top[1].Request.Fields, []string{"price", "rating"}) {
+		t.Errorf("Expected cd on price and rating requested 2 times, got %+v", top[1])
+	}
+}
@@ -0,0 +1,395 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// Layout of the keys under the configured prefix
+const (
+	redisResponsePrefix    = "compare:"     // Cache key → serialized CachedResponse
+	redisItemIndexPrefix   = "item:"        // Item ID → set of the cache keys that involve it
+	redisIdempotencyPrefix = "idempotency:" // Idempotency key → serialized IdempotentEntry
+)
+
+// NewRedisClient connects to the Redis server of the URL (redis://[:password@]host:port/db)
+// and checks it answers
+func NewRedisClient(ctx context.Context, url string) (*redis.Client, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+
+	client := redis.NewClient(opts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	return client, nil
+}
+
+// RedisRequestCache implements RequestCache on a Redis server shared by every replica.
+// Responses are stored as JSON with the hard TTL of the cache; the item index is a set per item
+// whose TTL is refreshed on every Set, so it outlives the entries it points to.
+// Redis errors are logged and behave as misses: the cache never fails a request.
+type RedisRequestCache struct {
+	client    redis.UniversalClient
+	keyPrefix string
+	logger    *zap.Logger
+	ttl       TTL
+
+	hits, misses, added atomic.Uint64 // Activity of this replica, for Stats
+}
+
+// NewRedisRequestCache creates the cache. The client is owned by the caller, Close does not close it.
+func NewRedisRequestCache(client redis.UniversalClient, keyPrefix string, ttl TTL, logger *zap.Logger) *RedisRequestCache {
+	logger.Info("request cache initialized",
+		zap.String("backend", "redis"),
+		zap.String("key_prefix", keyPrefix),
+		zap.Duration("soft_ttl", ttl.Soft),
+		zap.Duration("hard_ttl", ttl.keep()),
+	)
+
+	return &RedisRequestCache{
+		client:    client,
+		keyPrefix: keyPrefix,
+		logger:    logger,
+		ttl:       ttl,
+	}
+}
+
+// Get implements RequestCache.Get
+func (c *RedisRequestCache) Get(ctx context.Context, key string) (CachedResponse, bool) {
+	var response CachedResponse
+	if !redisGet(ctx, c.client, c.responseKey(key), &response, c.logger) {
+		c.misses.Add(1)
+		c.logger.Debug("cache miss", zap.String("key", key))
+		return CachedResponse{}, false
+	}
+	c.hits.Add(1)
+	response.Stale = c.ttl.stale(response.StoredAt)
+	c.logger.Debug("cache hit", zap.String("key", key), zap.Bool("stale", response.Stale))
+	return response, true
+}
+
+// Set implements RequestCache.Set
+func (c *RedisRequestCache) Set(ctx context.Context, key string, itemIDs []string, response CachedResponse) {
+	response.StoredAt = time.Now()
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		c.logger.Error("failed to encode cached response", zap.String("key", key), zap.Error(err))
+		return
+	}
+
+	responseKey := c.responseKey(key)
+	_, err = c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, responseKey, encoded, c.ttl.keep())
+		for _, id := range itemIDs {
+			indexKey := c.itemIndexKey(id)
+			pipe.SAdd(ctx, indexKey, responseKey)
+			pipe.PExpire(ctx, indexKey, c.ttl.keep())
+		}
+		return nil
+	})
+	if err != nil {
+		c.logger.Warn("failed to store cached response", zap.String("key", key), zap.Error(err))
+		return
+	}
+	c.added.Add(1)
+	c.logger.Debug("cache set", zap.String("key", key), zap.Duration("ttl", c.ttl.keep()))
+}
+
+// InvalidateItems implements RequestCache.InvalidateItems.
+// The index of an item may still name expired or replaced entries; only existing ones are counted.
+func (c *RedisRequestCache) InvalidateItems(ctx context.Context, itemIDs []string) int {
+	if len(itemIDs) == 0 {
+		return 0
+	}
+
+	indexKeys := make([]string, len(itemIDs))
+	members := make([]*redis.StringSliceCmd, len(itemIDs))
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range itemIDs {
+			indexKeys[i] = c.itemIndexKey(id)
+			members[i] = pipe.SMembers(ctx, indexKeys[i])
+		}
+		return nil
+	})
+	if err != nil {
+		c.logger.Warn("failed to read the cache item index", zap.Error(err))
+		return 0
+	}
+
+	keys := make(map[string]struct{})
+	for _, cmd := range members {
+		for _, key := range cmd.Val() {
+			keys[key] = struct{}{}
+		}
+	}
+	responseKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		responseKeys = append(responseKeys, key)
+	}
+
+	var deleted *redis.IntCmd
+	_, err = c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if len(responseKeys) > 0 {
+			deleted = pipe.Del(ctx, responseKeys...)
+		}
+		pipe.Del(ctx, indexKeys...)
+		return nil
+	})
+	if err != nil {
+		c.logger.Warn("failed to invalidate cache entries", zap.Error(err))
+		return 0
+	}
+
+	evicted := 0
+	if deleted != nil {
+		evicted = int(deleted.Val())
+	}
+	if evicted > 0 {
+		c.logger.Debug("cache entries invalidated",
+			zap.Int("items", len(itemIDs)),
+			zap.Int("entries", evicted),
+		)
+	}
+	return evicted
+}
+
+// Stats implements Administered.Stats. Counting the entries scans the keys of the cache.
+func (c *RedisRequestCache) Stats(ctx context.Context) Stats {
+	entries := redisCount(ctx, c.client, c.responseKey(""), c.logger)
+	return newStats(entries, c.hits.Load(), c.misses.Load(), c.added.Load(), 0)
+}
+
+// Keys implements Administered.Keys
+func (c *RedisRequestCache) Keys(ctx context.Context, limit int) []string {
+	return redisKeys(ctx, c.client, c.responseKey(""), limit, c.logger)
+}
+
+// Purge implements Administered.Purge.
+// The item index keeps naming the purged entries until it expires, as after InvalidateItems.
+func (c *RedisRequestCache) Purge(ctx context.Context, keys []string) int {
+	responseKeys := make([]string, len(keys))
+	for i, key := range keys {
+		responseKeys[i] = c.responseKey(key)
+	}
+	return redisDel(ctx, c.client, responseKeys, c.logger)
+}
+
+// PurgeAll implements Administered.PurgeAll: the entries and the item index
+func (c *RedisRequestCache) PurgeAll(ctx context.Context) int {
+	purged := redisPurgeAll(ctx, c.client, c.responseKey(""), c.logger)
+	redisPurgeAll(ctx, c.client, c.itemIndexKey(""), c.logger)
+	return purged
+}
+
+// Close implements RequestCache.Close
+func (c *RedisRequestCache) Close() {
+	c.logger.Info("request cache closed")
+}
+
+func (c *RedisRequestCache) responseKey(key string) string {
+	return c.keyPrefix + redisResponsePrefix + key
+}
+
+func (c *RedisRequestCache) itemIndexKey(itemID string) string {
+	return c.keyPrefix + redisItemIndexPrefix + itemID
+}
+
+// RedisIdempotencyCache implements IdempotencyCache on a Redis server shared by every replica,
+// so a retry reaching another replica still replays the original response.
+type RedisIdempotencyCache struct {
+	client    redis.UniversalClient
+	keyPrefix string
+	logger    *zap.Logger
+	ttl       time.Duration
+
+	hits, misses, added atomic.Uint64 // Activity of this replica, for Stats
+}
+
+// NewRedisIdempotencyCache creates the cache. The client is owned by the caller, Close does not close it.
+func NewRedisIdempotencyCache(client redis.UniversalClient, keyPrefix string, ttl time.Duration, logger *zap.Logger) *RedisIdempotencyCache {
+	logger.Info("idempotency cache initialized",
+		zap.String("backend", "redis"),
+		zap.String("key_prefix", keyPrefix),
+		zap.Duration("ttl", ttl),
+	)
+
+	return &RedisIdempotencyCache{
+		client:    client,
+		keyPrefix: keyPrefix,
+		logger:    logger,
+		ttl:       ttl,
+	}
+}
+
+// Get implements IdempotencyCache.Get
+func (c *RedisIdempotencyCache) Get(ctx context.Context, key string) (IdempotentEntry, bool) {
+	var entry IdempotentEntry
+	if !redisGet(ctx, c.client, c.entryKey(key), &entry, c.logger) {
+		c.misses.Add(1)
+		c.logger.Debug("idempotency miss", zap.String("key", key))
+		return IdempotentEntry{}, false
+	}
+	c.hits.Add(1)
+	c.logger.Debug("idempotency hit", zap.String("key", key))
+	return entry, true
+}
+
+// Set implements IdempotencyCache.Set
+func (c *RedisIdempotencyCache) Set(ctx context.Context, key string, entry IdempotentEntry) {
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		c.logger.Error("failed to encode idempotency entry", zap.String("key", key), zap.Error(err))
+		return
+	}
+	if err := c.client.Set(ctx, c.entryKey(key), encoded, c.ttl).Err(); err != nil {
+		c.logger.Warn("failed to store idempotency entry", zap.String("key", key), zap.Error(err))
+		return
+	}
+	c.added.Add(1)
+	c.logger.Debug("idempotency set", zap.String("key", key), zap.Duration("ttl", c.ttl))
+}
+
+// Stats implements Administered.Stats. Counting the entries scans the keys of the cache.
+func (c *RedisIdempotencyCache) Stats(ctx context.Context) Stats {
+	entries := redisCount(ctx, c.client, c.entryKey(""), c.logger)
+	return newStats(entries, c.hits.Load(), c.misses.Load(), c.added.Load(), 0)
+}
+
+// Keys implements Administered.Keys
+func (c *RedisIdempotencyCache) Keys(ctx context.Context, limit int) []string {
+	return redisKeys(ctx, c.client, c.entryKey(""), limit, c.logger)
+}
+
+// Purge implements Administered.Purge
+func (c *RedisIdempotencyCache) Purge(ctx context.Context, keys []string) int {
+	entryKeys := make([]string, len(keys))
+	for i, key := range keys {
+		entryKeys[i] = c.entryKey(key)
+	}
+	return redisDel(ctx, c.client, entryKeys, c.logger)
+}
+
+// PurgeAll implements Administered.PurgeAll
+func (c *RedisIdempotencyCache) PurgeAll(ctx context.Context) int {
+	return redisPurgeAll(ctx, c.client, c.entryKey(""), c.logger)
+}
+
+// Close implements IdempotencyCache.Close
+func (c *RedisIdempotencyCache) Close() {
+	c.logger.Info("idempotency cache closed")
+}
+
+func (c *RedisIdempotencyCache) entryKey(key string) string {
+	return c.keyPrefix + redisIdempotencyPrefix + key
+}
+
+// redisGet reads and decodes a JSON value; missing keys, Redis errors and undecodable values
+// are all reported as not found (the last two are logged)
+func redisGet(ctx context.Context, client redis.UniversalClient, key string, value interface{}, logger *zap.Logger) bool {
+	encoded, err := client.Get(ctx, key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			logger.Warn("failed to read from Redis", zap.String("key", key), zap.Error(err))
+		}
+		return false
+	}
+	if err := json.Unmarshal(encoded, value); err != nil {
+		logger.Warn("failed to decode value from Redis", zap.String("key", key), zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// redisScanCount is the COUNT hint of the SCAN calls: keys examined per round trip
+const redisScanCount = 1000
+
+// redisScan calls fn with every batch of keys under the prefix until fn returns false.
+// Keys created or deleted meanwhile may or may not be seen.
+func redisScan(ctx context.Context, client redis.UniversalClient, prefix string, fn func(keys []string) bool) error {
+	pattern := redisGlobEscaper.Replace(prefix) + "*"
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(ctx, cursor, pattern, redisScanCount).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 && !fn(keys) {
+			return nil
+		}
+		if cursor = next; cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// redisGlobEscaper escapes the characters SCAN MATCH patterns give a meaning to
+var redisGlobEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`)
+
+// redisCount returns how many keys are under the prefix; -1 when Redis fails
+func redisCount(ctx context.Context, client redis.UniversalClient, prefix string, logger *zap.Logger) int {
+	count := 0
+	err := redisScan(ctx, client, prefix, func(keys []string) bool {
+		count += len(keys)
+		return true
+	})
+	if err != nil {
+		logger.Warn("failed to count cache entries in Redis", zap.Error(err))
+		return -1
+	}
+	return count
+}
+
+// redisKeys returns up to limit keys under the prefix, without it and sorted
+func redisKeys(ctx context.Context, client redis.UniversalClient, prefix string, limit int, logger *zap.Logger) []string {
+	sample := make([]string, 0, limit)
+	err := redisScan(ctx, client, prefix, func(keys []string) bool {
+		for _, key := range keys[:min(len(keys), limit-len(sample))] {
+			sample = append(sample, strings.TrimPrefix(key, prefix))
+		}
+		return len(sample) < limit
+	})
+	if err != nil {
+		logger.Warn("failed to list cache keys in Redis", zap.Error(err))
+	}
+	sort.Strings(sample)
+	return sample
+}
+
+// redisDel deletes the keys; returns how many existed
+func redisDel(ctx context.Context, client redis.UniversalClient, keys []string, logger *zap.Logger) int {
+	if len(keys) == 0 {
+		return 0
+	}
+	deleted, err := client.Del(ctx, keys...).Result()
+	if err != nil {
+		logger.Warn("failed to purge cache entries in Redis", zap.Error(err))
+	}
+	return int(deleted)
+}
+
+// redisPurgeAll deletes every key under the prefix; returns how many
+func redisPurgeAll(ctx context.Context, client redis.UniversalClient, prefix string, logger *zap.Logger) int {
+	purged := 0
+	err := redisScan(ctx, client, prefix, func(keys []string) bool {
+		purged += redisDel(ctx, client, keys, logger)
+		return true
+	})
+	if err != nil {
+		logger.Warn("failed to purge cache entries in Redis", zap.Error(err))
+	}
+	return purged
+}
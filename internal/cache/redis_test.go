@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/goccy/go-json"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client, err := NewRedisClient(context.Background(), "redis://"+server.Addr())
+	if err != nil {
+		t.Fatalf("NewRedisClient() error = %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return server, client
+}
+
+func TestRedisRequestCache_RoundTrip(t *testing.T) {
+	server, client := newTestRedis(t)
+	c := NewRedisRequestCache(client, "test:", TTL{Soft: time.Minute}, zap.NewNop())
+	ctx := context.Background()
+
+	response := CachedResponse{
+		Body:    json.RawMessage(`{"data":{"items":[{"id":"a","price":10},{"id":"b","price":20}]},"metadata":{"order":["a","b"]},"error":null}`),
+		Encoded: map[string][]byte{EncodingGzip: {0x1f, 0x8b, 0x08, 0x00}, EncodingBrotli: {0x0b, 0x80}},
+		ETag:    `"0123456789abcdef"`,
+	}
+	c.Set(ctx, "ab", []string{"a", "b"}, response)
+
+	cached, found := c.Get(ctx, "ab")
+	if !found {
+		t.Fatal("Expected a cache hit")
+	}
+	if cached.StoredAt.IsZero() || cached.Stale {
+		t.Errorf("Expected a fresh entry with its storage time, got stored at %v stale %v", cached.StoredAt, cached.Stale)
+	}
+	response.StoredAt = cached.StoredAt
+	want, _ := json.Marshal(response)
+	got, _ := json.Marshal(cached)
+	if string(got) != string(want) {
+		t.Errorf("Cached response = %s, want %s", got, want)
+	}
+
+	// Entries are namespaced by the prefix and expire with the TTL
+	if !server.Exists("test:compare:ab") {
+		t.Errorf("Expected the entry under the key prefix, got keys %v", server.Keys())
+	}
+	server.FastForward(time.Minute + time.Second)
+	if _, found := c.Get(ctx, "ab"); found {
+		t.Error("Expected the entry to expire with the TTL")
+	}
+	if len(server.Keys()) != 0 {
+		t.Errorf("Expected the item index to expire too, got keys %v", server.Keys())
+	}
+}
+
+func TestRedisRequestCache_ServesStaleUntilHardTTL(t *testing.T) {
+	server, client := newTestRedis(t)
+	c := NewRedisRequestCache(client, "test:", TTL{Soft: 50 * time.Millisecond, Hard: time.Minute}, zap.NewNop())
+	ctx := context.Background()
+
+	c.Set(ctx, "ab", []string{"a", "b"}, CachedResponse{ETag: "1"})
+	if ttl := server.TTL("test:compare:ab"); ttl != time.Minute {
+		t.Errorf("Expected the entry to be kept for the hard TTL, got %v", ttl)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if cached, found := c.Get(ctx, "ab"); !found || !cached.Stale || cached.ETag != "1" {
+		t.Errorf("Expected a stale hit past the soft TTL, got found %v stale %v", found, cached.Stale)
+	}
+
+	server.FastForward(time.Minute)
+	if _, found := c.Get(ctx, "ab"); found {
+		t.Error("Expected the entry to expire with the hard TTL")
+	}
+}
+
+func TestRedisRequestCache_InvalidateItems(t *testing.T) {
+	server, client := newTestRedis(t)
+	c := NewRedisRequestCache(client, "test:", TTL{Soft: time.Minute}, zap.NewNop())
+	ctx := context.Background()
+
+	c.Set(ctx, "ab", []string{"a", "b"}, CachedResponse{ETag: "ab"})
+	c.Set(ctx, "bc", []string{"b", "c"}, CachedResponse{ETag: "bc"})
+	c.Set(ctx, "cd", []string{"c", "d"}, CachedResponse{ETag: "cd"})
+
+	if evicted := c.InvalidateItems(ctx, []string{"a"}); evicted != 1 {
+		t.Errorf("Expected 1 evicted entry, got %d", evicted)
+	}
+	if _, found := c.Get(ctx, "ab"); found {
+		t.Error("Expected the entry of item a to be evicted")
+	}
+	if _, found := c.Get(ctx, "bc"); !found {
+		t.Error("Expected the entries without item a to be kept")
+	}
+
+	// The index of b still names the evicted entry: only existing entries are counted
+	if evicted := c.InvalidateItems(ctx, []string{"b", "x"}); evicted != 1 {
+		t.Errorf("Expected 1 evicted entry, got %d", evicted)
+	}
+	if evicted := c.InvalidateItems(ctx, []string{"c"}); evicted != 1 {
+		t.Errorf("Expected 1 evicted entry, got %d", evicted)
+	}
+	// Only the index of d, which expires with the TTL, is left
+	if keys := server.Keys(); len(keys) != 1 || keys[0] != "test:item:d" {
+		t.Errorf("Expected only the index of d left, got %v", keys)
+	}
+}
+
+func TestRedisRequestCache_SharedBetweenReplicas(t *testing.T) {
+	_, client := newTestRedis(t)
+	replicaA := NewRedisRequestCache(client, "test:", TTL{Soft: time.Minute}, zap.NewNop())
+	replicaB := NewRedisRequestCache(client, "test:", TTL{Soft: time.Minute}, zap.NewNop())
+	other := NewRedisRequestCache(client, "other:", TTL{Soft: time.Minute}, zap.NewNop())
+	ctx := context.Background()
+
+	replicaA.Set(ctx, "ab", []string{"a", "b"}, CachedResponse{ETag: "v1"})
+
+	if cached, found := replicaB.Get(ctx, "ab"); !found || cached.ETag != "v1" {
+		t.Errorf("Expected the entry of replica A to be served by replica B, got %+v (found %v)", cached, found)
+	}
+	if _, found := other.Get(ctx, "ab"); found {
+		t.Error("Expected another key prefix not to see the entry")
+	}
+	if evicted := replicaB.InvalidateItems(ctx, []string{"a"}); evicted != 1 {
+		t.Errorf("Expected replica B to evict the entry of replica A, got %d evicted", evicted)
+	}
+}
+
+func TestRedisIdempotencyCache_ReplaysRawResponse(t *testing.T) {
+	server, client := newTestRedis(t)
+	c := NewRedisIdempotencyCache(client, "test:", 5*time.Minute, zap.NewNop())
+	ctx := context.Background()
+
+	response := json.RawMessage(`{"data":{"items":["a","b"]},"error":null}`)
+	c.Set(ctx, "key-1", IdempotentEntry{BodyHash: HashBody([]byte(`{"ids":["a","b"]}`)), Response: response})
+
+	entry, found := c.Get(ctx, "key-1")
+	if !found {
+		t.Fatal("Expected an idempotency hit")
+	}
+	if entry.BodyHash != HashBody([]byte(`{"ids":["a","b"]}`)) {
+		t.Errorf("BodyHash = %s, want the hash of the original body", entry.BodyHash)
+	}
+	// The response is replayed byte for byte as it was first rendered
+	if string(entry.Response) != string(response) {
+		t.Errorf("Response = %s, want %s", entry.Response, response)
+	}
+
+	server.FastForward(5*time.Minute + time.Second)
+	if _, found := c.Get(ctx, "key-1"); found {
+		t.Error("Expected the entry to expire with the TTL")
+	}
+}
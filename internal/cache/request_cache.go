@@ -2,67 +2,269 @@ package cache
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dgraph-io/ristretto/v2"
 	"go.uber.org/zap"
 )
 
-// RequestCache manages the cache of comparison responses
-type RequestCache struct {
-	cache  *ristretto.Cache[string, CachedResponse]
+// MemoryRequestCache implements RequestCache in process, on top of ristretto.
+// Each replica keeps its own entries.
+type MemoryRequestCache struct {
+	cache  *ristretto.Cache[string, requestEntry]
 	logger *zap.Logger
-	ttl    time.Duration
+	ttl    TTL
+
+	evicted atomic.Uint64 // Entries evicted to make room
+
+	mu     sync.Mutex
+	keys   map[string]int                 // Cache key → values stored under it that have not exited (ristretto cannot list them)
+	byItem map[string]map[string]struct{} // Item ID → cache keys of the comparisons that include it
 }
 
-// CachedResponse represents a cached response
-type CachedResponse struct {
-	Data     interface{}
-	Metadata interface{}
+// requestEntry is the stored value: the response plus what is needed to unindex and snapshot it
+type requestEntry struct {
+	key       string
+	itemIDs   []string
+	expiresAt time.Time
+	response  CachedResponse
 }
 
-// NewRequestCache creates a new instance of the cache
-func NewRequestCache(maxSize int64, ttl time.Duration, logger *zap.Logger) (*RequestCache, error) {
-	cache, err := ristretto.NewCache(&ristretto.Config[string, CachedResponse]{
-		NumCounters: maxSize * 10, // Number of keys to track
-		MaxCost:     maxSize,      // Maximum cost (number of entries)
-		BufferItems: 64,           // Number of keys per buffer
+// NewMemoryRequestCache creates a new instance of the cache, holding up to maxBytes of responses
+func NewMemoryRequestCache(maxBytes int64, ttl TTL, logger *zap.Logger) (*MemoryRequestCache, error) {
+	c := &MemoryRequestCache{
+		logger: logger,
+		ttl:    ttl,
+		keys:   make(map[string]int),
+		byItem: make(map[string]map[string]struct{}),
+	}
+
+	cache, err := ristretto.NewCache(&ristretto.Config[string, requestEntry]{
+		NumCounters: numCounters(maxBytes),                    // Number of keys to track
+		MaxCost:     maxBytes,                                 // Maximum cost (bytes of serialized responses)
+		BufferItems: 64,                                       // Number of keys per buffer
+		Metrics:     true,                                     // Tracks the bytes in use
+		OnEvict:     countEvictions[requestEntry](&c.evicted), // Counts the evictions for room
+		OnExit:      c.unindex,                                // Evicted, expired, rejected, replaced or deleted
 	})
 	if err != nil {
 		return nil, err
 	}
+	c.cache = cache
 
 	logger.Info("request cache initialized",
-		zap.Int64("max_size", maxSize),
-		zap.Duration("ttl", ttl),
+		zap.Int64("max_bytes", maxBytes),
+		zap.Duration("soft_ttl", ttl.Soft),
+		zap.Duration("hard_ttl", ttl.keep()),
 	)
 
-	return &RequestCache{
-		cache:  cache,
-		logger: logger,
-		ttl:    ttl,
-	}, nil
+	return c, nil
 }
 
-// Get gets a response from the cache
-func (c *RequestCache) Get(ctx context.Context, key string) (CachedResponse, bool) {
+// Get implements RequestCache.Get
+func (c *MemoryRequestCache) Get(ctx context.Context, key string) (CachedResponse, bool) {
 	if value, found := c.cache.Get(key); found {
-		c.logger.Debug("cache hit", zap.String("key", key))
-		return value, true
+		response := value.response
+		response.Stale = c.ttl.stale(response.StoredAt)
+		c.logger.Debug("cache hit", zap.String("key", key), zap.Bool("stale", response.Stale))
+		return response, true
 	}
 	c.logger.Debug("cache miss", zap.String("key", key))
 	return CachedResponse{}, false
 }
 
-// Set stores a response in the cache
-func (c *RequestCache) Set(ctx context.Context, key string, response CachedResponse) {
-	// Cost = 1 (each entry counts as 1)
-	c.cache.SetWithTTL(key, response, 1, c.ttl)
-	c.logger.Debug("cache set", zap.String("key", key), zap.Duration("ttl", c.ttl))
+// Set implements RequestCache.Set
+func (c *MemoryRequestCache) Set(ctx context.Context, key string, itemIDs []string, response CachedResponse) {
+	response.StoredAt = time.Now()
+	if c.set(key, itemIDs, response, c.ttl.keep()) {
+		c.logger.Debug("cache set", zap.String("key", key), zap.Int64("bytes", response.size()), zap.Duration("ttl", c.ttl.keep()))
+	}
+}
+
+// set stores and indexes an entry for ttl. Returns false when ristretto drops it.
+func (c *MemoryRequestCache) set(key string, itemIDs []string, response CachedResponse, ttl time.Duration) bool {
+	entry := requestEntry{
+		key:       key,
+		itemIDs:   itemIDs,
+		expiresAt: time.Now().Add(ttl),
+		response:  response,
+	}
+
+	c.mu.Lock()
+	c.keys[key]++
+	for _, id := range itemIDs {
+		keys, exists := c.byItem[id]
+		if !exists {
+			keys = make(map[string]struct{})
+			c.byItem[id] = keys
+		}
+		keys[key] = struct{}{}
+	}
+	c.mu.Unlock()
+
+	// Cost = encoded size, so a 50-item comparison weighs what it takes
+	if !c.cache.SetWithTTL(key, entry, response.size(), ttl) {
+		// Dropped by a full buffer: it will never exit the cache, so unindex it now
+		c.unindex(entry)
+		return false
+	}
+	return true
+}
+
+// InvalidateItems implements RequestCache.InvalidateItems
+func (c *MemoryRequestCache) InvalidateItems(ctx context.Context, itemIDs []string) int {
+	c.mu.Lock()
+	keys := make(map[string]struct{})
+	for _, id := range itemIDs {
+		for key := range c.byItem[id] {
+			keys[key] = struct{}{}
+		}
+	}
+	c.mu.Unlock()
+
+	// Del calls unindex, so the lock must not be held here
+	for key := range keys {
+		c.cache.Del(key)
+	}
+
+	if len(keys) > 0 {
+		c.logger.Debug("cache entries invalidated",
+			zap.Int("items", len(itemIDs)),
+			zap.Int("entries", len(keys)),
+		)
+	}
+	return len(keys)
 }
 
-// Close closes the cache
-func (c *RequestCache) Close() {
+// unindex removes an entry that left the cache from the item index once no value of its key is
+// left. Every value stored exits exactly once: replaced by a newer Set, or rejected when ristretto
+// still had the previous Set of the key buffered, in which case the previous value stays.
+func (c *MemoryRequestCache) unindex(entry requestEntry) {
+	if entry.key == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.keys[entry.key]--; c.keys[entry.key] > 0 {
+		return
+	}
+	delete(c.keys, entry.key)
+	for _, id := range entry.itemIDs {
+		keys := c.byItem[id]
+		delete(keys, entry.key)
+		if len(keys) == 0 {
+			delete(c.byItem, id)
+		}
+	}
+}
+
+// Snapshot implements RequestSnapshotter.Snapshot
+func (c *MemoryRequestCache) Snapshot() []RequestSnapshotEntry {
+	c.mu.Lock()
+	keys := make([]string, 0, len(c.keys))
+	for key := range c.keys {
+		keys = append(keys, key)
+	}
+	c.mu.Unlock()
+
+	entries := make([]RequestSnapshotEntry, 0, len(keys))
+	for _, key := range keys {
+		if value, found := c.cache.Get(key); found {
+			entries = append(entries, RequestSnapshotEntry{
+				Key:       key,
+				ItemIDs:   value.itemIDs,
+				Response:  value.response,
+				ExpiresAt: value.expiresAt,
+			})
+		}
+	}
+	return entries
+}
+
+// Restore implements RequestSnapshotter.Restore.
+// Entries keep their storage time, so they turn stale when they would have.
+func (c *MemoryRequestCache) Restore(entries []RequestSnapshotEntry, keep func(CachedResponse) bool) int {
+	restored := 0
+	for _, entry := range entries {
+		ttl := time.Until(entry.ExpiresAt)
+		if ttl <= 0 || !keep(entry.Response) {
+			continue
+		}
+		if c.set(entry.Key, entry.ItemIDs, entry.Response, ttl) {
+			if restored++; restored%restoreBatch == 0 {
+				c.cache.Wait()
+			}
+		}
+	}
+	c.cache.Wait()
+	return restored
+}
+
+// Stats implements Administered.Stats from ristretto's metrics
+func (c *MemoryRequestCache) Stats(ctx context.Context) Stats {
+	c.mu.Lock()
+	entries := len(c.keys)
+	c.mu.Unlock()
+
+	metrics := c.cache.Metrics
+	stats := newStats(entries, metrics.Hits(), metrics.Misses(), metrics.KeysAdded(), c.evicted.Load())
+	stats.UsedBytes, stats.MaxBytes = c.UsedBytes(), c.MaxBytes()
+	return stats
+}
+
+// Keys implements Administered.Keys
+func (c *MemoryRequestCache) Keys(ctx context.Context, limit int) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return sampleKeys(c.keys, limit)
+}
+
+// Purge implements Administered.Purge
+func (c *MemoryRequestCache) Purge(ctx context.Context, keys []string) int {
+	purged := 0
+	for _, key := range keys {
+		c.mu.Lock()
+		_, exists := c.keys[key]
+		c.mu.Unlock()
+
+		// Del calls unindex, so the lock must not be held here
+		if exists {
+			c.cache.Del(key)
+			purged++
+		}
+	}
+	return purged
+}
+
+// PurgeAll implements Administered.PurgeAll.
+// Entries are deleted one by one: ristretto's Clear must not run alongside Get and Set.
+func (c *MemoryRequestCache) PurgeAll(ctx context.Context) int {
+	c.mu.Lock()
+	keys := make([]string, 0, len(c.keys))
+	for key := range c.keys {
+		keys = append(keys, key)
+	}
+	c.mu.Unlock()
+
+	return c.Purge(ctx, keys)
+}
+
+// Close implements RequestCache.Close
+func (c *MemoryRequestCache) Close() {
 	c.cache.Close()
 	c.logger.Info("request cache closed")
 }
+
+// UsedBytes implements Sized.UsedBytes
+func (c *MemoryRequestCache) UsedBytes() int64 {
+	return usedBytes(c.cache)
+}
+
+// MaxBytes implements Sized.MaxBytes
+func (c *MemoryRequestCache) MaxBytes() int64 {
+	return c.cache.MaxCost()
+}
@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestMemoryRequestCache_InvalidateItems(t *testing.T) {
+	c, err := NewMemoryRequestCache(1<<20, TTL{Soft: time.Minute}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewMemoryRequestCache() error = %v", err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+
+	c.Set(ctx, "ab", []string{"a", "b"}, CachedResponse{ETag: "ab"})
+	c.Set(ctx, "bc", []string{"b", "c"}, CachedResponse{ETag: "bc"})
+	c.Set(ctx, "cd", []string{"c", "d"}, CachedResponse{ETag: "cd"})
+	c.cache.Wait()
+
+	if evicted := c.InvalidateItems(ctx, []string{"a"}); evicted != 1 {
+		t.Errorf("Expected 1 evicted entry, got %d", evicted)
+	}
+	if _, found := c.Get(ctx, "ab"); found {
+		t.Error("Expected the entry of item a to be evicted")
+	}
+	if _, found := c.Get(ctx, "bc"); !found {
+		t.Error("Expected the entries without item a to be kept")
+	}
+
+	if evicted := c.InvalidateItems(ctx, []string{"c", "x"}); evicted != 2 {
+		t.Errorf("Expected 2 evicted entries, got %d", evicted)
+	}
+	c.cache.Wait()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.byItem) != 0 {
+		t.Errorf("Expected an empty item index, got %v", c.byItem)
+	}
+}
+
+func TestMemoryRequestCache_ReplacedEntryKeepsIndex(t *testing.T) {
+	c, err := NewMemoryRequestCache(1<<20, TTL{Soft: time.Minute}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewMemoryRequestCache() error = %v", err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+
+	c.Set(ctx, "ab", []string{"a", "b"}, CachedResponse{ETag: "1"})
+	c.cache.Wait()
+	c.Set(ctx, "ab", []string{"a", "b"}, CachedResponse{ETag: "2"})
+	c.cache.Wait()
+
+	// Replacing the entry must not unindex the new one
+	if evicted := c.InvalidateItems(ctx, []string{"b"}); evicted != 1 {
+		t.Errorf("Expected the replaced entry to stay indexed, got %d evicted", evicted)
+	}
+}
+
+func TestMemoryRequestCache_ServesStaleUntilHardTTL(t *testing.T) {
+	c, err := NewMemoryRequestCache(1<<20, TTL{Soft: 50 * time.Millisecond, Hard: 200 * time.Millisecond}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewMemoryRequestCache() error = %v", err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+
+	c.Set(ctx, "ab", []string{"a", "b"}, CachedResponse{ETag: "1"})
+	c.cache.Wait()
+
+	if cached, found := c.Get(ctx, "ab"); !found || cached.Stale {
+		t.Errorf("Expected a fresh hit, got found %v stale %v", found, cached.Stale)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if cached, found := c.Get(ctx, "ab"); !found || !cached.Stale || cached.ETag != "1" {
+		t.Errorf("Expected a stale hit past the soft TTL, got found %v stale %v", found, cached.Stale)
+	}
+
+	// A refresh makes it fresh again
+	c.Set(ctx, "ab", []string{"a", "b"}, CachedResponse{ETag: "2"})
+	c.cache.Wait()
+	if cached, found := c.Get(ctx, "ab"); !found || cached.Stale || cached.ETag != "2" {
+		t.Errorf("Expected the refreshed entry to be fresh, got found %v stale %v", found, cached.Stale)
+	}
+}
+
+func TestMemoryRequestCache_ChargesEncodedSize(t *testing.T) {
+	c, err := NewMemoryRequestCache(1<<20, TTL{Soft: time.Minute}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewMemoryRequestCache() error = %v", err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+
+	if c.UsedBytes() != 0 || c.MaxBytes() != 1<<20 {
+		t.Fatalf("Expected an empty 1 MiB cache, got %d of %d bytes", c.UsedBytes(), c.MaxBytes())
+	}
+
+	// An entry costs its body plus its compressed variants (and ristretto's bookkeeping)
+	c.Set(ctx, "small", []string{"a"}, CachedResponse{Body: make([]byte, 2<<10)})
+	c.Set(ctx, "large", []string{"a"}, CachedResponse{
+		Body:    make([]byte, 50<<10),
+		Encoded: map[string][]byte{EncodingGzip: make([]byte, 5<<10)},
+	})
+	c.cache.Wait()
+	if want := int64(2<<10 + 50<<10 + len(EncodingGzip) + 5<<10); c.UsedBytes() < want || c.UsedBytes() > want+1<<10 {
+		t.Errorf("UsedBytes() = %d, want about %d", c.UsedBytes(), want)
+	}
+
+	// Entries over the budget are rejected
+	c.Set(ctx, "huge", []string{"a"}, CachedResponse{Body: make([]byte, 2<<20)})
+	c.cache.Wait()
+	if _, found := c.Get(ctx, "huge"); found {
+		t.Error("Expected an entry over the budget to be rejected")
+	}
+
+	if evicted := c.InvalidateItems(ctx, []string{"a"}); evicted != 2 {
+		t.Errorf("Expected 2 evicted entries, got %d", evicted)
+	}
+	c.cache.Wait()
+	if c.UsedBytes() != 0 {
+		t.Errorf("Expected evicted entries to release their bytes, got %d in use", c.UsedBytes())
+	}
+}
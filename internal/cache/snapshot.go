@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// restoreBatch is how many entries are restored between waits for ristretto to apply them:
+// its set buffer drops entries when it fills up
+const restoreBatch = 1 << 10
+
+// RequestSnapshotter is implemented by the request caches kept in process memory, whose entries
+// are lost on restart unless they are saved on shutdown and restored on boot
+type RequestSnapshotter interface {
+	// Snapshot returns the live entries
+	Snapshot() []RequestSnapshotEntry
+	// Restore stores the entries that have not expired and keep accepts. Returns how many.
+	Restore(entries []RequestSnapshotEntry, keep func(CachedResponse) bool) int
+}
+
+// IdempotencySnapshotter is RequestSnapshotter for the idempotency caches
+type IdempotencySnapshotter interface {
+	// Snapshot returns the live entries
+	Snapshot() []IdempotencySnapshotEntry
+	// Restore stores the entries that have not expired. Returns how many.
+	Restore(entries []IdempotencySnapshotEntry) int
+}
+
+// RequestSnapshotEntry is a request cache entry saved in a snapshot
+type RequestSnapshotEntry struct {
+	Key       string         `json:"key"`
+	ItemIDs   []string       `json:"item_ids"`
+	Response  CachedResponse `json:"response"`
+	ExpiresAt time.Time      `json:"expires_at"`
+}
+
+// IdempotencySnapshotEntry is an idempotency cache entry saved in a snapshot
+type IdempotencySnapshotEntry struct {
+	Key       string          `json:"key"`
+	Entry     IdempotentEntry `json:"entry"`
+	ExpiresAt time.Time       `json:"expires_at"`
+}
+
+// Snapshot is the content of the in-process caches, saved on shutdown
+type Snapshot struct {
+	SavedAt     time.Time                  `json:"saved_at"`
+	Requests    []RequestSnapshotEntry     `json:"requests,omitempty"`
+	Idempotency []IdempotencySnapshotEntry `json:"idempotency,omitempty"`
+}
+
+// ReadSnapshot reads a snapshot file; a missing file is an empty snapshot
+func ReadSnapshot(filePath string) (Snapshot, error) {
+	var snapshot Snapshot
+	content, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return snapshot, nil
+	}
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to read cache snapshot: %w", err)
+	}
+	if err := json.Unmarshal(content, &snapshot); err != nil {
+		return snapshot, fmt.Errorf("failed to parse cache snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+// WriteSnapshot writes a snapshot file
+func WriteSnapshot(filePath string, snapshot Snapshot) error {
+	content, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filePath, ".cache-snapshot-*", content)
+}
+
+// writeFileAtomic writes to a temp file and renames it, so a crash never leaves a partial file
+func writeFileAtomic(filePath, tempPattern string, content []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), tempPattern)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filePath)
+}
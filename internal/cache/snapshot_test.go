@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"go.uber.org/zap"
+)
+
+func TestMemoryRequestCache_SnapshotAndRestore(t *testing.T) {
+	ctx := context.Background()
+	previous, err := NewMemoryRequestCache(1<<20, TTL{Soft: time.Minute, Hard: 5 * time.Minute}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewMemoryRequestCache() error = %v", err)
+	}
+	defer previous.Close()
+
+	// ab is set twice before ristretto applies the first Set, which rejects the second: the key stays listed
+	previous.Set(ctx, "ab", []string{"a", "b"}, CachedResponse{Body: json.RawMessage(`{"data":"ab"}`), CatalogVersion: "v2"})
+	previous.Set(ctx, "ab", []string{"a", "b"}, CachedResponse{Body: json.RawMessage(`{"data":"ab"}`), CatalogVersion: "v2"})
+	previous.Set(ctx, "cd", []string{"c", "d"}, CachedResponse{Body: json.RawMessage(`{"data":"cd"}`), CatalogVersion: "v1"})
+	previous.cache.Wait()
+	previous.Set(ctx, "ab", []string{"a", "b"}, CachedResponse{Body: json.RawMessage(`{"data":"ab2"}`), CatalogVersion: "v2"})
+	previous.cache.Wait()
+
+	// A replaced entry is listed once, with its latest response
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	entries := previous.Snapshot()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries in the snapshot, got %d", len(entries))
+	}
+	expired := RequestSnapshotEntry{Key: "old", ItemIDs: []string{"a"}, Response: CachedResponse{CatalogVersion: "v2"}, ExpiresAt: time.Now().Add(-time.Second)}
+	if err := WriteSnapshot(path, Snapshot{SavedAt: time.Now(), Requests: append(entries, expired)}); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+
+	snapshot, err := ReadSnapshot(path)
+	if err != nil {
+		t.Fatalf("ReadSnapshot() error = %v", err)
+	}
+	restored, err := NewMemoryRequestCache(1<<20, TTL{Soft: time.Minute, Hard: 5 * time.Minute}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewMemoryRequestCache() error = %v", err)
+	}
+	defer restored.Close()
+
+	// Only v2 is live: cd is dropped, and so is the expired entry
+	count := restored.Restore(snapshot.Requests, func(response CachedResponse) bool { return response.CatalogVersion == "v2" })
+	if count != 1 {
+		t.Errorf("Expected 1 restored entry, got %d", count)
+	}
+	cached, found := restored.Get(ctx, "ab")
+	if !found || string(cached.Body) != `{"data":"ab2"}` || cached.Stale {
+		t.Errorf("Expected the latest ab to be restored fresh, got %s (found %v, stale %v)", cached.Body, found, cached.Stale)
+	}
+	for _, key := range []string{"cd", "old"} {
+		if _, found := restored.Get(ctx, key); found {
+			t.Errorf("Expected %s not to be restored", key)
+		}
+	}
+
+	// Restored entries keep their storage time and expiry, and are indexed by their items
+	if !cached.StoredAt.Equal(entries[0].Response.StoredAt) && !cached.StoredAt.Equal(entries[1].Response.StoredAt) {
+		t.Errorf("Expected the restored entry to keep its storage time, got %v", cached.StoredAt)
+	}
+	if ttl, _ := restored.cache.GetTTL("ab"); ttl > 5*time.Minute || ttl < 4*time.Minute {
+		t.Errorf("Expected the remaining hard TTL, got %v", ttl)
+	}
+	if evicted := restored.InvalidateItems(ctx, []string{"b"}); evicted != 1 {
+		t.Errorf("Expected the restored entry to be indexed by its items, got %d evicted", evicted)
+	}
+}
+
+func TestMemoryIdempotencyCache_SnapshotAndRestore(t *testing.T) {
+	ctx := context.Background()
+	previous, err := NewMemoryIdempotencyCache(1<<20, 5*time.Minute, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewMemoryIdempotencyCache() error = %v", err)
+	}
+	defer previous.Close()
+
+	previous.Set(ctx, "key-1", IdempotentEntry{BodyHash: "hash-1", Response: json.RawMessage(`{"data":1}`)})
+	previous.Set(ctx, "key-2", IdempotentEntry{BodyHash: "hash-2", Response: json.RawMessage(`{"data":2}`)})
+	previous.cache.Wait()
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := WriteSnapshot(path, Snapshot{SavedAt: time.Now(), Idempotency: previous.Snapshot()}); err != nil {
+		t.Fatalf("WriteSnapshot() error = %v", err)
+	}
+	snapshot, err := ReadSnapshot(path)
+	if err != nil {
+		t.Fatalf("ReadSnapshot() error = %v", err)
+	}
+
+	restored, err := NewMemoryIdempotencyCache(1<<20, 5*time.Minute, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewMemoryIdempotencyCache() error = %v", err)
+	}
+	defer restored.Close()
+
+	if count := restored.Restore(snapshot.Idempotency); count != 2 {
+		t.Errorf("Expected 2 restored entries, got %d", count)
+	}
+	// A retry after the restart replays the original response
+	entry, found := restored.Get(ctx, "key-1")
+	if !found || entry.BodyHash != "hash-1" || string(entry.Response) != `{"data":1}` {
+		t.Errorf("Expected key-1 to be restored, got %+v (found %v)", entry, found)
+	}
+}
+
+func TestReadSnapshot_MissingFile(t *testing.T) {
+	snapshot, err := ReadSnapshot(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("ReadSnapshot() error = %v", err)
+	}
+	if len(snapshot.Requests) != 0 || len(snapshot.Idempotency) != 0 {
+		t.Errorf("Expected an empty snapshot, got %+v", snapshot)
+	}
+}
@@ -4,9 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"sync/atomic"
+	"time"
 
-	"github.com/goccy/go-json"
 	"github.com/mmedinam1600/product-comparison-api/internal/domain"
 	"go.uber.org/zap"
 )
@@ -14,25 +13,44 @@ import (
 // CatalogRepository define the contract to access the catalog of products
 type CatalogRepository interface {
 	// GetByIDs retrieves items by their IDs.
-	// Returns: items found, IDs that were not found
-	GetByIDs(ctx context.Context, ids []string) ([]domain.Item, []string)
+	// Returns: items found, IDs that were not found, and an error when the catalog could not be read
+	GetByIDs(ctx context.Context, ids []string) ([]domain.Item, []string, error)
 
-	// GetAll retrieves all items from the catalog
-	GetAll(ctx context.Context) []domain.Item
+	// GetAll retrieves all items from the catalog, or an error when the catalog could not be read
+	GetAll(ctx context.Context) ([]domain.Item, error)
+
+	// GetVariants retrieves the variants of a parent item, sorted by ID.
+	// Returns false when id is not a parent (unknown, a variant or an item without variants),
+	// and an error when the catalog could not be read.
+	GetVariants(ctx context.Context, id string) ([]domain.Item, bool, error)
+}
+
+// Watcher is implemented by the repositories that reload the catalog in the background
+type Watcher interface {
+	// Watch reloads the catalog every interval until ctx is done
+	Watch(ctx context.Context, interval time.Duration)
 }
 
-// FileCatalogRepo implements CatalogRepository loading data from a JSON file
+// FileCatalogRepo implements CatalogRepository loading data from a JSON, NDJSON or CSV file.
+// Every load that changes the content publishes a new immutable version.
 type FileCatalogRepo struct {
+	*catalogStore
+
 	logger   *zap.Logger
 	filePath string
-	// atomic.Value for lock-free catalog reads
-	catalog atomic.Value // map[string]domain.Item
+	format   FeedFormat
+
+	// Last observed state of the file, only touched by reload
+	modTime time.Time
+	size    int64
 }
 
-func NewFileCatalogRepo(filePath string, logger *zap.Logger) (*FileCatalogRepo, error) {
+func NewFileCatalogRepo(filePath string, format FeedFormat, keepVersions int, logger *zap.Logger) (*FileCatalogRepo, error) {
 	repo := &FileCatalogRepo{
-		logger:   logger,
-		filePath: filePath,
+		catalogStore: newCatalogStore(keepVersions, logger),
+		logger:       logger,
+		filePath:     filePath,
+		format:       format,
 	}
 
 	if err := repo.reload(); err != nil {
@@ -41,70 +59,84 @@ func NewFileCatalogRepo(filePath string, logger *zap.Logger) (*FileCatalogRepo,
 
 	logger.Info("catalog loaded successfully",
 		zap.String("file", filePath),
-		zap.Int("items", len(repo.getCatalog())),
+		zap.String("format", string(format)),
+		zap.Int("items", repo.CurrentSnapshot().Len()),
+		zap.String("version", repo.CurrentSnapshot().Version.ID),
 	)
 
 	return repo, nil
 }
 
-// GetByIDs implementa CatalogRepository.GetByIDs
-func (r *FileCatalogRepo) GetByIDs(ctx context.Context, ids []string) ([]domain.Item, []string) {
-	catalog := r.getCatalog()
-
-	found := make([]domain.Item, 0, len(ids))
-	missing := make([]string, 0)
-
-	// Maintain the order of the requested IDs
-	for _, id := range ids {
-		if item, exists := catalog[id]; exists {
-			found = append(found, item)
-		} else {
-			missing = append(missing, id)
+// Watch reloads the catalog every time the file changes, checking every interval until ctx is done.
+// A failed reload (including a feed with invalid rows) keeps serving the previous version.
+func (r *FileCatalogRepo) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(r.filePath)
+			if err != nil {
+				r.logger.Warn("failed to stat catalog file", zap.Error(err))
+				continue
+			}
+			if info.ModTime().Equal(r.modTime) && info.Size() == r.size {
+				continue
+			}
+			if err := r.reload(); err != nil {
+				r.logger.Error("failed to reload catalog, keeping previous version", zap.Error(err))
+			}
 		}
 	}
-
-	return found, missing
 }
 
-// GetAll implements CatalogRepository.GetAll
-func (r *FileCatalogRepo) GetAll(ctx context.Context) []domain.Item {
-	catalog := r.getCatalog()
-
-	items := make([]domain.Item, 0, len(catalog))
-	for _, item := range catalog {
-		items = append(items, item)
+// reload loads (or reloads) the catalog from the feed file.
+// A feed with invalid rows is rejected as a whole: publishing the rest would drop those items
+// from the catalog.
+func (r *FileCatalogRepo) reload() error {
+	// 1. Open file
+	file, err := os.Open(r.filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
 	}
+	defer file.Close()
 
-	return items
-}
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
 
-// reload loads (or reloads) the catalog from the JSON file
-func (r *FileCatalogRepo) reload() error {
-	// 1. Read file
-	data, err := os.ReadFile(r.filePath)
+	// 2. Stream the feed
+	items := make([]domain.Item, 0)
+	report, err := ImportFeed(file, r.format, func(item domain.Item) error {
+		items = append(items, item)
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
+		return fmt.Errorf("failed to import %s feed: %w", r.format, err)
 	}
 
-	// 2. Parse JSON to array of items
-	var items []domain.Item
-	if err := json.Unmarshal(data, &items); err != nil {
-		return fmt.Errorf("failed to unmarshal JSON: %w", err)
+	for _, rowErr := range report.Errors {
+		r.logger.Warn("invalid catalog row",
+			zap.Int("row", rowErr.Row),
+			zap.String("id", rowErr.ID),
+			zap.Error(rowErr.Err),
+		)
+	}
+	if err := report.Err(); err != nil {
+		return fmt.Errorf("rejected %s feed: %w", r.format, err)
 	}
 
-	// 3. Build index by ID
-	catalog := make(map[string]domain.Item, len(items))
-	for _, item := range items {
-		catalog[item.ID] = item
+	// 3. Publish a new version and its search index atomically (no-op if the content did not change)
+	if _, _, err := r.publish(items); err != nil {
+		return fmt.Errorf("failed to publish catalog: %w", err)
 	}
 
-	// 4. Update catalog atomically
-	r.catalog.Store(catalog)
+	r.modTime = info.ModTime()
+	r.size = info.Size()
 
 	return nil
 }
-
-// getCatalog gets the current catalog in a thread-safe way
-func (r *FileCatalogRepo) getCatalog() map[string]domain.Item {
-	return r.catalog.Load().(map[string]domain.Item)
-}
@@ -0,0 +1,40 @@
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestFileCatalogRepo_ReloadRejectsInvalidRows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "items.ndjson")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write feed: %v", err)
+		}
+	}
+
+	write(`{"id":"a","name":"A","price":10,"rating":4}` + "\n" + `{"id":"b","name":"B","price":20,"rating":5}` + "\n")
+	repo, err := NewFileCatalogRepo(path, FeedFormatNDJSON, 3, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewFileCatalogRepo() error = %v", err)
+	}
+	live := repo.CurrentSnapshot()
+
+	// b lost its name: publishing the rest would drop b from the catalog
+	write(`{"id":"a","name":"A","price":8,"rating":4}` + "\n" + `{"id":"b","price":20,"rating":5}` + "\n")
+	if err := repo.reload(); err == nil {
+		t.Fatal("Expected a feed with an invalid row to be rejected")
+	}
+	if repo.CurrentSnapshot() != live {
+		t.Error("Expected the rejected feed to keep the live version")
+	}
+
+	// The first load has no version to keep: it fails
+	if _, err := NewFileCatalogRepo(path, FeedFormatNDJSON, 3, zap.NewNop()); err == nil {
+		t.Error("Expected the first load of a feed with an invalid row to fail")
+	}
+}
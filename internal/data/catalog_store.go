@@ -0,0 +1,189 @@
+package data
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"go.uber.org/zap"
+)
+
+// VersionedCatalog is implemented by the repositories that keep the last catalog loads
+// as immutable snapshots
+type VersionedCatalog interface {
+	CatalogRepository
+
+	// CurrentSnapshot returns the snapshot that serves live requests
+	CurrentSnapshot() *Snapshot
+
+	// SnapshotByVersion returns a retained snapshot by its version ID
+	SnapshotByVersion(id string) (*Snapshot, bool)
+
+	// SnapshotAt returns the snapshot that was live at the given time
+	SnapshotAt(t time.Time) (*Snapshot, bool)
+
+	// Versions lists the retained versions, newest first
+	Versions() []domain.CatalogVersion
+
+	// OnPublish registers a listener called (synchronously, in registration order)
+	// every time a new version becomes live. previous is nil for the first load.
+	OnPublish(fn PublishListener)
+}
+
+// PublishListener receives the replaced and the new live snapshot
+type PublishListener func(previous, current *Snapshot)
+
+// DefaultRetainedVersions is the number of snapshots kept when none is configured
+const DefaultRetainedVersions = 10
+
+// catalogStore holds the live snapshot and the history of previous loads.
+// Reads of the live snapshot are lock-free; the history is only locked for pinned lookups.
+type catalogStore struct {
+	logger *zap.Logger
+	keep   int
+
+	// atomic.Pointer for lock-free catalog reads
+	current atomic.Pointer[Snapshot]
+
+	// Full-text index of the live snapshot, swapped on every publish
+	searchIndex atomic.Pointer[SearchIndex]
+
+	// publishMu serializes publications so listeners observe versions in order
+	publishMu sync.Mutex
+
+	mu        sync.RWMutex
+	history   []*Snapshot // Oldest first, at most keep entries
+	listeners []PublishListener
+}
+
+func newCatalogStore(keep int, logger *zap.Logger) *catalogStore {
+	if keep < 1 {
+		keep = DefaultRetainedVersions
+	}
+	return &catalogStore{logger: logger, keep: keep}
+}
+
+// publish builds a snapshot (and its search index) from the items and makes it the live one.
+// Returns false when the content is identical to the live snapshot, which is kept as is.
+// Every catalog source (file, HTTP) swaps versions through here.
+func (s *catalogStore) publish(items []domain.Item) (*Snapshot, bool, error) {
+	snapshot, err := NewSnapshot(items, time.Now())
+	if err != nil {
+		return nil, false, err
+	}
+
+	s.publishMu.Lock()
+	defer s.publishMu.Unlock()
+
+	previous := s.current.Load()
+	if previous != nil && previous.Version.ContentHash == snapshot.Version.ContentHash {
+		return previous, false, nil
+	}
+
+	for _, orphan := range snapshot.Orphans() {
+		s.logger.Warn("skipped variant without a top-level parent",
+			zap.String("id", orphan.ID),
+			zap.String("parent_id", orphan.ParentID),
+		)
+	}
+
+	index := NewSearchIndex(snapshot.all())
+	index.version = &snapshot.Version
+
+	s.mu.Lock()
+	s.history = append(s.history, snapshot)
+	if len(s.history) > s.keep {
+		s.history = s.history[len(s.history)-s.keep:]
+	}
+	s.current.Store(snapshot)
+	s.searchIndex.Store(index)
+	listeners := s.listeners
+	s.mu.Unlock()
+
+	s.logger.Info("catalog version published",
+		zap.String("version", snapshot.Version.ID),
+		zap.String("content_hash", snapshot.Version.ContentHash),
+		zap.Int("items", snapshot.Version.ItemCount),
+		zap.Int("search_terms", len(index.vocabulary)),
+	)
+
+	for _, listener := range listeners {
+		listener(previous, snapshot)
+	}
+
+	return snapshot, true, nil
+}
+
+// OnPublish implements VersionedCatalog.OnPublish
+func (s *catalogStore) OnPublish(fn PublishListener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.listeners = append(s.listeners, fn)
+}
+
+// SearchIndex implements Searchable.SearchIndex
+func (s *catalogStore) SearchIndex(ctx context.Context) (*SearchIndex, error) {
+	return s.searchIndex.Load(), nil
+}
+
+// GetByIDs implements CatalogRepository.GetByIDs over the live snapshot
+func (s *catalogStore) GetByIDs(ctx context.Context, ids []string) ([]domain.Item, []string, error) {
+	return s.CurrentSnapshot().GetByIDs(ctx, ids)
+}
+
+// GetAll implements CatalogRepository.GetAll over the live snapshot
+func (s *catalogStore) GetAll(ctx context.Context) ([]domain.Item, error) {
+	return s.CurrentSnapshot().GetAll(ctx)
+}
+
+// GetVariants implements CatalogRepository.GetVariants over the live snapshot
+func (s *catalogStore) GetVariants(ctx context.Context, id string) ([]domain.Item, bool, error) {
+	return s.CurrentSnapshot().GetVariants(ctx, id)
+}
+
+// CurrentSnapshot implements VersionedCatalog.CurrentSnapshot
+func (s *catalogStore) CurrentSnapshot() *Snapshot {
+	return s.current.Load()
+}
+
+// SnapshotByVersion implements VersionedCatalog.SnapshotByVersion
+func (s *catalogStore) SnapshotByVersion(id string) (*Snapshot, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, snapshot := range s.history {
+		if snapshot.Version.ID == id {
+			return snapshot, true
+		}
+	}
+	return nil, false
+}
+
+// SnapshotAt implements VersionedCatalog.SnapshotAt.
+// Fails when t is older than the oldest retained snapshot.
+func (s *catalogStore) SnapshotAt(t time.Time) (*Snapshot, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for i := len(s.history) - 1; i >= 0; i-- {
+		if !s.history[i].Version.CreatedAt.After(t) {
+			return s.history[i], true
+		}
+	}
+	return nil, false
+}
+
+// Versions implements VersionedCatalog.Versions
+func (s *catalogStore) Versions() []domain.CatalogVersion {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	versions := make([]domain.CatalogVersion, 0, len(s.history))
+	for i := len(s.history) - 1; i >= 0; i-- {
+		versions = append(versions, s.history[i].Version)
+	}
+	return versions
+}
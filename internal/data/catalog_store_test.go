@@ -0,0 +1,145 @@
+package data
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"go.uber.org/zap"
+)
+
+func catalogWithPrice(price float64) []domain.Item {
+	return []domain.Item{
+		{ID: "b", Name: "Item B", Price: 10},
+		{ID: "a", Name: "Item A", Price: price},
+	}
+}
+
+func TestNewSnapshot_ContentHashIgnoresOrder(t *testing.T) {
+	items := catalogWithPrice(5)
+	reversed := []domain.Item{items[1], items[0]}
+
+	first, err := NewSnapshot(items, time.Now())
+	if err != nil {
+		t.Fatalf("NewSnapshot() error = %v", err)
+	}
+	second, err := NewSnapshot(reversed, time.Now())
+	if err != nil {
+		t.Fatalf("NewSnapshot() error = %v", err)
+	}
+
+	if first.Version.ContentHash != second.Version.ContentHash {
+		t.Error("Expected same content hash for the same items in a different order")
+	}
+	if first.Version.ItemCount != 2 {
+		t.Errorf("Expected item count 2, got %d", first.Version.ItemCount)
+	}
+
+	all, _ := first.GetAll(context.Background())
+	if all[0].ID != "a" || all[1].ID != "b" {
+		t.Errorf("Expected GetAll sorted by ID, got %v", all)
+	}
+}
+
+func TestCatalogStore_Publish(t *testing.T) {
+	store := newCatalogStore(2, zap.NewNop())
+
+	first, changed, err := store.publish(catalogWithPrice(1))
+	if err != nil || !changed {
+		t.Fatalf("publish() = %v, %v; want a new version", changed, err)
+	}
+
+	// Same content keeps the live version
+	same, changed, _ := store.publish(catalogWithPrice(1))
+	if changed || same != first {
+		t.Error("Expected unchanged content to keep the live snapshot")
+	}
+
+	store.publish(catalogWithPrice(2))
+	third, _, _ := store.publish(catalogWithPrice(3))
+
+	if store.CurrentSnapshot() != third {
+		t.Error("Expected the last published snapshot to be live")
+	}
+
+	versions := store.Versions()
+	if len(versions) != 2 {
+		t.Fatalf("Expected 2 retained versions, got %d", len(versions))
+	}
+	if versions[0].ID != third.Version.ID {
+		t.Errorf("Expected newest version first, got %v", versions[0].ID)
+	}
+
+	// The first version was evicted by the retention limit
+	if _, found := store.SnapshotByVersion(first.Version.ID); found {
+		t.Error("Expected the oldest version to be evicted")
+	}
+	if snapshot, found := store.SnapshotByVersion(third.Version.ID); !found || snapshot != third {
+		t.Error("Expected to find the live version by ID")
+	}
+}
+
+func TestCatalogStore_SnapshotAt(t *testing.T) {
+	store := newCatalogStore(5, zap.NewNop())
+
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	yesterday, _ := NewSnapshot(catalogWithPrice(1), base)
+	today, _ := NewSnapshot(catalogWithPrice(2), base.Add(24*time.Hour))
+	store.history = []*Snapshot{yesterday, today}
+	store.current.Store(today)
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		expected *Snapshot
+	}{
+		{name: "Before the oldest version", at: base.Add(-time.Minute), expected: nil},
+		{name: "Exactly at a version", at: base, expected: yesterday},
+		{name: "Between versions", at: base.Add(12 * time.Hour), expected: yesterday},
+		{name: "After the newest version", at: base.Add(48 * time.Hour), expected: today},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := store.SnapshotAt(tt.at)
+			if tt.expected == nil {
+				if found {
+					t.Errorf("Expected no snapshot, got %v", got.Version.ID)
+				}
+				return
+			}
+			if got != tt.expected {
+				t.Errorf("SnapshotAt() = %v, want %v", got, tt.expected.Version.ID)
+			}
+		})
+	}
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	previous, _ := NewSnapshot([]domain.Item{
+		{ID: "kept", Price: 1},
+		{ID: "repriced", Price: 2},
+		{ID: "removed", Price: 3},
+	}, time.Now())
+	current, _ := NewSnapshot([]domain.Item{
+		{ID: "kept", Price: 1},
+		{ID: "repriced", Price: 20},
+		{ID: "added", Price: 4},
+	}, time.Now())
+
+	diff := DiffSnapshots(previous, current)
+
+	if len(diff.Added) != 1 || diff.Added[0] != "added" {
+		t.Errorf("Added = %v, want [added]", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0] != "removed" {
+		t.Errorf("Removed = %v, want [removed]", diff.Removed)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0] != "repriced" {
+		t.Errorf("Changed = %v, want [repriced]", diff.Changed)
+	}
+	if diff.FromVersion != previous.Version.ID || diff.ToVersion != current.Version.ID {
+		t.Errorf("Unexpected versions %s → %s", diff.FromVersion, diff.ToVersion)
+	}
+}
@@ -0,0 +1,161 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"go.uber.org/zap"
+)
+
+// ExchangeRateSource provides the exchange-rate table currently in force
+type ExchangeRateSource interface {
+	// Current returns the live table
+	Current() *domain.ExchangeRates
+}
+
+// FileExchangeRates implements ExchangeRateSource loading the table from a JSON file:
+//
+//	{"base": "USD", "updated_at": "2026-01-15T12:00:00Z", "rates": {"MXN": 17.1, "BRL": 5.3}}
+//
+// Without updated_at the modification time of the file is used.
+// Every change of the file is reloaded; a failed reload keeps the previous table.
+type FileExchangeRates struct {
+	logger   *zap.Logger
+	filePath string
+
+	current atomic.Pointer[domain.ExchangeRates]
+
+	mu        sync.Mutex
+	listeners []func(*domain.ExchangeRates)
+
+	// Last observed state of the file, only touched by reload
+	modTime time.Time
+	size    int64
+}
+
+// NewFileExchangeRates creates the source. The first load must succeed.
+func NewFileExchangeRates(filePath string, logger *zap.Logger) (*FileExchangeRates, error) {
+	r := &FileExchangeRates{logger: logger, filePath: filePath}
+
+	if err := r.reload(); err != nil {
+		return nil, fmt.Errorf("failed to load exchange rates: %w", err)
+	}
+
+	return r, nil
+}
+
+// Current implements ExchangeRateSource.Current
+func (r *FileExchangeRates) Current() *domain.ExchangeRates {
+	return r.current.Load()
+}
+
+// OnReload registers a listener called every time a new table becomes live
+func (r *FileExchangeRates) OnReload(fn func(*domain.ExchangeRates)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.listeners = append(r.listeners, fn)
+}
+
+// Watch reloads the table every time the file changes, checking every interval until ctx is done
+func (r *FileExchangeRates) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(r.filePath)
+			if err != nil {
+				r.logger.Warn("failed to stat exchange rates file", zap.Error(err))
+				continue
+			}
+			if info.ModTime().Equal(r.modTime) && info.Size() == r.size {
+				continue
+			}
+			if err := r.reload(); err != nil {
+				r.logger.Error("failed to reload exchange rates, keeping previous table", zap.Error(err))
+			}
+		}
+	}
+}
+
+// reload reads, validates and publishes the table
+func (r *FileExchangeRates) reload() error {
+	info, err := os.Stat(r.filePath)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(r.filePath)
+	if err != nil {
+		return err
+	}
+	// A broken file is reported once, not on every check
+	r.modTime = info.ModTime()
+	r.size = info.Size()
+
+	var rates domain.ExchangeRates
+	if err := json.Unmarshal(content, &rates); err != nil {
+		return fmt.Errorf("invalid exchange rates file: %w", err)
+	}
+	if err := normalizeRates(&rates); err != nil {
+		return err
+	}
+	if rates.UpdatedAt.IsZero() {
+		rates.UpdatedAt = info.ModTime().UTC()
+	}
+
+	r.current.Store(&rates)
+
+	r.logger.Info("exchange rates loaded",
+		zap.String("file", r.filePath),
+		zap.String("base", rates.Base),
+		zap.Int("currencies", len(rates.Rates)),
+		zap.Time("updated_at", rates.UpdatedAt),
+	)
+
+	r.mu.Lock()
+	listeners := r.listeners
+	r.mu.Unlock()
+	for _, listener := range listeners {
+		listener(&rates)
+	}
+
+	return nil
+}
+
+// normalizeRates upper-cases the currency codes and rejects invalid codes or rates
+func normalizeRates(rates *domain.ExchangeRates) error {
+	base := domain.NormalizeCurrency(rates.Base)
+	if base == "" {
+		return fmt.Errorf("invalid base currency %q", rates.Base)
+	}
+	if len(rates.Rates) == 0 {
+		return errors.New("exchange rates file has no rates")
+	}
+
+	normalized := make(map[string]float64, len(rates.Rates))
+	for code, rate := range rates.Rates {
+		currency := domain.NormalizeCurrency(code)
+		if currency == "" {
+			return fmt.Errorf("invalid currency %q", code)
+		}
+		if rate <= 0 {
+			return fmt.Errorf("rate of %s must be positive", currency)
+		}
+		normalized[currency] = rate
+	}
+
+	rates.Base = base
+	rates.Rates = normalized
+	return nil
+}
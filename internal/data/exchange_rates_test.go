@@ -0,0 +1,110 @@
+package data
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"go.uber.org/zap"
+)
+
+func writeRates(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write rates file: %v", err)
+	}
+}
+
+func TestNewFileExchangeRates(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectError bool
+	}{
+		{name: "Valid table", content: `{"base": "usd", "updated_at": "2026-01-15T12:00:00Z", "rates": {"mxn": 17.1}}`},
+		{name: "Invalid JSON", content: `{"base": "USD",`, expectError: true},
+		{name: "Invalid base", content: `{"base": "dollar", "rates": {"MXN": 17.1}}`, expectError: true},
+		{name: "No rates", content: `{"base": "USD", "rates": {}}`, expectError: true},
+		{name: "Invalid currency", content: `{"base": "USD", "rates": {"MX": 17.1}}`, expectError: true},
+		{name: "Non-positive rate", content: `{"base": "USD", "rates": {"MXN": 0}}`, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rates.json")
+			writeRates(t, path, tt.content)
+
+			rates, err := NewFileExchangeRates(path, zap.NewNop())
+			if tt.expectError {
+				if err == nil {
+					t.Fatal("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewFileExchangeRates() error = %v", err)
+			}
+
+			current := rates.Current()
+			if current.Base != "USD" || current.Rates["MXN"] != 17.1 {
+				t.Errorf("Expected normalized codes, got %+v", current)
+			}
+			if !current.UpdatedAt.Equal(time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)) {
+				t.Errorf("UpdatedAt = %v", current.UpdatedAt)
+			}
+		})
+	}
+}
+
+func TestFileExchangeRates_UpdatedAtDefaultsToModTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	writeRates(t, path, `{"base": "USD", "rates": {"MXN": 17.1}}`)
+	modTime := time.Date(2026, 2, 1, 8, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("failed to set mtime: %v", err)
+	}
+
+	rates, err := NewFileExchangeRates(path, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewFileExchangeRates() error = %v", err)
+	}
+	if !rates.Current().UpdatedAt.Equal(modTime) {
+		t.Errorf("UpdatedAt = %v, want %v", rates.Current().UpdatedAt, modTime)
+	}
+}
+
+func TestFileExchangeRates_Watch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	writeRates(t, path, `{"base": "USD", "rates": {"MXN": 17.1}}`)
+
+	rates, err := NewFileExchangeRates(path, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewFileExchangeRates() error = %v", err)
+	}
+	reloaded := make(chan *domain.ExchangeRates, 1)
+	rates.OnReload(func(current *domain.ExchangeRates) { reloaded <- current })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go rates.Watch(ctx, 5*time.Millisecond)
+
+	// A broken file keeps the previous table
+	writeRates(t, path, `{"base": "USD", "rates": {"MXN": -1}}`)
+	time.Sleep(30 * time.Millisecond)
+	if rates.Current().Rates["MXN"] != 17.1 {
+		t.Errorf("Expected the previous table after a failed reload, got %v", rates.Current().Rates)
+	}
+
+	writeRates(t, path, `{"base": "USD", "rates": {"MXN": 18.25, "BRL": 5.3}}`)
+	select {
+	case current := <-reloaded:
+		if current.Rates["MXN"] != 18.25 || rates.Current() != current {
+			t.Errorf("Expected the new table to be live, got %v", current.Rates)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the table to reload")
+	}
+}
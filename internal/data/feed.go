@@ -0,0 +1,425 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"github.com/mmedinam1600/product-comparison-api/internal/i18n"
+)
+
+// FeedFormat identifies the encoding of a catalog feed
+type FeedFormat string
+
+const (
+	FeedFormatJSON   FeedFormat = "json"   // One JSON array of items
+	FeedFormatNDJSON FeedFormat = "ndjson" // One JSON item per line
+	FeedFormatCSV    FeedFormat = "csv"    // Flat columns, specifications as spec.<key>[.<attr>], translations as name.<locale>
+)
+
+// specColumnPrefix marks the CSV columns that map into Item.Specifications
+const specColumnPrefix = "spec."
+
+// ParseFeedFormat validates a format name. An empty name is inferred from the file extension.
+func ParseFeedFormat(name, path string) (FeedFormat, error) {
+	if name == "" {
+		name = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+		if name == "jsonl" {
+			name = string(FeedFormatNDJSON)
+		}
+	}
+
+	switch format := FeedFormat(strings.ToLower(name)); format {
+	case FeedFormatJSON, FeedFormatNDJSON, FeedFormatCSV:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unsupported feed format %q", name)
+	}
+}
+
+// RowError describes a feed row that was skipped
+type RowError struct {
+	Row int    // 1-based row (CSV data row, NDJSON line or JSON array element)
+	ID  string // Item ID when it could be read
+	Err error
+}
+
+func (e RowError) Error() string {
+	if e.ID != "" {
+		return fmt.Sprintf("row %d (id %s): %v", e.Row, e.ID, e.Err)
+	}
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+// ImportReport summarizes the result of an import
+type ImportReport struct {
+	Imported int
+	Errors   []RowError
+}
+
+// Err returns an error naming the first rejected row, or nil when every row was valid
+func (r ImportReport) Err() error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d invalid rows, first %w", len(r.Errors), r.Errors[0])
+}
+
+// ImportFeed reads the items of a feed one at a time and calls fn for every valid row.
+// Invalid rows are collected in the report and skipped; the returned error is reserved for
+// failures that stop the import (unreadable input, broken structure or an error from fn).
+func ImportFeed(r io.Reader, format FeedFormat, fn func(domain.Item) error) (ImportReport, error) {
+	imp := &feedImporter{fn: fn, seen: make(map[string]bool)}
+
+	var err error
+	switch format {
+	case FeedFormatJSON:
+		err = imp.readJSON(r)
+	case FeedFormatNDJSON:
+		err = imp.readNDJSON(r)
+	case FeedFormatCSV:
+		err = imp.readCSV(r)
+	default:
+		err = fmt.Errorf("unsupported feed format %q", format)
+	}
+
+	return imp.report, err
+}
+
+// feedImporter carries the state shared by every format
+type feedImporter struct {
+	fn     func(domain.Item) error
+	seen   map[string]bool
+	report ImportReport
+}
+
+// accept validates an item and hands it to the callback
+func (imp *feedImporter) accept(row int, item domain.Item) error {
+	translations, err := canonicalTranslations(item.Translations)
+	if err != nil {
+		imp.reject(row, item.ID, err)
+		return nil
+	}
+	item.Translations = translations
+
+	if item.Currency != "" {
+		currency := domain.NormalizeCurrency(item.Currency)
+		if currency == "" {
+			imp.reject(row, item.ID, fmt.Errorf("invalid currency %q", item.Currency))
+			return nil
+		}
+		item.Currency = currency
+	}
+
+	if err := validateItem(item); err != nil {
+		imp.reject(row, item.ID, err)
+		return nil
+	}
+	if imp.seen[item.ID] {
+		imp.reject(row, item.ID, errors.New("duplicate id"))
+		return nil
+	}
+	imp.seen[item.ID] = true
+
+	if err := imp.fn(item); err != nil {
+		return fmt.Errorf("row %d: %w", row, err)
+	}
+	imp.report.Imported++
+	return nil
+}
+
+func (imp *feedImporter) reject(row int, id string, err error) {
+	imp.report.Errors = append(imp.report.Errors, RowError{Row: row, ID: id, Err: err})
+}
+
+// readJSON streams the elements of a JSON array without loading the whole array
+func (imp *feedImporter) readJSON(r io.Reader) error {
+	dec := json.NewDecoder(r)
+
+	if tok, err := dec.Token(); err != nil {
+		return fmt.Errorf("failed to read JSON feed: %w", err)
+	} else if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return errors.New("JSON feed must be an array of items")
+	}
+
+	for row := 1; dec.More(); row++ {
+		// Decode into raw bytes first so a malformed element does not break the stream
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return fmt.Errorf("failed to read JSON feed at element %d: %w", row, err)
+		}
+
+		var item domain.Item
+		if err := json.Unmarshal(raw, &item); err != nil {
+			imp.reject(row, "", err)
+			continue
+		}
+		if err := imp.accept(row, item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readNDJSON streams one item per line; blank lines are ignored
+func (imp *feedImporter) readNDJSON(r io.Reader) error {
+	reader := bufio.NewReader(r)
+
+	for row := 1; ; row++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("failed to read NDJSON feed at line %d: %w", row, err)
+		}
+
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			var item domain.Item
+			if uerr := json.Unmarshal(trimmed, &item); uerr != nil {
+				imp.reject(row, "", uerr)
+			} else if aerr := imp.accept(row, item); aerr != nil {
+				return aerr
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// readCSV maps flat columns into items. Columns named spec.<key> hold scalar specifications
+// and spec.<key>.<attr> build nested ones, e.g. spec.weight.value + spec.weight.unit.
+func (imp *feedImporter) readCSV(r io.Reader) error {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := make([]string, len(header))
+	for i, name := range header {
+		columns[i] = strings.ToLower(strings.TrimSpace(name))
+	}
+	if !slices.Contains(columns, "id") {
+		return errors.New("CSV feed must have an id column")
+	}
+
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			// Field count mismatches and bad quoting only affect the current row
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				imp.reject(row, "", err)
+				continue
+			}
+			return fmt.Errorf("failed to read CSV feed at row %d: %w", row, err)
+		}
+
+		item, err := itemFromCSV(columns, record)
+		if err != nil {
+			imp.reject(row, item.ID, err)
+			continue
+		}
+		if err := imp.accept(row, item); err != nil {
+			return err
+		}
+	}
+}
+
+// itemFromCSV builds an item from one CSV record
+// Variants may leave price and rating empty to inherit them from the parent.
+func itemFromCSV(columns, record []string) (domain.Item, error) {
+	item := domain.Item{Specifications: make(map[string]interface{})}
+	var emptyNumbers []string
+
+	for i, column := range columns {
+		value := strings.TrimSpace(record[i])
+
+		switch {
+		case column == "id":
+			item.ID = value
+		case column == "parent_id":
+			item.ParentID = value
+		case column == "currency":
+			item.Currency = value
+		case column == "availability" || column == "estimated_ship_date":
+			if value == "" {
+				continue
+			}
+			if item.Availability == nil {
+				item.Availability = &domain.Availability{}
+			}
+			if column == "availability" {
+				item.Availability.Status = domain.AvailabilityStatus(strings.ToLower(value))
+			} else {
+				item.Availability.EstimatedShipDate = value
+			}
+		case column == "name":
+			item.Name = value
+		case column == "image_url":
+			item.ImageURL = value
+		case column == "description":
+			item.Description = value
+		case strings.HasPrefix(column, "name.") || strings.HasPrefix(column, "description."):
+			if value == "" {
+				continue
+			}
+			field, locale, _ := strings.Cut(column, ".")
+			if item.Translations == nil {
+				item.Translations = make(map[string]domain.ItemTranslation)
+			}
+			translation := item.Translations[locale]
+			if field == "name" {
+				translation.Name = value
+			} else {
+				translation.Description = value
+			}
+			item.Translations[locale] = translation
+		case (column == "price" || column == "rating") && value == "":
+			emptyNumbers = append(emptyNumbers, column)
+		case column == "price":
+			price, err := parseCSVNumber(column, value)
+			if err != nil {
+				return item, err
+			}
+			item.Price = price
+		case column == "rating":
+			rating, err := parseCSVNumber(column, value)
+			if err != nil {
+				return item, err
+			}
+			item.Rating = rating
+		case strings.HasPrefix(column, specColumnPrefix):
+			if value == "" {
+				continue
+			}
+			if err := setSpecification(item.Specifications, strings.TrimPrefix(column, specColumnPrefix), parseCSVValue(value)); err != nil {
+				return item, err
+			}
+		}
+	}
+
+	if len(emptyNumbers) > 0 && !item.IsVariant() {
+		return item, fmt.Errorf("%s is required", emptyNumbers[0])
+	}
+
+	return item, nil
+}
+
+// setSpecification stores a value under "key" or "key.attr"
+func setSpecification(specs map[string]interface{}, path string, value interface{}) error {
+	key, attr, nested := strings.Cut(path, ".")
+	if key == "" || (nested && (attr == "" || strings.Contains(attr, "."))) {
+		return fmt.Errorf("invalid specification column %q", specColumnPrefix+path)
+	}
+
+	if !nested {
+		if _, isObject := specs[key].(map[string]interface{}); isObject {
+			return fmt.Errorf("specification %q is both a value and an object", key)
+		}
+		specs[key] = value
+		return nil
+	}
+
+	obj, exists := specs[key].(map[string]interface{})
+	if !exists {
+		if _, isScalar := specs[key]; isScalar {
+			return fmt.Errorf("specification %q is both a value and an object", key)
+		}
+		obj = make(map[string]interface{})
+		specs[key] = obj
+	}
+	obj[attr] = value
+	return nil
+}
+
+// parseCSVValue infers the type of a specification cell the same way JSON would decode it
+func parseCSVValue(value string) interface{} {
+	switch strings.ToLower(value) {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if number, err := strconv.ParseFloat(value, 64); err == nil {
+		return number
+	}
+	return value
+}
+
+func parseCSVNumber(column, value string) (float64, error) {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number, got %q", column, value)
+	}
+	return number, nil
+}
+
+// canonicalTranslations normalizes the locale tags of the translations ("es_mx" → "es-MX")
+// and drops the empty ones. Fails on a malformed or repeated tag.
+func canonicalTranslations(translations map[string]domain.ItemTranslation) (map[string]domain.ItemTranslation, error) {
+	if len(translations) == 0 {
+		return nil, nil
+	}
+
+	canonical := make(map[string]domain.ItemTranslation, len(translations))
+	for tag, translation := range translations {
+		locale := i18n.Canonical(tag)
+		if locale == "" {
+			return nil, fmt.Errorf("invalid translation locale %q", tag)
+		}
+		if _, exists := canonical[locale]; exists {
+			return nil, fmt.Errorf("translation locale %q is repeated", locale)
+		}
+		if translation != (domain.ItemTranslation{}) {
+			canonical[locale] = translation
+		}
+	}
+	if len(canonical) == 0 {
+		return nil, nil
+	}
+	return canonical, nil
+}
+
+// validateItem applies the rules every catalog source must satisfy
+func validateItem(item domain.Item) error {
+	switch {
+	case strings.TrimSpace(item.ID) == "":
+		return errors.New("id is required")
+	case item.ParentID == item.ID:
+		return errors.New("an item cannot be its own parent")
+	case strings.TrimSpace(item.Name) == "" && !item.IsVariant():
+		return errors.New("name is required") // Variants inherit it from the parent
+	case item.Price < 0:
+		return errors.New("price must not be negative")
+	case item.Rating < 0 || item.Rating > 5:
+		return errors.New("rating must be between 0 and 5")
+	}
+	if availability := item.Availability; availability != nil {
+		switch {
+		case !availability.Status.IsValid():
+			return fmt.Errorf("unknown availability %q", availability.Status)
+		case availability.EstimatedShipDate == "":
+		case availability.Status != domain.Backorder:
+			return errors.New("estimated_ship_date is only allowed on backorder items")
+		case !domain.ValidShipDate(availability.EstimatedShipDate):
+			return fmt.Errorf("invalid estimated_ship_date %q, expected YYYY-MM-DD", availability.EstimatedShipDate)
+		}
+	}
+	return nil
+}
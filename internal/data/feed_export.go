@@ -0,0 +1,191 @@
+package data
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/goccy/go-json"
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+)
+
+// csvBaseColumns are the fixed columns of an exported CSV feed, in order
+var csvBaseColumns = []string{
+	"id", "parent_id", "name", "image_url", "description", "price", "currency", "rating",
+	"availability", "estimated_ship_date",
+}
+
+// ExportFeed writes the items in a format ImportFeed reads back.
+// CSV flattens specifications into spec.<key>[.<attr>] columns (deeper values are written as
+// JSON text) and translations into name.<locale> / description.<locale> columns.
+func ExportFeed(w io.Writer, format FeedFormat, items []domain.Item) error {
+	buffered := bufio.NewWriter(w)
+
+	var err error
+	switch format {
+	case FeedFormatJSON:
+		err = writeJSONFeed(buffered, items)
+	case FeedFormatNDJSON:
+		err = writeNDJSONFeed(buffered, items)
+	case FeedFormatCSV:
+		err = writeCSVFeed(buffered, items)
+	default:
+		err = fmt.Errorf("unsupported feed format %q", format)
+	}
+	if err != nil {
+		return err
+	}
+
+	return buffered.Flush()
+}
+
+// writeJSONFeed writes one JSON array, one item per line
+func writeJSONFeed(w *bufio.Writer, items []domain.Item) error {
+	if _, err := w.WriteString("["); err != nil {
+		return err
+	}
+	for i, item := range items {
+		encoded, err := json.Marshal(item)
+		if err != nil {
+			return fmt.Errorf("failed to encode item %s: %w", item.ID, err)
+		}
+		separator := ",\n"
+		if i == 0 {
+			separator = "\n"
+		}
+		if _, err := w.WriteString(separator); err != nil {
+			return err
+		}
+		if _, err := w.Write(encoded); err != nil {
+			return err
+		}
+	}
+	_, err := w.WriteString("\n]\n")
+	return err
+}
+
+// writeNDJSONFeed writes one JSON item per line
+func writeNDJSONFeed(w *bufio.Writer, items []domain.Item) error {
+	for _, item := range items {
+		encoded, err := json.Marshal(item)
+		if err != nil {
+			return fmt.Errorf("failed to encode item %s: %w", item.ID, err)
+		}
+		if _, err := w.Write(encoded); err != nil {
+			return err
+		}
+		if err := w.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeCSVFeed writes the fixed columns, then the translation and specification columns
+// found in any item, sorted by name
+func writeCSVFeed(w *bufio.Writer, items []domain.Item) error {
+	rows := make([]map[string]string, len(items))
+	extraColumns := make(map[string]bool)
+	for i, item := range items {
+		row, err := csvRow(item)
+		if err != nil {
+			return err
+		}
+		for column := range row {
+			extraColumns[column] = true
+		}
+		rows[i] = row
+	}
+	for _, column := range csvBaseColumns {
+		delete(extraColumns, column)
+	}
+
+	columns := make([]string, 0, len(csvBaseColumns)+len(extraColumns))
+	columns = append(columns, csvBaseColumns...)
+	extra := make([]string, 0, len(extraColumns))
+	for column := range extraColumns {
+		extra = append(extra, column)
+	}
+	sort.Strings(extra)
+	columns = append(columns, extra...)
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i, column := range columns {
+			record[i] = row[column]
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvRow maps an item to its CSV cells by column name; empty cells are left out
+func csvRow(item domain.Item) (map[string]string, error) {
+	row := map[string]string{
+		"id":          item.ID,
+		"parent_id":   item.ParentID,
+		"name":        item.Name,
+		"image_url":   item.ImageURL,
+		"description": item.Description,
+		"price":       strconv.FormatFloat(item.Price, 'f', -1, 64),
+		"currency":    item.Currency,
+		"rating":      strconv.FormatFloat(item.Rating, 'f', -1, 64),
+	}
+	if item.Availability != nil {
+		row["availability"] = string(item.Availability.Status)
+		row["estimated_ship_date"] = item.Availability.EstimatedShipDate
+	}
+
+	for locale, translation := range item.Translations {
+		if translation.Name != "" {
+			row["name."+locale] = translation.Name
+		}
+		if translation.Description != "" {
+			row["description."+locale] = translation.Description
+		}
+	}
+
+	for key, value := range item.Specifications {
+		if object, isObject := value.(map[string]interface{}); isObject {
+			for attr, attrValue := range object {
+				cell, err := csvCell(attrValue)
+				if err != nil {
+					return nil, fmt.Errorf("failed to encode specification %s.%s of %s: %w", key, attr, item.ID, err)
+				}
+				row[specColumnPrefix+key+"."+attr] = cell
+			}
+			continue
+		}
+		cell, err := csvCell(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode specification %s of %s: %w", key, item.ID, err)
+		}
+		row[specColumnPrefix+key] = cell
+	}
+
+	return row, nil
+}
+
+// csvCell formats a specification value the way parseCSVValue reads it back;
+// lists and nested objects become JSON text
+func csvCell(value interface{}) (string, error) {
+	switch value.(type) {
+	case nil:
+		return "", nil
+	case map[string]interface{}, []interface{}:
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	default:
+		return domain.FormatFieldValue(value), nil
+	}
+}
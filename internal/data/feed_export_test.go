@@ -0,0 +1,75 @@
+package data
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+)
+
+func exportCatalog() []domain.Item {
+	return []domain.Item{
+		{
+			ID: "m1", Name: "Mouse, \"Pro\"", Description: "Wireless", Price: 10.5, Rating: 4.2, Currency: "MXN",
+			Specifications: map[string]interface{}{
+				"weight":   map[string]interface{}{"value": 0.08, "unit": "kg"},
+				"wireless": true,
+				"dpi":      1600.0,
+			},
+			Translations: map[string]domain.ItemTranslation{"es-MX": {Name: "Ratón"}},
+			Availability: &domain.Availability{Status: domain.Backorder, EstimatedShipDate: "2026-11-02"},
+		},
+		{
+			ID: "m1-black", ParentID: "m1", Name: "Mouse, \"Pro\"", Price: 10.5, Rating: 4.2,
+			Specifications: map[string]interface{}{"color": "black"},
+		},
+		{ID: "k1", Name: "Keyboard", Price: 80, Rating: 4.5, Specifications: map[string]interface{}{"layout": "ANSI"}},
+	}
+}
+
+func TestExportFeed_RoundTrip(t *testing.T) {
+	for _, format := range []FeedFormat{FeedFormatJSON, FeedFormatNDJSON, FeedFormatCSV} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := ExportFeed(&buf, format, exportCatalog()); err != nil {
+				t.Fatalf("ExportFeed() error = %v", err)
+			}
+
+			items, report := importAll(t, buf.String(), format)
+			if len(report.Errors) != 0 {
+				t.Fatalf("Expected the export to import cleanly, got %v", report.Errors)
+			}
+
+			if expected := exportCatalog(); !reflect.DeepEqual(items, expected) {
+				t.Errorf("Round trip mismatch:\n got %+v\nwant %+v", items, expected)
+			}
+		})
+	}
+}
+
+func TestExportFeed_CSVColumns(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportFeed(&buf, FeedFormatCSV, exportCatalog()); err != nil {
+		t.Fatalf("ExportFeed() error = %v", err)
+	}
+
+	header, _, _ := strings.Cut(buf.String(), "\n")
+	expected := "id,parent_id,name,image_url,description,price,currency,rating,availability,estimated_ship_date," +
+		"name.es-MX,spec.color,spec.dpi,spec.layout,spec.weight.unit,spec.weight.value,spec.wireless"
+	if header != expected {
+		t.Errorf("Header = %s\nwant %s", header, expected)
+	}
+}
+
+func TestExportFeed_JSONEmptyCatalog(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportFeed(&buf, FeedFormatJSON, nil); err != nil {
+		t.Fatalf("ExportFeed() error = %v", err)
+	}
+	items, _ := importAll(t, buf.String(), FeedFormatJSON)
+	if len(items) != 0 {
+		t.Errorf("Expected an empty array, got %v", items)
+	}
+}
@@ -0,0 +1,213 @@
+package data
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+)
+
+func importAll(t *testing.T, feed string, format FeedFormat) ([]domain.Item, ImportReport) {
+	t.Helper()
+
+	items := []domain.Item{}
+	report, err := ImportFeed(strings.NewReader(feed), format, func(item domain.Item) error {
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ImportFeed() error = %v", err)
+	}
+	return items, report
+}
+
+func TestImportFeed_CSV(t *testing.T) {
+	feed := `id,name,price,rating,spec.weight.value,spec.weight.unit,spec.wireless,spec.layout
+m1,Mouse One,10.5,4.2,0.08,kg,true,
+m2,Mouse Two,abc,4,0.09,kg,false,
+k1,Keyboard,80,4.5,,,false,ANSI
+`
+
+	items, report := importAll(t, feed, FeedFormatCSV)
+
+	if report.Imported != 2 || len(items) != 2 {
+		t.Fatalf("Expected 2 imported items, got %d", report.Imported)
+	}
+
+	expectedSpecs := map[string]interface{}{
+		"weight":   map[string]interface{}{"value": 0.08, "unit": "kg"},
+		"wireless": true,
+	}
+	if !reflect.DeepEqual(items[0].Specifications, expectedSpecs) {
+		t.Errorf("Specifications = %v, want %v", items[0].Specifications, expectedSpecs)
+	}
+	if items[0].Price != 10.5 || items[0].Rating != 4.2 {
+		t.Errorf("Expected price 10.5 and rating 4.2, got %v and %v", items[0].Price, items[0].Rating)
+	}
+
+	// Empty spec cells are omitted instead of stored as empty strings
+	if _, exists := items[1].Specifications["weight"]; exists {
+		t.Error("Expected empty weight columns to be omitted")
+	}
+	if items[1].Specifications["layout"] != "ANSI" {
+		t.Errorf("Expected layout ANSI, got %v", items[1].Specifications["layout"])
+	}
+
+	if len(report.Errors) != 1 || report.Errors[0].Row != 2 || report.Errors[0].ID != "m2" {
+		t.Errorf("Expected one error for row 2 (m2), got %v", report.Errors)
+	}
+}
+
+func TestImportFeed_CSVConflictingSpecColumns(t *testing.T) {
+	feed := "id,name,price,rating,spec.weight,spec.weight.value\nm1,Mouse,1,1,100,0.1\n"
+
+	_, report := importAll(t, feed, FeedFormatCSV)
+
+	if report.Imported != 0 || len(report.Errors) != 1 {
+		t.Errorf("Expected the row to be rejected, got %+v", report)
+	}
+}
+
+func TestImportFeed_CSVVariants(t *testing.T) {
+	feed := `id,parent_id,name,price,rating,spec.color
+m,,Mouse,25,4.5,white
+m-black,m,,,,black
+k,,Keyboard,,4,
+loop,loop,Loop,1,1,
+`
+
+	items, report := importAll(t, feed, FeedFormatCSV)
+
+	// Variants may leave name, price and rating empty; top-level items may not
+	if len(items) != 2 || items[1].ID != "m-black" || items[1].ParentID != "m" {
+		t.Fatalf("Expected items m and m-black, got %v", items)
+	}
+	if len(report.Errors) != 2 || report.Errors[0].ID != "k" || report.Errors[1].ID != "loop" {
+		t.Errorf("Expected errors for k and loop, got %v", report.Errors)
+	}
+}
+
+func TestImportFeed_Translations(t *testing.T) {
+	csvFeed := `id,name,price,rating,name.es_mx,description.pt-br,name.pt-BR
+m1,Mouse,10,4,Ratón,Mouse sem fio,
+`
+	items, report := importAll(t, csvFeed, FeedFormatCSV)
+	if len(items) != 1 {
+		t.Fatalf("Expected 1 item, got %v", report.Errors)
+	}
+	expected := map[string]domain.ItemTranslation{
+		"es-MX": {Name: "Ratón"},
+		"pt-BR": {Description: "Mouse sem fio"},
+	}
+	if !reflect.DeepEqual(items[0].Translations, expected) {
+		t.Errorf("Translations = %v, want %v", items[0].Translations, expected)
+	}
+
+	ndjsonFeed := `{"id":"m1","name":"Mouse","price":1,"rating":1,"translations":{"ES-mx":{"name":"Ratón"},"es":{}}}
+{"id":"m2","name":"Mouse","price":1,"rating":1,"translations":{"not a locale":{"name":"x"}}}`
+	items, report = importAll(t, ndjsonFeed, FeedFormatNDJSON)
+	if len(items) != 1 || !reflect.DeepEqual(items[0].Translations, map[string]domain.ItemTranslation{"es-MX": {Name: "Ratón"}}) {
+		t.Errorf("Expected canonical non-empty translations, got %v", items)
+	}
+	if len(report.Errors) != 1 || report.Errors[0].ID != "m2" {
+		t.Errorf("Expected m2 rejected for its locale, got %v", report.Errors)
+	}
+}
+
+func TestImportFeed_Availability(t *testing.T) {
+	csvFeed := `id,name,price,rating,availability,estimated_ship_date
+m1,Mouse,10,4,In_Stock,
+m2,Mouse,10,4,backorder,2026-11-02
+m3,Mouse,10,4,,
+m4,Mouse,10,4,sold_out,
+m5,Mouse,10,4,in_stock,2026-11-02
+m6,Mouse,10,4,backorder,02/11/2026
+m7,Mouse,10,4,,2026-11-02
+`
+	items, report := importAll(t, csvFeed, FeedFormatCSV)
+	if len(items) != 3 {
+		t.Fatalf("Expected 3 items, got %v (errors %v)", items, report.Errors)
+	}
+	if *items[0].Availability != (domain.Availability{Status: domain.InStock}) {
+		t.Errorf("Expected a normalized status, got %+v", items[0].Availability)
+	}
+	if *items[1].Availability != (domain.Availability{Status: domain.Backorder, EstimatedShipDate: "2026-11-02"}) {
+		t.Errorf("Expected a backorder with ship date, got %+v", items[1].Availability)
+	}
+	if items[2].Availability != nil {
+		t.Errorf("Expected no availability data, got %+v", items[2].Availability)
+	}
+
+	// Unknown status, ship date out of backorder, invalid date, ship date without status
+	rejected := []string{}
+	for _, rowErr := range report.Errors {
+		rejected = append(rejected, rowErr.ID)
+	}
+	if !reflect.DeepEqual(rejected, []string{"m4", "m5", "m6", "m7"}) {
+		t.Errorf("Expected m4..m7 rejected, got %v", report.Errors)
+	}
+}
+
+func TestImportFeed_NDJSON(t *testing.T) {
+	feed := `{"id":"m1","name":"Mouse One","price":10,"rating":4,"specifications":{"sensor_dpi":1600}}
+
+{"id":"m2","name":"Mouse Two","price":
+{"id":"m3","name":"","price":5,"rating":4}
+{"id":"m1","name":"Again","price":1,"rating":1}
+{"id":"m4","name":"Mouse Four","price":12,"rating":4.9}`
+
+	items, report := importAll(t, feed, FeedFormatNDJSON)
+
+	if len(items) != 2 || items[0].ID != "m1" || items[1].ID != "m4" {
+		t.Fatalf("Expected items m1 and m4, got %v", items)
+	}
+	if items[0].Specifications["sensor_dpi"] != float64(1600) {
+		t.Errorf("Expected sensor_dpi 1600, got %v", items[0].Specifications["sensor_dpi"])
+	}
+
+	rows := []int{}
+	for _, rowErr := range report.Errors {
+		rows = append(rows, rowErr.Row)
+	}
+	if !reflect.DeepEqual(rows, []int{3, 4, 5}) {
+		t.Errorf("Expected errors on lines [3 4 5], got %v", rows)
+	}
+}
+
+func TestImportFeed_JSONRequiresArray(t *testing.T) {
+	_, err := ImportFeed(strings.NewReader(`{"id":"m1"}`), FeedFormatJSON, func(domain.Item) error { return nil })
+	if err == nil {
+		t.Error("Expected error for a JSON feed that is not an array")
+	}
+}
+
+func TestParseFeedFormat(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    string
+		path      string
+		expected  FeedFormat
+		expectErr bool
+	}{
+		{name: "Explicit format wins", format: "csv", path: "items.json", expected: FeedFormatCSV},
+		{name: "Inferred from extension", path: "data/items.ndjson", expected: FeedFormatNDJSON},
+		{name: "jsonl is NDJSON", path: "feed.jsonl", expected: FeedFormatNDJSON},
+		{name: "Unknown extension fails", path: "feed.xml", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFeedFormat(tt.format, tt.path)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("Expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil || got != tt.expected {
+				t.Errorf("ParseFeedFormat() = %v, %v; want %v", got, err, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"go.uber.org/zap"
+)
+
+// Defaults of HTTPCatalogConfig
+const (
+	DefaultHTTPCatalogTimeout    = 30 * time.Second
+	DefaultHTTPCatalogMaxBackoff = 10 * time.Minute
+)
+
+// errNotModified is returned by fetch when the server answers 304
+var errNotModified = errors.New("catalog not modified")
+
+// HTTPCatalogConfig configures the remote catalog source
+type HTTPCatalogConfig struct {
+	URL           string
+	Format        FeedFormat    // Empty: from the Content-Type, then the URL extension, then JSON
+	Authorization string        // Optional Authorization header (e.g. "Bearer <token>")
+	Timeout       time.Duration // Per request
+	MaxBackoff    time.Duration // Longest wait between polls after consecutive failures
+}
+
+// HTTPCatalogRepo implements CatalogRepository polling a catalog export URL (e.g. the PIM).
+// Requests are conditional (ETag / Last-Modified), so an unchanged export costs a 304.
+// Every download that changes the content publishes a new immutable version.
+type HTTPCatalogRepo struct {
+	*catalogStore
+
+	logger *zap.Logger
+	cfg    HTTPCatalogConfig
+	client *http.Client
+
+	// Validators of the last published download, only touched by fetch
+	etag         string
+	lastModified string
+}
+
+// NewHTTPCatalogRepo creates the repository. The first download must succeed.
+func NewHTTPCatalogRepo(ctx context.Context, cfg HTTPCatalogConfig, keepVersions int, logger *zap.Logger) (*HTTPCatalogRepo, error) {
+	if _, err := url.ParseRequestURI(cfg.URL); err != nil {
+		return nil, fmt.Errorf("invalid catalog URL: %w", err)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultHTTPCatalogTimeout
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = DefaultHTTPCatalogMaxBackoff
+	}
+
+	repo := &HTTPCatalogRepo{
+		catalogStore: newCatalogStore(keepVersions, logger),
+		logger:       logger,
+		cfg:          cfg,
+		client:       &http.Client{Timeout: cfg.Timeout},
+	}
+
+	if err := repo.fetch(ctx); err != nil {
+		return nil, fmt.Errorf("failed to load catalog: %w", err)
+	}
+
+	logger.Info("catalog loaded successfully",
+		zap.String("url", redactURL(cfg.URL)),
+		zap.Int("items", repo.CurrentSnapshot().Len()),
+		zap.String("version", repo.CurrentSnapshot().Version.ID),
+	)
+
+	return repo, nil
+}
+
+// Watch polls the URL every interval until ctx is done. After a failure the wait doubles,
+// up to MaxBackoff, and a failed download keeps serving the previous version.
+func (r *HTTPCatalogRepo) Watch(ctx context.Context, interval time.Duration) {
+	failures := 0
+
+	for {
+		timer := time.NewTimer(pollDelay(interval, r.cfg.MaxBackoff, failures))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		err := r.fetch(ctx)
+		switch {
+		case err == nil, errors.Is(err, errNotModified):
+			failures = 0
+		case ctx.Err() != nil:
+			return
+		default:
+			failures++
+			r.logger.Error("failed to reload catalog, keeping previous version",
+				zap.Error(err),
+				zap.Int("consecutive_failures", failures),
+				zap.Duration("next_poll", pollDelay(interval, r.cfg.MaxBackoff, failures)),
+			)
+		}
+	}
+}
+
+// fetch downloads the catalog and publishes it.
+// Returns errNotModified when the server answers 304. A payload with invalid rows is rejected
+// as a whole, and its ETag is not kept so the next poll downloads it again.
+func (r *HTTPCatalogRepo) fetch(ctx context.Context) error {
+	// 1. Conditional request
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.cfg.URL, nil)
+	if err != nil {
+		return err
+	}
+	if r.etag != "" {
+		req.Header.Set("If-None-Match", r.etag)
+	}
+	if r.lastModified != "" {
+		req.Header.Set("If-Modified-Since", r.lastModified)
+	}
+	if r.cfg.Authorization != "" {
+		req.Header.Set("Authorization", r.cfg.Authorization)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		// url.Error repeats the full URL, which may carry a token
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to download catalog: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return errNotModified
+	default:
+		return fmt.Errorf("catalog server answered %s", resp.Status)
+	}
+
+	// 2. Stream and validate the payload
+	format := r.cfg.Format
+	if format == "" {
+		format = detectFeedFormat(resp.Header.Get("Content-Type"), r.cfg.URL)
+	}
+
+	items := make([]domain.Item, 0)
+	report, err := ImportFeed(resp.Body, format, func(item domain.Item) error {
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to import %s feed: %w", format, err)
+	}
+
+	for _, rowErr := range report.Errors {
+		r.logger.Warn("invalid catalog row",
+			zap.Int("row", rowErr.Row),
+			zap.String("id", rowErr.ID),
+			zap.Error(rowErr.Err),
+		)
+	}
+	if err := report.Err(); err != nil {
+		return fmt.Errorf("rejected %s feed: %w", format, err)
+	}
+
+	// A broken export must never wipe the live catalog
+	if len(items) == 0 {
+		return errors.New("catalog payload has no items")
+	}
+
+	// 3. Publish a new version and its search index atomically (no-op if the content did not change)
+	if _, _, err := r.publish(items); err != nil {
+		return fmt.Errorf("failed to publish catalog: %w", err)
+	}
+
+	r.etag = resp.Header.Get("ETag")
+	r.lastModified = resp.Header.Get("Last-Modified")
+
+	return nil
+}
+
+// pollDelay returns the wait before the next poll: the interval, doubled per consecutive failure
+func pollDelay(interval, maxBackoff time.Duration, failures int) time.Duration {
+	delay := interval
+	for i := 0; i < failures && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, max(maxBackoff, interval))
+}
+
+// detectFeedFormat infers the format of a download from its Content-Type,
+// then from the extension of the URL path, and falls back to JSON
+func detectFeedFormat(contentType, rawURL string) FeedFormat {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch mediaType {
+		case "application/json":
+			return FeedFormatJSON
+		case "application/x-ndjson", "application/ndjson", "application/jsonl":
+			return FeedFormatNDJSON
+		case "text/csv":
+			return FeedFormatCSV
+		}
+	}
+
+	if parsed, err := url.Parse(rawURL); err == nil {
+		if format, err := ParseFeedFormat("", path.Base(parsed.Path)); err == nil {
+			return format
+		}
+	}
+
+	return FeedFormatJSON
+}
+
+// redactURL drops the credentials and query of a URL (e.g. signed export links) before logging it
+func redactURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "<invalid url>"
+	}
+	parsed.User = nil
+	parsed.RawQuery = ""
+	return parsed.String()
+}
@@ -0,0 +1,199 @@
+package data
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// pimServer stands in for the PIM export: it serves the current payload with an ETag
+// and answers 304 when the client already has it
+type pimServer struct {
+	mu          sync.Mutex
+	payload     string
+	contentType string
+	etag        string
+	status      int // Forced status, 0 = normal behavior
+	requests    int
+	notModified int
+	auth        string
+}
+
+func (p *pimServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.requests++
+	p.auth = r.Header.Get("Authorization")
+
+	if p.status != 0 {
+		w.WriteHeader(p.status)
+		return
+	}
+	if r.Header.Get("If-None-Match") == p.etag {
+		p.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("ETag", p.etag)
+	w.Header().Set("Content-Type", p.contentType)
+	_, _ = w.Write([]byte(p.payload))
+}
+
+func (p *pimServer) set(payload, etag string, status int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.payload, p.etag, p.status = payload, etag, status
+}
+
+func TestHTTPCatalogRepo_Fetch(t *testing.T) {
+	pim := &pimServer{
+		payload:     `[{"id":"a","name":"Item A","price":10,"rating":4}]`,
+		contentType: "application/json",
+		etag:        `"v1"`,
+	}
+	server := httptest.NewServer(pim)
+	defer server.Close()
+
+	ctx := context.Background()
+	repo, err := NewHTTPCatalogRepo(ctx, HTTPCatalogConfig{
+		URL:           server.URL + "/export",
+		Authorization: "Bearer secret",
+	}, 5, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewHTTPCatalogRepo() error = %v", err)
+	}
+	first := repo.CurrentSnapshot()
+	if first.Len() != 1 || pim.auth != "Bearer secret" {
+		t.Fatalf("Expected 1 item downloaded with the authorization header, got %d (%q)", first.Len(), pim.auth)
+	}
+	if index, _ := repo.SearchIndex(ctx); index == nil || index.Len() != 1 {
+		t.Error("Expected the search index to be published with the snapshot")
+	}
+
+	// Unchanged export → 304 and same version
+	if err := repo.fetch(ctx); err != errNotModified {
+		t.Fatalf("Expected errNotModified, got %v", err)
+	}
+	if pim.notModified != 1 || repo.CurrentSnapshot() != first {
+		t.Error("Expected a conditional request keeping the live version")
+	}
+
+	// Changed export, NDJSON detected from the Content-Type
+	pim.contentType = "application/x-ndjson"
+	pim.set("{\"id\":\"a\",\"name\":\"Item A\",\"price\":8,\"rating\":4}\n{\"id\":\"b\",\"name\":\"Item B\",\"price\":5,\"rating\":3}\n", `"v2"`, 0)
+	if err := repo.fetch(ctx); err != nil {
+		t.Fatalf("fetch() error = %v", err)
+	}
+	second := repo.CurrentSnapshot()
+	if second == first || second.Len() != 2 {
+		t.Fatalf("Expected a new version with 2 items, got %d", second.Len())
+	}
+
+	tests := []struct {
+		name    string
+		payload string
+		status  int
+	}{
+		{name: "Server error", status: http.StatusInternalServerError},
+		{name: "Malformed payload", payload: `{"not":"an array"`},
+		{name: "No valid items", payload: `{"id":"","name":"no id","price":1}` + "\n"},
+		{name: "One invalid row", payload: `{"id":"a","name":"Item A","price":8,"rating":4}` + "\n" + `{"id":"b","name":"Item B","price":-5,"rating":3}` + "\n"},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pim.set(tt.payload, `"bad-`+string(rune('0'+i))+`"`, tt.status)
+			if err := repo.fetch(ctx); err == nil {
+				t.Fatal("Expected an error")
+			}
+			if repo.CurrentSnapshot() != second {
+				t.Error("Expected a failed download to keep the live version")
+			}
+		})
+	}
+}
+
+func TestNewHTTPCatalogRepo_InitialFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	if _, err := NewHTTPCatalogRepo(context.Background(), HTTPCatalogConfig{URL: server.URL}, 5, zap.NewNop()); err == nil {
+		t.Error("Expected an error when the first download fails")
+	}
+	if _, err := NewHTTPCatalogRepo(context.Background(), HTTPCatalogConfig{URL: "not a url"}, 5, zap.NewNop()); err == nil {
+		t.Error("Expected an error for an invalid URL")
+	}
+}
+
+func TestHTTPCatalogRepo_WatchBacksOff(t *testing.T) {
+	pim := &pimServer{payload: `[{"id":"a","name":"A","price":1,"rating":1}]`, contentType: "application/json", etag: `"v1"`}
+	server := httptest.NewServer(pim)
+	defer server.Close()
+
+	repo, err := NewHTTPCatalogRepo(context.Background(), HTTPCatalogConfig{
+		URL:        server.URL,
+		MaxBackoff: 40 * time.Millisecond,
+	}, 5, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewHTTPCatalogRepo() error = %v", err)
+	}
+	pim.set("", `"v1"`, http.StatusBadGateway)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	repo.Watch(ctx, 10*time.Millisecond)
+
+	// Without backoff ~20 polls fit in 200ms; with 10, 20, 40, 40... at most ~7
+	pim.mu.Lock()
+	polls := pim.requests - 1
+	pim.mu.Unlock()
+	if polls < 2 || polls > 8 {
+		t.Errorf("Expected the failing polls to back off, got %d polls", polls)
+	}
+}
+
+func TestPollDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{failures: 0, expected: 30 * time.Second},
+		{failures: 1, expected: time.Minute},
+		{failures: 3, expected: 4 * time.Minute},
+		{failures: 10, expected: 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := pollDelay(30*time.Second, 5*time.Minute, tt.failures); got != tt.expected {
+			t.Errorf("pollDelay(failures=%d) = %v, want %v", tt.failures, got, tt.expected)
+		}
+	}
+}
+
+func TestDetectFeedFormat(t *testing.T) {
+	tests := []struct {
+		contentType string
+		url         string
+		expected    FeedFormat
+	}{
+		{contentType: "text/csv; charset=utf-8", url: "https://pim/export", expected: FeedFormatCSV},
+		{contentType: "application/x-ndjson", url: "https://pim/export.json", expected: FeedFormatNDJSON},
+		{contentType: "application/octet-stream", url: "https://pim/export.jsonl?token=x", expected: FeedFormatNDJSON},
+		{contentType: "", url: "https://pim/export", expected: FeedFormatJSON},
+	}
+
+	for _, tt := range tests {
+		if got := detectFeedFormat(tt.contentType, tt.url); got != tt.expected {
+			t.Errorf("detectFeedFormat(%q, %q) = %v, want %v", tt.contentType, tt.url, got, tt.expected)
+		}
+	}
+}
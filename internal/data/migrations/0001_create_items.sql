@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS items (
+    id             TEXT PRIMARY KEY,
+    name           TEXT        NOT NULL,
+    image_url      TEXT        NOT NULL DEFAULT '',
+    description    TEXT        NOT NULL DEFAULT '',
+    price          NUMERIC(12, 2) NOT NULL,
+    rating         NUMERIC(3, 2)  NOT NULL DEFAULT 0,
+    specifications JSONB       NOT NULL DEFAULT '{}'::jsonb,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS items_specifications_gin ON items USING GIN (specifications);
@@ -0,0 +1,4 @@
+-- Variants reference their parent; no foreign key so a feed can load them in any order
+ALTER TABLE items ADD COLUMN IF NOT EXISTS parent_id TEXT;
+
+CREATE INDEX IF NOT EXISTS items_parent_id ON items (parent_id) WHERE parent_id IS NOT NULL;
@@ -0,0 +1,2 @@
+-- Localized name and description per locale: {"es-MX": {"name": "...", "description": "..."}}
+ALTER TABLE items ADD COLUMN IF NOT EXISTS translations JSONB NOT NULL DEFAULT '{}'::jsonb;
@@ -0,0 +1,2 @@
+-- Currency of the price (ISO 4217); NULL uses the currency of the storefront
+ALTER TABLE items ADD COLUMN IF NOT EXISTS currency TEXT;
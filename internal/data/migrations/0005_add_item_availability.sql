@@ -0,0 +1,3 @@
+-- Availability of the item; NULL status means no availability data (treated as available)
+ALTER TABLE items ADD COLUMN IF NOT EXISTS availability TEXT;
+ALTER TABLE items ADD COLUMN IF NOT EXISTS estimated_ship_date DATE;
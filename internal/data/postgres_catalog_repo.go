@@ -0,0 +1,363 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"go.uber.org/zap"
+)
+
+// PostgresConfig holds the connection and pool settings of the PostgreSQL catalog
+type PostgresConfig struct {
+	URL             string
+	MaxConns        int32
+	MinConns        int32
+	MaxConnLifetime time.Duration
+	MaxConnIdleTime time.Duration
+	Migrate         bool // Apply pending migrations on startup
+}
+
+// PostgresCatalogRepo implements CatalogRepository reading from the items table of PostgreSQL.
+// Specifications are stored as JSONB so the product service can evolve them freely.
+// Variants are stored as written by the feed and inherit from their parent on read.
+type PostgresCatalogRepo struct {
+	pool   *pgxpool.Pool
+	logger *zap.Logger
+
+	// Search index of the table, rebuilt when its stamp changes
+	searchMu    sync.Mutex
+	searchIndex *SearchIndex
+	searchStamp tableStamp
+}
+
+// tableStamp identifies the content of the items table: any insert, update or delete changes it
+type tableStamp struct {
+	count int64
+	hash  string // Sum of the hashes of every (id, updated_at)
+}
+
+const selectItemColumns = `SELECT id, name, image_url, description, price, rating, specifications, COALESCE(parent_id, ''), translations, COALESCE(currency, ''), COALESCE(availability, ''), COALESCE(to_char(estimated_ship_date, 'YYYY-MM-DD'), '') FROM items`
+
+// NewPostgresCatalogRepo creates the connection pool, verifies it and optionally runs the migrations
+func NewPostgresCatalogRepo(ctx context.Context, cfg PostgresConfig, logger *zap.Logger) (*PostgresCatalogRepo, error) {
+	poolCfg, err := pgxpool.ParseConfig(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database url: %w", err)
+	}
+	if cfg.MaxConns > 0 {
+		poolCfg.MaxConns = cfg.MaxConns
+	}
+	if cfg.MinConns > 0 {
+		poolCfg.MinConns = cfg.MinConns
+	}
+	if cfg.MaxConnLifetime > 0 {
+		poolCfg.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		poolCfg.MaxConnIdleTime = cfg.MaxConnIdleTime
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pool: %w", err)
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	if cfg.Migrate {
+		if err := Migrate(ctx, pool, logger); err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("failed to migrate database: %w", err)
+		}
+	}
+
+	logger.Info("postgres catalog connected",
+		zap.Int32("max_conns", poolCfg.MaxConns),
+		zap.Int32("min_conns", poolCfg.MinConns),
+	)
+
+	return &PostgresCatalogRepo{
+		pool:   pool,
+		logger: logger,
+	}, nil
+}
+
+// GetByIDs implements CatalogRepository.GetByIDs.
+// A query failure (including a cancelled ctx) is returned, never reported as missing IDs.
+func (r *PostgresCatalogRepo) GetByIDs(ctx context.Context, ids []string) ([]domain.Item, []string, error) {
+	// The requested items plus the variants of the requested parents and the parents of the requested variants
+	items, err := r.queryItems(ctx, selectItemColumns+`
+		WHERE id = ANY($1)
+		   OR parent_id = ANY($1)
+		   OR id IN (SELECT parent_id FROM items WHERE id = ANY($1))`, ids)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query items by id: %w", err)
+	}
+
+	byID := make(map[string]domain.Item, len(items))
+	for _, item := range r.resolveVariants(items) {
+		byID[item.ID] = item
+	}
+
+	found := make([]domain.Item, 0, len(ids))
+	missing := make([]string, 0)
+
+	// Maintain the order of the requested IDs
+	for _, id := range ids {
+		if item, exists := byID[id]; exists {
+			found = append(found, item)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+
+	return found, missing, nil
+}
+
+// GetAll implements CatalogRepository.GetAll.
+// A query failure is returned, never reported as an empty catalog.
+func (r *PostgresCatalogRepo) GetAll(ctx context.Context) ([]domain.Item, error) {
+	items, err := r.queryItems(ctx, selectItemColumns+` ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query all items: %w", err)
+	}
+
+	return r.resolveVariants(items), nil
+}
+
+// GetVariants implements CatalogRepository.GetVariants.
+// A query failure is returned, never reported as id not being a parent.
+func (r *PostgresCatalogRepo) GetVariants(ctx context.Context, id string) ([]domain.Item, bool, error) {
+	items, err := r.queryItems(ctx, selectItemColumns+` WHERE id = $1 OR parent_id = $1 ORDER BY id`, id)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to query variants of %s: %w", id, err)
+	}
+
+	variants := make([]domain.Item, 0)
+	for _, item := range r.resolveVariants(items) {
+		if item.ParentID == id {
+			variants = append(variants, item)
+		}
+	}
+	return variants, len(variants) > 0, nil
+}
+
+// SearchIndex implements Searchable.SearchIndex. The index is rebuilt only when the table changed
+// since the last build, checked with one aggregate query per call.
+func (r *PostgresCatalogRepo) SearchIndex(ctx context.Context) (*SearchIndex, error) {
+	var stamp tableStamp
+	err := r.pool.QueryRow(ctx, `
+		SELECT count(*), COALESCE(sum(hashtextextended(id || ':' || updated_at::text, 0)), 0)::text
+		FROM items`).Scan(&stamp.count, &stamp.hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stamp items: %w", err)
+	}
+
+	r.searchMu.Lock()
+	defer r.searchMu.Unlock()
+
+	if r.searchIndex != nil && r.searchStamp == stamp {
+		return r.searchIndex, nil
+	}
+
+	// A change committed after the stamp only makes the next call rebuild again
+	items, err := r.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.searchIndex = NewSearchIndex(items)
+	r.searchStamp = stamp
+
+	r.logger.Debug("search index rebuilt", zap.Int64("items", stamp.count))
+
+	return r.searchIndex, nil
+}
+
+// UpsertItems inserts or updates the given items in a single transaction, keeping the other items
+func (r *PostgresCatalogRepo) UpsertItems(ctx context.Context, items []domain.Item) error {
+	imp, err := r.begin(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer imp.Rollback(ctx)
+
+	if err := imp.Upsert(ctx, items); err != nil {
+		return err
+	}
+	return imp.Commit(ctx)
+}
+
+// ItemImport upserts items in batches inside one transaction, so an import that fails
+// partway leaves the catalog as it was
+type ItemImport struct {
+	tx      pgx.Tx
+	replace bool     // Commit deletes the items the import did not upsert
+	ids     []string // Items upserted so far
+}
+
+// BeginImport starts an import that replaces the catalog: once it commits, the items it did
+// not upsert are gone. It must end with Commit or Rollback.
+func (r *PostgresCatalogRepo) BeginImport(ctx context.Context) (*ItemImport, error) {
+	return r.begin(ctx, true)
+}
+
+func (r *PostgresCatalogRepo) begin(ctx context.Context, replace bool) (*ItemImport, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	return &ItemImport{tx: tx, replace: replace, ids: make([]string, 0)}, nil
+}
+
+// Upsert inserts or updates a batch of items, visible once the import commits
+func (i *ItemImport) Upsert(ctx context.Context, items []domain.Item) error {
+	batch := &pgx.Batch{}
+	for _, item := range items {
+		i.ids = append(i.ids, item.ID)
+		specs, err := json.Marshal(item.Specifications)
+		if err != nil {
+			return fmt.Errorf("failed to marshal specifications of %s: %w", item.ID, err)
+		}
+		translations := []byte("{}")
+		if len(item.Translations) > 0 {
+			if translations, err = json.Marshal(item.Translations); err != nil {
+				return fmt.Errorf("failed to marshal translations of %s: %w", item.ID, err)
+			}
+		}
+		var availability domain.Availability
+		if item.Availability != nil {
+			availability = *item.Availability
+		}
+		batch.Queue(`
+			INSERT INTO items (id, name, image_url, description, price, rating, specifications, parent_id, translations, currency, availability, estimated_ship_date)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9, NULLIF($10, ''), NULLIF($11, ''), NULLIF($12, '')::date)
+			ON CONFLICT (id) DO UPDATE SET
+				name = EXCLUDED.name,
+				image_url = EXCLUDED.image_url,
+				description = EXCLUDED.description,
+				price = EXCLUDED.price,
+				rating = EXCLUDED.rating,
+				specifications = EXCLUDED.specifications,
+				parent_id = EXCLUDED.parent_id,
+				translations = EXCLUDED.translations,
+				currency = EXCLUDED.currency,
+				availability = EXCLUDED.availability,
+				estimated_ship_date = EXCLUDED.estimated_ship_date,
+				updated_at = now()`,
+			item.ID, item.Name, item.ImageURL, item.Description, item.Price, item.Rating, specs, item.ParentID, translations, item.Currency,
+			string(availability.Status), availability.EstimatedShipDate,
+		)
+	}
+
+	if err := i.tx.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to upsert items: %w", err)
+	}
+	return nil
+}
+
+// Commit publishes every item of the import at once, and removes the items missing from it
+// when the import replaces the catalog
+func (i *ItemImport) Commit(ctx context.Context) error {
+	if i.replace {
+		if _, err := i.tx.Exec(ctx, `DELETE FROM items WHERE NOT (id = ANY($1))`, i.ids); err != nil {
+			return fmt.Errorf("failed to delete items missing from the import: %w", err)
+		}
+	}
+	if err := i.tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit import: %w", err)
+	}
+	return nil
+}
+
+// Rollback discards the import; a no-op once it committed
+func (i *ItemImport) Rollback(ctx context.Context) {
+	_ = i.tx.Rollback(ctx)
+}
+
+// Close releases every connection of the pool
+func (r *PostgresCatalogRepo) Close() {
+	r.pool.Close()
+	r.logger.Info("postgres catalog closed")
+}
+
+// queryItems runs a select of selectItemColumns and scans every row, keeping the order of the query
+func (r *PostgresCatalogRepo) queryItems(ctx context.Context, query string, args ...interface{}) ([]domain.Item, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]domain.Item, 0)
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// resolveVariants materializes the variants of the rows, logging the ones whose parent is not a top-level item
+func (r *PostgresCatalogRepo) resolveVariants(items []domain.Item) []domain.Item {
+	resolved, orphans := ResolveVariants(items)
+	for _, orphan := range orphans {
+		r.logger.Warn("skipped variant without a top-level parent",
+			zap.String("id", orphan.ID),
+			zap.String("parent_id", orphan.ParentID),
+		)
+	}
+	return resolved
+}
+
+// scanItem maps the current row to a domain.Item.
+// Specifications are decoded with the same JSON library as the file catalog so numbers stay float64.
+func scanItem(rows pgx.Rows) (domain.Item, error) {
+	var item domain.Item
+	var specs, translations []byte
+	var availability domain.Availability
+
+	if err := rows.Scan(
+		&item.ID,
+		&item.Name,
+		&item.ImageURL,
+		&item.Description,
+		&item.Price,
+		&item.Rating,
+		&specs,
+		&item.ParentID,
+		&translations,
+		&item.Currency,
+		&availability.Status,
+		&availability.EstimatedShipDate,
+	); err != nil {
+		return domain.Item{}, err
+	}
+
+	if len(specs) > 0 {
+		if err := json.Unmarshal(specs, &item.Specifications); err != nil {
+			return domain.Item{}, fmt.Errorf("invalid specifications for %s: %w", item.ID, err)
+		}
+	}
+	if len(translations) > 0 && string(translations) != "{}" {
+		if err := json.Unmarshal(translations, &item.Translations); err != nil {
+			return domain.Item{}, fmt.Errorf("invalid translations for %s: %w", item.ID, err)
+		}
+	}
+	if availability.Status != "" {
+		item.Availability = &availability
+	}
+
+	return item, nil
+}
@@ -0,0 +1,299 @@
+//go:build integration
+
+package data
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"go.uber.org/zap"
+)
+
+// newTestPostgresRepo connects to the database in POSTGRES_TEST_URL (see `make test-integration`)
+// and leaves the items table empty
+func newTestPostgresRepo(t *testing.T) *PostgresCatalogRepo {
+	t.Helper()
+
+	url := os.Getenv("POSTGRES_TEST_URL")
+	if url == "" {
+		t.Skip("POSTGRES_TEST_URL not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	repo, err := NewPostgresCatalogRepo(ctx, PostgresConfig{URL: url, MaxConns: 4, Migrate: true}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewPostgresCatalogRepo() error = %v", err)
+	}
+	t.Cleanup(repo.Close)
+
+	if _, err := repo.pool.Exec(ctx, "TRUNCATE items"); err != nil {
+		t.Fatalf("failed to truncate items: %v", err)
+	}
+
+	return repo
+}
+
+func seedItems() []domain.Item {
+	return []domain.Item{
+		{
+			ID:     "mouse-1",
+			Name:   "Mouse 1",
+			Price:  34.24,
+			Rating: 4.0,
+			Specifications: map[string]interface{}{
+				"weight":     map[string]interface{}{"value": 0.082, "unit": "kg"},
+				"sensor_dpi": 18000,
+				"wireless":   true,
+			},
+		},
+		{
+			ID:     "mouse-2",
+			Name:   "Mouse 2",
+			Price:  23.26,
+			Rating: 4.8,
+			Specifications: map[string]interface{}{
+				"sensor_dpi": 12000,
+				"wireless":   false,
+			},
+		},
+	}
+}
+
+func TestPostgresCatalogRepo_MigrateIsIdempotent(t *testing.T) {
+	repo := newTestPostgresRepo(t)
+
+	if err := Migrate(context.Background(), repo.pool, zap.NewNop()); err != nil {
+		t.Fatalf("second Migrate() error = %v", err)
+	}
+}
+
+func TestPostgresCatalogRepo_GetByIDs(t *testing.T) {
+	repo := newTestPostgresRepo(t)
+	ctx := context.Background()
+
+	if err := repo.UpsertItems(ctx, seedItems()); err != nil {
+		t.Fatalf("UpsertItems() error = %v", err)
+	}
+
+	items, missing, err := repo.GetByIDs(ctx, []string{"mouse-2", "unknown", "mouse-1"})
+	if err != nil {
+		t.Fatalf("GetByIDs() error = %v", err)
+	}
+
+	if len(missing) != 1 || missing[0] != "unknown" {
+		t.Errorf("Expected missing [unknown], got %v", missing)
+	}
+	if len(items) != 2 || items[0].ID != "mouse-2" || items[1].ID != "mouse-1" {
+		t.Fatalf("Expected items in requested order, got %v", items)
+	}
+
+	// JSONB specifications keep the same shape as the file catalog
+	if items[1].Specifications["sensor_dpi"] != float64(18000) {
+		t.Errorf("Expected sensor_dpi 18000 (float64), got %v (%T)",
+			items[1].Specifications["sensor_dpi"], items[1].Specifications["sensor_dpi"])
+	}
+	weight, ok := items[1].Specifications["weight"].(map[string]interface{})
+	if !ok || weight["unit"] != "kg" {
+		t.Errorf("Expected nested weight spec, got %v", items[1].Specifications["weight"])
+	}
+	if items[1].Price != 34.24 {
+		t.Errorf("Expected price 34.24, got %v", items[1].Price)
+	}
+}
+
+func TestPostgresCatalogRepo_UpsertUpdatesExisting(t *testing.T) {
+	repo := newTestPostgresRepo(t)
+	ctx := context.Background()
+
+	items := seedItems()
+	if err := repo.UpsertItems(ctx, items); err != nil {
+		t.Fatalf("UpsertItems() error = %v", err)
+	}
+
+	items[0].Price = 19.99
+	if err := repo.UpsertItems(ctx, items[:1]); err != nil {
+		t.Fatalf("UpsertItems() error = %v", err)
+	}
+
+	all, err := repo.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(all))
+	}
+	if all[0].ID != "mouse-1" || all[0].Price != 19.99 {
+		t.Errorf("Expected updated price 19.99 for mouse-1, got %v", all[0])
+	}
+}
+
+func TestPostgresCatalogRepo_Variants(t *testing.T) {
+	repo := newTestPostgresRepo(t)
+	ctx := context.Background()
+
+	items := append(seedItems(),
+		domain.Item{
+			ID: "mouse-1-black", ParentID: "mouse-1",
+			Specifications: map[string]interface{}{"color": "black"},
+			Translations:   map[string]domain.ItemTranslation{"es-MX": {Description: "Mouse negro"}},
+		},
+		domain.Item{
+			ID: "mouse-1-pro", ParentID: "mouse-1", Price: 49.99,
+			Availability: &domain.Availability{Status: domain.Backorder, EstimatedShipDate: "2026-11-02"},
+		},
+	)
+	if err := repo.UpsertItems(ctx, items); err != nil {
+		t.Fatalf("UpsertItems() error = %v", err)
+	}
+
+	variants, isParent, err := repo.GetVariants(ctx, "mouse-1")
+	if err != nil {
+		t.Fatalf("GetVariants() error = %v", err)
+	}
+	if !isParent || len(variants) != 2 || variants[0].ID != "mouse-1-black" || variants[1].ID != "mouse-1-pro" {
+		t.Fatalf("Expected variants [mouse-1-black mouse-1-pro], got %v", variants)
+	}
+	if variants[1].Availability == nil || *variants[1].Availability != *items[len(items)-1].Availability {
+		t.Errorf("Expected the availability back, got %+v", variants[1].Availability)
+	}
+	if variants[0].Availability != nil {
+		t.Errorf("Expected no availability data, got %+v", variants[0].Availability)
+	}
+	if _, isParent, _ := repo.GetVariants(ctx, "mouse-2"); isParent {
+		t.Error("Expected mouse-2 not to be a parent")
+	}
+
+	// A variant read alone still inherits from its parent
+	found, missing, err := repo.GetByIDs(ctx, []string{"mouse-1-black", "mouse-1"})
+	if err != nil {
+		t.Fatalf("GetByIDs() error = %v", err)
+	}
+	if len(missing) != 0 || len(found) != 2 {
+		t.Fatalf("Expected 2 items, got %v (missing %v)", found, missing)
+	}
+	if found[0].Name != items[0].Name || found[0].Specifications["color"] != "black" {
+		t.Errorf("Expected inherited name and own color, got %+v", found[0])
+	}
+	if found[0].Translations["es-MX"].Description != "Mouse negro" {
+		t.Errorf("Expected the JSONB translations back, got %v", found[0].Translations)
+	}
+	if len(found[1].Variants) != 2 {
+		t.Errorf("Expected the parent to list 2 variants, got %v", found[1].Variants)
+	}
+}
+
+func TestPostgresCatalogRepo_HonorsContext(t *testing.T) {
+	repo := newTestPostgresRepo(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	items, missing, err := repo.GetByIDs(ctx, []string{"mouse-1", "mouse-2"})
+	if err == nil {
+		t.Errorf("Expected cancelled query to fail, got items=%v missing=%v", items, missing)
+	}
+}
+
+func TestPostgresCatalogRepo_ImportRollsBack(t *testing.T) {
+	repo := newTestPostgresRepo(t)
+	ctx := context.Background()
+	items := seedItems()
+
+	imp, err := repo.BeginImport(ctx)
+	if err != nil {
+		t.Fatalf("BeginImport() error = %v", err)
+	}
+	if err := imp.Upsert(ctx, items[:1]); err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+	if err := imp.Upsert(ctx, items[1:]); err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+	imp.Rollback(ctx)
+
+	// A failed import leaves nothing behind, not even its first batches
+	if all, err := repo.GetAll(ctx); err != nil || len(all) != 0 {
+		t.Errorf("Expected an empty catalog after the rollback, got %v", all)
+	}
+}
+
+func TestPostgresCatalogRepo_ImportReplacesCatalog(t *testing.T) {
+	repo := newTestPostgresRepo(t)
+	ctx := context.Background()
+	items := seedItems()
+	if err := repo.UpsertItems(ctx, items); err != nil {
+		t.Fatalf("UpsertItems() error = %v", err)
+	}
+
+	// The next feed only lists the second item
+	imp, err := repo.BeginImport(ctx)
+	if err != nil {
+		t.Fatalf("BeginImport() error = %v", err)
+	}
+	defer imp.Rollback(ctx)
+	if err := imp.Upsert(ctx, items[1:]); err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+	if err := imp.Commit(ctx); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	all, err := repo.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	if len(all) != 1 || all[0].ID != items[1].ID {
+		t.Errorf("Expected only %s after the import, got %v", items[1].ID, all)
+	}
+
+	// UpsertItems keeps the items it does not list
+	if err := repo.UpsertItems(ctx, items[:1]); err != nil {
+		t.Fatalf("UpsertItems() error = %v", err)
+	}
+	if all, err := repo.GetAll(ctx); err != nil || len(all) != 2 {
+		t.Errorf("Expected 2 items after the upsert, got %v (%v)", all, err)
+	}
+}
+
+func TestPostgresCatalogRepo_SearchIndex(t *testing.T) {
+	repo := newTestPostgresRepo(t)
+	ctx := context.Background()
+	items := seedItems()
+	if err := repo.UpsertItems(ctx, items); err != nil {
+		t.Fatalf("UpsertItems() error = %v", err)
+	}
+
+	first, err := repo.SearchIndex(ctx)
+	if err != nil {
+		t.Fatalf("SearchIndex() error = %v", err)
+	}
+	if first.Len() != 2 {
+		t.Fatalf("Expected 2 indexed items, got %d", first.Len())
+	}
+
+	// An unchanged table reuses the index
+	if again, err := repo.SearchIndex(ctx); err != nil || again != first {
+		t.Errorf("Expected the same index for an unchanged table, got %p (%v)", again, err)
+	}
+
+	// An update rebuilds it
+	items[1].Name = "Trackball 2"
+	if err := repo.UpsertItems(ctx, items[1:]); err != nil {
+		t.Fatalf("UpsertItems() error = %v", err)
+	}
+	updated, err := repo.SearchIndex(ctx)
+	if err != nil {
+		t.Fatalf("SearchIndex() error = %v", err)
+	}
+	if updated == first {
+		t.Fatal("Expected a new index after the update")
+	}
+	if hits := updated.Search("trackball", 10); len(hits) != 1 || hits[0].Item.ID != "mouse-2" {
+		t.Errorf("Expected the renamed mouse-2, got %v", hits)
+	}
+}
@@ -0,0 +1,200 @@
+package data
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"go.uber.org/zap"
+)
+
+// PriceTrendWindow is the period covered by the derived price trend fields
+const PriceTrendWindow = 30 * 24 * time.Hour
+
+// PriceHistory records the price of every item each time a catalog version is published.
+// A point is only stored when the price changes, so the history stays small.
+type PriceHistory struct {
+	logger    *zap.Logger
+	filePath  string        // Optional JSON file that survives restarts
+	retention time.Duration // Points older than this are pruned (the last one is kept as baseline)
+
+	mu     sync.RWMutex
+	points map[string][]domain.PricePoint // Item ID → points, oldest first
+}
+
+// NewPriceHistory creates the history, restoring it from filePath when the file exists
+func NewPriceHistory(filePath string, retention time.Duration, logger *zap.Logger) (*PriceHistory, error) {
+	history := &PriceHistory{
+		logger:    logger,
+		filePath:  filePath,
+		retention: retention,
+		points:    make(map[string][]domain.PricePoint),
+	}
+
+	if filePath == "" {
+		return history, nil
+	}
+
+	content, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read price history: %w", err)
+	}
+	if err := json.Unmarshal(content, &history.points); err != nil {
+		return nil, fmt.Errorf("failed to parse price history: %w", err)
+	}
+
+	logger.Info("price history restored",
+		zap.String("file", filePath),
+		zap.Int("items", len(history.points)),
+	)
+
+	return history, nil
+}
+
+// Record stores the prices of a snapshot; it is registered with VersionedCatalog.OnPublish
+func (h *PriceHistory) Record(snapshot *Snapshot) {
+	recordedAt := snapshot.Version.CreatedAt
+	changed := 0
+
+	h.mu.Lock()
+	for _, id := range snapshot.ids {
+		price := snapshot.items[id].Price
+		points := h.points[id]
+
+		if n := len(points); n > 0 && points[n-1].Price == price {
+			continue
+		}
+		h.points[id] = append(points, domain.PricePoint{
+			Price:          price,
+			RecordedAt:     recordedAt,
+			CatalogVersion: snapshot.Version.ID,
+		})
+		changed++
+	}
+	h.prune(recordedAt)
+	h.mu.Unlock()
+
+	h.logger.Info("price history recorded",
+		zap.String("version", snapshot.Version.ID),
+		zap.Int("price_changes", changed),
+	)
+
+	if changed > 0 {
+		if err := h.save(); err != nil {
+			h.logger.Error("failed to persist price history", zap.Error(err))
+		}
+	}
+}
+
+// History returns the points of an item, oldest first
+func (h *PriceHistory) History(id string) ([]domain.PricePoint, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	points, exists := h.points[id]
+	if !exists {
+		return nil, false
+	}
+	return append([]domain.PricePoint(nil), points...), true
+}
+
+// Trend derives the price trend fields of an item priced at price at the given moment.
+// Points recorded after at are ignored, so pinned catalog versions get the trend they had.
+func (h *PriceHistory) Trend(id string, price float64, at time.Time) *domain.PriceTrend {
+	h.mu.RLock()
+	points := h.points[id]
+	h.mu.RUnlock()
+
+	windowStart := at.Add(-PriceTrendWindow)
+
+	// reference is the price in effect when the window started (or the first one seen after)
+	reference := price
+	low := price
+	hasReference := false
+
+	for _, point := range points {
+		if point.RecordedAt.After(at) {
+			break
+		}
+		if !point.RecordedAt.After(windowStart) {
+			reference = point.Price
+			low = point.Price
+			hasReference = true
+			continue
+		}
+		if !hasReference {
+			reference = point.Price
+			hasReference = true
+		}
+		low = math.Min(low, point.Price)
+	}
+	low = math.Min(low, price)
+
+	changePct := 0.0
+	if reference > 0 {
+		changePct = math.Round((price-reference)/reference*10000) / 100
+	}
+
+	return &domain.PriceTrend{
+		Low30d:    low,
+		ChangePct: changePct,
+		AtLowest:  price <= low,
+	}
+}
+
+// prune drops the points older than the retention, keeping the newest of them as the
+// baseline of the window. Must be called with the lock held.
+func (h *PriceHistory) prune(now time.Time) {
+	if h.retention <= 0 {
+		return
+	}
+	cutoff := now.Add(-h.retention)
+
+	for id, points := range h.points {
+		first := 0
+		for first+1 < len(points) && points[first+1].RecordedAt.Before(cutoff) {
+			first++
+		}
+		if first > 0 {
+			h.points[id] = append([]domain.PricePoint(nil), points[first:]...)
+		}
+	}
+}
+
+// save writes the history to a temp file and renames it, so a crash never leaves a partial file
+func (h *PriceHistory) save() error {
+	if h.filePath == "" {
+		return nil
+	}
+
+	h.mu.RLock()
+	content, err := json.Marshal(h.points)
+	h.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(h.filePath), ".price-history-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), h.filePath)
+}
@@ -0,0 +1,107 @@
+package data
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"go.uber.org/zap"
+)
+
+func recordPrices(t *testing.T, history *PriceHistory, at time.Time, prices map[string]float64) {
+	t.Helper()
+
+	items := []domain.Item{}
+	for id, price := range prices {
+		items = append(items, domain.Item{ID: id, Name: id, Price: price})
+	}
+	snapshot, err := NewSnapshot(items, at)
+	if err != nil {
+		t.Fatalf("NewSnapshot() error = %v", err)
+	}
+	history.Record(snapshot)
+}
+
+func TestPriceHistory_RecordsOnlyChanges(t *testing.T) {
+	history, _ := NewPriceHistory("", 0, zap.NewNop())
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	recordPrices(t, history, base, map[string]float64{"a": 10})
+	recordPrices(t, history, base.Add(time.Hour), map[string]float64{"a": 10})
+	recordPrices(t, history, base.Add(2*time.Hour), map[string]float64{"a": 8})
+
+	points, found := history.History("a")
+	if !found || len(points) != 2 {
+		t.Fatalf("Expected 2 points, got %v", points)
+	}
+	if points[1].Price != 8 || !points[1].RecordedAt.Equal(base.Add(2*time.Hour)) {
+		t.Errorf("Unexpected last point %+v", points[1])
+	}
+}
+
+func TestPriceHistory_Trend(t *testing.T) {
+	history, _ := NewPriceHistory("", 0, zap.NewNop())
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	// 100 before the window, then 80, 120 and finally 90
+	recordPrices(t, history, now.Add(-40*day), map[string]float64{"a": 100})
+	recordPrices(t, history, now.Add(-20*day), map[string]float64{"a": 80})
+	recordPrices(t, history, now.Add(-10*day), map[string]float64{"a": 120})
+	recordPrices(t, history, now.Add(-day), map[string]float64{"a": 90})
+
+	trend := history.Trend("a", 90, now)
+	expected := domain.PriceTrend{Low30d: 80, ChangePct: -10, AtLowest: false}
+	if *trend != expected {
+		t.Errorf("Trend() = %+v, want %+v", *trend, expected)
+	}
+
+	// As of 15 days ago, 80 was the current low
+	past := history.Trend("a", 80, now.Add(-15*day))
+	if !past.AtLowest || past.ChangePct != -20 {
+		t.Errorf("Expected past trend at lowest with -20%%, got %+v", *past)
+	}
+
+	// Unknown items have a flat trend
+	flat := history.Trend("unknown", 50, now)
+	if flat.Low30d != 50 || flat.ChangePct != 0 || !flat.AtLowest {
+		t.Errorf("Expected flat trend, got %+v", *flat)
+	}
+}
+
+func TestPriceHistory_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "price-history.json")
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	history, err := NewPriceHistory(path, 0, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewPriceHistory() error = %v", err)
+	}
+	recordPrices(t, history, base, map[string]float64{"a": 10, "b": 20})
+
+	restored, err := NewPriceHistory(path, 0, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewPriceHistory() error = %v", err)
+	}
+	points, found := restored.History("b")
+	if !found || len(points) != 1 || points[0].Price != 20 {
+		t.Errorf("Expected restored history for b, got %v", points)
+	}
+}
+
+func TestPriceHistory_Prune(t *testing.T) {
+	history, _ := NewPriceHistory("", 30*24*time.Hour, zap.NewNop())
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	recordPrices(t, history, base, map[string]float64{"a": 10})
+	recordPrices(t, history, base.Add(10*day), map[string]float64{"a": 11})
+	recordPrices(t, history, base.Add(50*day), map[string]float64{"a": 12})
+
+	// The point of day 0 is dropped; day 10 stays as the baseline of the window
+	points, _ := history.History("a")
+	if len(points) != 2 || points[0].Price != 11 {
+		t.Errorf("Expected baseline 11 and current 12, got %v", points)
+	}
+}
@@ -0,0 +1,259 @@
+package data
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+)
+
+// Weight of a term by the field it appears in: a hit in the name matters more than in the description
+const (
+	nameFieldWeight        = 3.0
+	descriptionFieldWeight = 1.0
+)
+
+// Score factor by match type, relative to an exact term match
+const (
+	prefixMatchFactor = 0.7
+	typoMatchFactor   = 0.5
+)
+
+// Searchable is implemented by the repositories that keep a full-text index of the catalog
+type Searchable interface {
+	// SearchIndex returns the index of the live catalog, or an error when the catalog could not be read
+	SearchIndex(ctx context.Context) (*SearchIndex, error)
+}
+
+// posting is the occurrence of a term in one item
+type posting struct {
+	doc    int     // Position in SearchIndex.items
+	weight float64 // Sum of the field weights of every occurrence
+}
+
+// SearchIndex is an immutable inverted index over the name and description of the items.
+// It keeps its own copy of the items, so a search always reads one consistent catalog.
+type SearchIndex struct {
+	items      []domain.Item
+	postings   map[string][]posting
+	vocabulary []string               // Sorted terms, for prefix lookups
+	version    *domain.CatalogVersion // Catalog version the index was built from (nil when unknown)
+}
+
+// NewSearchIndex builds the index of the given items
+func NewSearchIndex(items []domain.Item) *SearchIndex {
+	index := &SearchIndex{
+		items:    items,
+		postings: make(map[string][]posting),
+	}
+
+	for doc, item := range items {
+		// Translations are indexed too, so shoppers can search in their language.
+		// A term shared by several languages of a field counts as in the language that repeats it most.
+		names, descriptions := []string{item.Name}, []string{item.Description}
+		for _, translation := range item.Translations {
+			names = append(names, translation.Name)
+			descriptions = append(descriptions, translation.Description)
+		}
+
+		weights := make(map[string]float64)
+		for term, count := range fieldTerms(names) {
+			weights[term] += nameFieldWeight * float64(count)
+		}
+		for term, count := range fieldTerms(descriptions) {
+			weights[term] += descriptionFieldWeight * float64(count)
+		}
+		for term, weight := range weights {
+			index.postings[term] = append(index.postings[term], posting{doc: doc, weight: weight})
+		}
+	}
+
+	index.vocabulary = make([]string, 0, len(index.postings))
+	for term := range index.postings {
+		index.vocabulary = append(index.vocabulary, term)
+	}
+	sort.Strings(index.vocabulary)
+
+	return index
+}
+
+// fieldTerms counts the terms of the texts of a field (the same field in several languages),
+// keeping for every term its highest count in a single text
+func fieldTerms(texts []string) map[string]int {
+	terms := make(map[string]int)
+	for _, text := range texts {
+		counts := make(map[string]int)
+		for _, term := range tokenize(text) {
+			counts[term]++
+		}
+		for term, count := range counts {
+			terms[term] = max(terms[term], count)
+		}
+	}
+	return terms
+}
+
+// Version returns the catalog version the index was built from, nil when unknown
+func (idx *SearchIndex) Version() *domain.CatalogVersion {
+	return idx.version
+}
+
+// Len returns the number of indexed items
+func (idx *SearchIndex) Len() int {
+	return len(idx.items)
+}
+
+// Search returns the items that match every term of the query, best first.
+// Each query term matches index terms exactly, as a prefix or with a small typo.
+func (idx *SearchIndex) Search(query string, limit int) []domain.SearchHit {
+	terms := tokenize(query)
+	if len(terms) == 0 {
+		return []domain.SearchHit{}
+	}
+
+	// === STEP 1: Score every query term independently ===
+	var scores map[int]float64
+	for _, term := range terms {
+		termScores := idx.scoreTerm(term)
+
+		// Every term must match: keep only the items matched by all of them
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		for doc := range scores {
+			if termScore, matched := termScores[doc]; matched {
+				scores[doc] += termScore
+			} else {
+				delete(scores, doc)
+			}
+		}
+	}
+
+	// === STEP 2: Rank ===
+	hits := make([]domain.SearchHit, 0, len(scores))
+	for doc, score := range scores {
+		hits = append(hits, domain.SearchHit{
+			Item:  idx.items[doc],
+			Score: math.Round(score*1000) / 1000,
+		})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if hits[i].Item.Rating != hits[j].Item.Rating {
+			return hits[i].Item.Rating > hits[j].Item.Rating
+		}
+		return hits[i].Item.ID < hits[j].Item.ID
+	})
+
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// scoreTerm returns the best score of a query term for every item it matches
+func (idx *SearchIndex) scoreTerm(term string) map[int]float64 {
+	scores := make(map[int]float64)
+
+	add := func(indexTerm string, factor float64) {
+		postings := idx.postings[indexTerm]
+		// Rare terms discriminate better than common ones
+		idf := math.Log(1 + float64(len(idx.items))/float64(len(postings)))
+		for _, p := range postings {
+			if score := p.weight * idf * factor; score > scores[p.doc] {
+				scores[p.doc] = score
+			}
+		}
+	}
+
+	// Exact match
+	if _, exists := idx.postings[term]; exists {
+		add(term, 1)
+	}
+
+	// Prefix match (the user is still typing)
+	if len([]rune(term)) >= 2 {
+		first := sort.SearchStrings(idx.vocabulary, term)
+		for i := first; i < len(idx.vocabulary) && strings.HasPrefix(idx.vocabulary[i], term); i++ {
+			if idx.vocabulary[i] != term {
+				add(idx.vocabulary[i], prefixMatchFactor)
+			}
+		}
+	}
+
+	// Typo tolerance
+	if maxEdits := allowedTypos(term); maxEdits > 0 {
+		for _, indexTerm := range idx.vocabulary {
+			if indexTerm != term && withinEditDistance(term, indexTerm, maxEdits) {
+				add(indexTerm, typoMatchFactor)
+			}
+		}
+	}
+
+	return scores
+}
+
+// tokenize splits a text into lowercase terms of letters and digits
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// allowedTypos returns the edits tolerated for a query term: none for short terms,
+// where a single edit changes the word, one from 4 characters and two from 8
+func allowedTypos(term string) int {
+	switch n := len([]rune(term)); {
+	case n >= 8:
+		return 2
+	case n >= 4:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// withinEditDistance reports whether a and b differ by at most max insertions, deletions,
+// substitutions or transpositions of adjacent characters
+func withinEditDistance(a, b string, max int) bool {
+	ra, rb := []rune(a), []rune(b)
+	if diff := len(ra) - len(rb); diff > max || -diff > max {
+		return false
+	}
+
+	// Three rows of the optimal string alignment matrix
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > max {
+			return false
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+
+	return prev[len(rb)] <= max
+}
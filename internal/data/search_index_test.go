@@ -0,0 +1,95 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+)
+
+func searchCatalog() []domain.Item {
+	return []domain.Item{
+		{ID: "1", Name: "Pro Mouse Logitech 3", Description: "High-precision gaming mouse with 6 buttons.", Rating: 4.8},
+		{ID: "2", Name: "Pro Mouse HP 2", Description: "High-precision gaming mouse with 2 buttons.", Rating: 4.0},
+		{ID: "3", Name: "Logitech Keyboard K380", Description: "Compact wireless keyboard.", Rating: 4.5},
+		{ID: "4", Name: "UltraView 34 LG", Description: "34\" monitor, works great with a mouse.", Rating: 4.6},
+	}
+}
+
+func hitIDs(hits []domain.SearchHit) []string {
+	ids := make([]string, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.Item.ID
+	}
+	return ids
+}
+
+func TestSearchIndex_Search(t *testing.T) {
+	index := NewSearchIndex(searchCatalog())
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{name: "All terms must match", query: "logitech mouse", expected: []string{"1"}},
+		{name: "Case and punctuation are ignored", query: "LOGITECH, Mouse!", expected: []string{"1"}},
+		{name: "Name matches rank above description matches", query: "mouse", expected: []string{"1", "2", "4"}},
+		{name: "Prefix match", query: "logi", expected: []string{"1", "3"}},
+		{name: "Typo tolerance", query: "logitec mosue", expected: []string{"1"}},
+		{name: "Short terms are not fuzzy", query: "hq", expected: []string{}},
+		{name: "No match", query: "headphones", expected: []string{}},
+		{name: "Empty query", query: "  ", expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := hitIDs(index.Search(tt.query, 0))
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Search(%q) = %v, want %v", tt.query, got, tt.expected)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.expected)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestSearchIndex_ExactMatchScoresHigher(t *testing.T) {
+	index := NewSearchIndex([]domain.Item{
+		{ID: "a", Name: "Keyboard"},
+		{ID: "b", Name: "Keyboards"},
+	})
+
+	hits := index.Search("keyboard", 0)
+	if len(hits) != 2 || hits[0].Item.ID != "a" || hits[0].Score <= hits[1].Score {
+		t.Errorf("Expected the exact match first with a higher score, got %v", hits)
+	}
+
+	if limited := index.Search("keyboard", 1); len(limited) != 1 {
+		t.Errorf("Expected the limit to cap the hits, got %d", len(limited))
+	}
+}
+
+func TestWithinEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		max      int
+		expected bool
+	}{
+		{a: "mouse", b: "mouse", max: 0, expected: true},
+		{a: "mosue", b: "mouse", max: 1, expected: true},
+		{a: "mous", b: "mouse", max: 1, expected: true},
+		{a: "moose", b: "mouse", max: 1, expected: true},
+		{a: "mice", b: "mouse", max: 1, expected: false},
+		{a: "keybaord", b: "keyboards", max: 2, expected: true},
+	}
+
+	for _, tt := range tests {
+		if got := withinEditDistance(tt.a, tt.b, tt.max); got != tt.expected {
+			t.Errorf("withinEditDistance(%q, %q, %d) = %v, want %v", tt.a, tt.b, tt.max, got, tt.expected)
+		}
+	}
+}
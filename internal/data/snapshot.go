@@ -0,0 +1,170 @@
+package data
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+)
+
+// Snapshot is an immutable load of the catalog. It implements CatalogRepository,
+// so a past version can be compared exactly like the live catalog.
+type Snapshot struct {
+	Version domain.CatalogVersion
+
+	items      map[string]domain.Item
+	ids        []string          // Sorted IDs, gives GetAll a stable order
+	itemHashes map[string]string // ID → content hash of the item
+	orphans    []domain.Item     // Variants left out because their parent is not a top-level item
+}
+
+// NewSnapshot resolves the variants, indexes the items and computes the content hash of the whole catalog
+func NewSnapshot(items []domain.Item, createdAt time.Time) (*Snapshot, error) {
+	items, orphans := ResolveVariants(items)
+
+	snapshot := &Snapshot{
+		items:      make(map[string]domain.Item, len(items)),
+		ids:        make([]string, 0, len(items)),
+		itemHashes: make(map[string]string, len(items)),
+		orphans:    orphans,
+	}
+
+	for _, item := range items {
+		itemHash, err := hashItem(item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash item %s: %w", item.ID, err)
+		}
+		if _, exists := snapshot.items[item.ID]; !exists {
+			snapshot.ids = append(snapshot.ids, item.ID)
+		}
+		snapshot.items[item.ID] = item
+		snapshot.itemHashes[item.ID] = itemHash
+	}
+	sort.Strings(snapshot.ids)
+
+	// The catalog hash only depends on the content, not on the order of the feed
+	catalogHash := sha256.New()
+	for _, id := range snapshot.ids {
+		catalogHash.Write([]byte(id))
+		catalogHash.Write([]byte{0})
+		catalogHash.Write([]byte(snapshot.itemHashes[id]))
+		catalogHash.Write([]byte{'\n'})
+	}
+	contentHash := hex.EncodeToString(catalogHash.Sum(nil))
+
+	createdAt = createdAt.UTC()
+	snapshot.Version = domain.CatalogVersion{
+		ID:          createdAt.Format("20060102T150405Z") + "-" + contentHash[:8],
+		CreatedAt:   createdAt,
+		ContentHash: contentHash,
+		ItemCount:   len(snapshot.ids),
+	}
+
+	return snapshot, nil
+}
+
+// GetByIDs implements CatalogRepository.GetByIDs
+func (s *Snapshot) GetByIDs(ctx context.Context, ids []string) ([]domain.Item, []string, error) {
+	found := make([]domain.Item, 0, len(ids))
+	missing := make([]string, 0)
+
+	// Maintain the order of the requested IDs
+	for _, id := range ids {
+		if item, exists := s.items[id]; exists {
+			found = append(found, item)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+
+	return found, missing, nil
+}
+
+// GetAll implements CatalogRepository.GetAll. Items are sorted by ID.
+func (s *Snapshot) GetAll(ctx context.Context) ([]domain.Item, error) {
+	return s.all(), nil
+}
+
+// all returns the items of the snapshot, sorted by ID
+func (s *Snapshot) all() []domain.Item {
+	items := make([]domain.Item, 0, len(s.ids))
+	for _, id := range s.ids {
+		items = append(items, s.items[id])
+	}
+	return items
+}
+
+// GetVariants implements CatalogRepository.GetVariants
+func (s *Snapshot) GetVariants(ctx context.Context, id string) ([]domain.Item, bool, error) {
+	parent, exists := s.items[id]
+	if !exists || len(parent.Variants) == 0 {
+		return nil, false, nil
+	}
+
+	variants := make([]domain.Item, 0, len(parent.Variants))
+	for _, variantID := range parent.Variants {
+		variants = append(variants, s.items[variantID])
+	}
+	return variants, true, nil
+}
+
+// Get returns a single item of the snapshot
+func (s *Snapshot) Get(id string) (domain.Item, bool) {
+	item, exists := s.items[id]
+	return item, exists
+}
+
+// Len returns the number of items in the snapshot
+func (s *Snapshot) Len() int {
+	return len(s.ids)
+}
+
+// Orphans returns the variants left out of the snapshot because of their parent
+func (s *Snapshot) Orphans() []domain.Item {
+	return s.orphans
+}
+
+// hashItem hashes the canonical JSON of an item (map keys are always encoded sorted)
+func hashItem(item domain.Item) (string, error) {
+	encoded, err := json.Marshal(item)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// DiffSnapshots compares the item content hashes of two snapshots.
+// The ID lists are sorted, because the snapshot IDs are.
+func DiffSnapshots(previous, current *Snapshot) domain.CatalogDiff {
+	diff := domain.CatalogDiff{
+		FromVersion: previous.Version.ID,
+		ToVersion:   current.Version.ID,
+		CreatedAt:   current.Version.CreatedAt,
+		Added:       []string{},
+		Removed:     []string{},
+		Changed:     []string{},
+	}
+
+	for _, id := range current.ids {
+		previousHash, existed := previous.itemHashes[id]
+		switch {
+		case !existed:
+			diff.Added = append(diff.Added, id)
+		case previousHash != current.itemHashes[id]:
+			diff.Changed = append(diff.Changed, id)
+		}
+	}
+	for _, id := range previous.ids {
+		if _, exists := current.itemHashes[id]; !exists {
+			diff.Removed = append(diff.Removed, id)
+		}
+	}
+
+	return diff
+}
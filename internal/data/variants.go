@@ -0,0 +1,48 @@
+package data
+
+import (
+	"sort"
+
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+)
+
+// ResolveVariants materializes the parent/variant hierarchy of a catalog load.
+// Variants inherit the fields of their parent they leave empty (see domain.Item.InheritFrom)
+// and parents list their variant IDs, sorted. Variants only hang from top-level items:
+// a variant whose parent is missing or is itself a variant is returned as an orphan
+// and left out of the resolved items. The order of the input is kept.
+func ResolveVariants(items []domain.Item) (resolved []domain.Item, orphans []domain.Item) {
+	parents := make(map[string]domain.Item)
+	for _, item := range items {
+		if !item.IsVariant() {
+			parents[item.ID] = item
+		}
+	}
+
+	variantIDs := make(map[string][]string)
+	resolved = make([]domain.Item, 0, len(items))
+	for _, item := range items {
+		item.Variants = nil // Derived, never taken from the source
+		if !item.IsVariant() {
+			resolved = append(resolved, item)
+			continue
+		}
+
+		parent, exists := parents[item.ParentID]
+		if !exists {
+			orphans = append(orphans, item)
+			continue
+		}
+		variantIDs[parent.ID] = append(variantIDs[parent.ID], item.ID)
+		resolved = append(resolved, item.InheritFrom(parent))
+	}
+
+	for i, item := range resolved {
+		if ids, isParent := variantIDs[item.ID]; isParent {
+			sort.Strings(ids)
+			resolved[i].Variants = ids
+		}
+	}
+
+	return resolved, orphans
+}
@@ -0,0 +1,122 @@
+package data
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+)
+
+func variantCatalog() []domain.Item {
+	return []domain.Item{
+		{ID: "m-black", ParentID: "m", Specifications: map[string]interface{}{"color": "black"}},
+		{
+			ID: "m", Name: "Mouse", Description: "Wireless mouse", Price: 25, Rating: 4.5,
+			Specifications: map[string]interface{}{"color": "white", "wireless": true},
+			Translations:   map[string]domain.ItemTranslation{"es": {Name: "Ratón", Description: "Ratón inalámbrico"}},
+		},
+		{ID: "m-pro", ParentID: "m", Name: "Mouse Pro", Price: 40, Specifications: map[string]interface{}{"buttons": 8.0}},
+		{ID: "k", Name: "Keyboard", Price: 80},
+		{ID: "orphan", ParentID: "missing"},
+		{ID: "nested", ParentID: "m-black"},
+	}
+}
+
+func TestResolveVariants(t *testing.T) {
+	resolved, orphans := ResolveVariants(variantCatalog())
+
+	if len(resolved) != 4 {
+		t.Fatalf("Expected 4 resolved items, got %d", len(resolved))
+	}
+	// Missing parents and variants of variants are left out
+	if len(orphans) != 2 || orphans[0].ID != "orphan" || orphans[1].ID != "nested" {
+		t.Errorf("Expected orphans [orphan nested], got %v", orphans)
+	}
+
+	byID := make(map[string]domain.Item)
+	for _, item := range resolved {
+		byID[item.ID] = item
+	}
+
+	// Empty fields come from the parent, specifications are merged
+	black := byID["m-black"]
+	if black.Name != "Mouse" || black.Description != "Wireless mouse" || black.Price != 25 || black.Rating != 4.5 {
+		t.Errorf("Expected inherited fields, got %+v", black)
+	}
+	expectedSpecs := map[string]interface{}{"color": "black", "wireless": true}
+	if !reflect.DeepEqual(black.Specifications, expectedSpecs) {
+		t.Errorf("Specifications = %v, want %v", black.Specifications, expectedSpecs)
+	}
+
+	// Overridden fields are kept
+	pro := byID["m-pro"]
+	if pro.Name != "Mouse Pro" || pro.Price != 40 || pro.Rating != 4.5 {
+		t.Errorf("Expected overrides to win, got %+v", pro)
+	}
+	if pro.Specifications["color"] != "white" || pro.Specifications["buttons"] != 8.0 {
+		t.Errorf("Expected merged specifications, got %v", pro.Specifications)
+	}
+
+	// Translations are inherited along with the base field they translate
+	if black.Translations["es"] != (domain.ItemTranslation{Name: "Ratón", Description: "Ratón inalámbrico"}) {
+		t.Errorf("Expected inherited translations, got %v", black.Translations)
+	}
+	if pro.Translations["es"] != (domain.ItemTranslation{Description: "Ratón inalámbrico"}) {
+		t.Errorf("Expected only the description translation for an overridden name, got %v", pro.Translations)
+	}
+
+	// The parent lists its variants and keeps its own specifications
+	parent := byID["m"]
+	if !reflect.DeepEqual(parent.Variants, []string{"m-black", "m-pro"}) {
+		t.Errorf("Variants = %v, want [m-black m-pro]", parent.Variants)
+	}
+	if parent.Specifications["color"] != "white" {
+		t.Errorf("Expected the parent specifications untouched, got %v", parent.Specifications)
+	}
+	if byID["k"].Variants != nil {
+		t.Errorf("Expected no variants for a plain item, got %v", byID["k"].Variants)
+	}
+}
+
+func TestSnapshot_GetVariants(t *testing.T) {
+	snapshot, err := NewSnapshot(variantCatalog(), time.Now())
+	if err != nil {
+		t.Fatalf("NewSnapshot() error = %v", err)
+	}
+	ctx := context.Background()
+
+	variants, isParent, _ := snapshot.GetVariants(ctx, "m")
+	if !isParent || len(variants) != 2 || variants[0].ID != "m-black" || variants[1].ID != "m-pro" {
+		t.Errorf("Expected variants [m-black m-pro], got %v (parent %v)", variants, isParent)
+	}
+	for _, id := range []string{"k", "m-black", "unknown"} {
+		if _, isParent, _ := snapshot.GetVariants(ctx, id); isParent {
+			t.Errorf("Expected %s not to be a parent", id)
+		}
+	}
+
+	if snapshot.Len() != 4 || len(snapshot.Orphans()) != 2 {
+		t.Errorf("Expected 4 items and 2 orphans, got %d and %d", snapshot.Len(), len(snapshot.Orphans()))
+	}
+}
+
+func TestDiffSnapshots_ParentChangeAffectsVariants(t *testing.T) {
+	previous, err := NewSnapshot(variantCatalog(), time.Now())
+	if err != nil {
+		t.Fatalf("NewSnapshot() error = %v", err)
+	}
+
+	items := variantCatalog()
+	items[1].Price = 20 // The parent: m-black inherits the price, m-pro overrides it
+	current, err := NewSnapshot(items, time.Now())
+	if err != nil {
+		t.Fatalf("NewSnapshot() error = %v", err)
+	}
+
+	diff := DiffSnapshots(previous, current)
+	if !reflect.DeepEqual(diff.Changed, []string{"m", "m-black"}) {
+		t.Errorf("Changed = %v, want [m m-black]", diff.Changed)
+	}
+}
@@ -0,0 +1,56 @@
+package domain
+
+import "time"
+
+// AvailabilityStatus es el estado de disponibilidad de un item
+type AvailabilityStatus string
+
+const (
+	InStock      AvailabilityStatus = "in_stock"
+	Backorder    AvailabilityStatus = "backorder"    // Se puede pedir; se envía en EstimatedShipDate
+	Discontinued AvailabilityStatus = "discontinued" // Ya no se vende
+)
+
+// ShipDateLayout es el formato de EstimatedShipDate (solo fecha)
+const ShipDateLayout = "2006-01-02"
+
+// IsValid indica si el estado es conocido
+func (s AvailabilityStatus) IsValid() bool {
+	switch s {
+	case InStock, Backorder, Discontinued:
+		return true
+	default:
+		return false
+	}
+}
+
+// Availability describe la disponibilidad de un item
+type Availability struct {
+	Status            AvailabilityStatus `json:"status"`
+	EstimatedShipDate string             `json:"estimated_ship_date,omitempty"` // YYYY-MM-DD, solo en backorder
+}
+
+// ValidShipDate indica si date es una fecha con formato ShipDateLayout
+func ValidShipDate(date string) bool {
+	_, err := time.Parse(ShipDateLayout, date)
+	return err == nil
+}
+
+// UnavailablePolicy define qué hace la comparación con los items no disponibles (descontinuados)
+type UnavailablePolicy string
+
+const (
+	UnavailableFlag    UnavailablePolicy = "flag"    // Se comparan, pero nunca son "best"; genera un warning
+	UnavailableExclude UnavailablePolicy = "exclude" // Se quitan de la comparación; genera un warning
+	UnavailableInclude UnavailablePolicy = "include" // Se comparan como cualquier otro item
+)
+
+// IsValid indica si la política es soportada
+func (p UnavailablePolicy) IsValid() bool {
+	switch p {
+	case UnavailableFlag, UnavailableExclude, UnavailableInclude:
+		return true
+	default:
+		return false
+	}
+}
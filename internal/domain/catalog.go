@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// CatalogVersion identifica una carga inmutable del catálogo
+type CatalogVersion struct {
+	ID          string    `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	ContentHash string    `json:"content_hash"`
+	ItemCount   int       `json:"item_count"`
+}
+
+// CatalogDiff describe los items que cambiaron entre dos versiones del catálogo
+type CatalogDiff struct {
+	FromVersion string    `json:"from_version"`
+	ToVersion   string    `json:"to_version"`
+	CreatedAt   time.Time `json:"created_at"` // Momento en que se publicó ToVersion
+	Added       []string  `json:"added"`
+	Removed     []string  `json:"removed"`
+	Changed     []string  `json:"changed"` // Mismo ID con contenido distinto
+}
+
+// AffectedIDs retorna todos los IDs agregados, eliminados o modificados
+func (d CatalogDiff) AffectedIDs() []string {
+	ids := make([]string, 0, len(d.Added)+len(d.Removed)+len(d.Changed))
+	ids = append(ids, d.Added...)
+	ids = append(ids, d.Removed...)
+	return append(ids, d.Changed...)
+}
@@ -1,9 +1,78 @@
 package domain
 
-// CompareRequest representa la solicitud de comparación de productos
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// CompareRequest representa la solicitud de comparación de productos.
+// Un ID de un producto padre se expande a todas sus variantes.
+// CatalogVersion y AsOf (excluyentes) fijan la versión del catálogo contra la que se compara.
+// Los precios se convierten a Currency antes de calcular las diferencias.
+// Unavailable define el trato de los items descontinuados (por defecto UnavailableFlag).
 type CompareRequest struct {
-	Ids    []string  `json:"ids" binding:"required,min=1"`
-	Fields *[]string `json:"fields,omitempty"`
+	Ids            []string          `json:"ids" binding:"required,min=1"`
+	Fields         *[]string         `json:"fields,omitempty"`
+	CatalogVersion *string           `json:"catalog_version,omitempty"`
+	AsOf           *time.Time        `json:"as_of,omitempty"`
+	Currency       string            `json:"currency,omitempty"` // Moneda de la respuesta; vacía usa la moneda de la tienda
+	Unavailable    UnavailablePolicy `json:"unavailable,omitempty"`
+}
+
+// PinsCatalog indica si la solicitud pide una versión del catálogo distinta a la actual
+func (r CompareRequest) PinsCatalog() bool {
+	return r.CatalogVersion != nil || r.AsOf != nil
+}
+
+// Canonical retorna la forma canónica de la solicitud: IDs sin vacíos ni duplicados y ordenados,
+// campos canónicos (ver CanonicalFields), moneda normalizada y la política de no disponibles por
+// defecto explícita. Las solicitudes equivalentes ("a,b" y "b,a", "usd" y "USD") comparten forma.
+// Una moneda inválida se conserva tal cual, para que siga fallando.
+func (r CompareRequest) Canonical() CompareRequest {
+	seen := make(map[string]bool, len(r.Ids))
+	ids := make([]string, 0, len(r.Ids))
+	for _, id := range r.Ids {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	canonical := r
+	canonical.Ids = ids
+	canonical.Fields = CanonicalFields(r.Fields)
+	if currency := NormalizeCurrency(r.Currency); currency != "" {
+		canonical.Currency = currency
+	}
+	if canonical.Unavailable == "" {
+		canonical.Unavailable = UnavailableFlag
+	}
+	return canonical
+}
+
+// CanonicalFields recorta, elimina duplicados y ordena los campos pedidos; nil si no queda ninguno
+func CanonicalFields(fields *[]string) *[]string {
+	if fields == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(*fields))
+	canonical := make([]string, 0, len(*fields))
+	for _, field := range *fields {
+		field = strings.TrimSpace(field)
+		if field != "" && !seen[field] {
+			seen[field] = true
+			canonical = append(canonical, field)
+		}
+	}
+	if len(canonical) == 0 {
+		return nil
+	}
+
+	sort.Strings(canonical)
+	return &canonical
 }
 
 // Metric define el tipo de métrica para la comparación de campos
@@ -31,17 +100,37 @@ type CompareResult struct {
 
 // ComparePolicy contiene la configuración de la comparación aplicada
 type ComparePolicy struct {
-	EffectiveMode      string   `json:"effective_mode"`
-	ComparabilityScore float64  `json:"comparability_score"`
-	Warnings           []string `json:"warnings,omitempty"`
+	EffectiveMode      string    `json:"effective_mode"`
+	ComparabilityScore float64   `json:"comparability_score"`
+	Warnings           []Warning `json:"warnings,omitempty"`
+}
+
+// WarningCode identifica un aviso de la comparación, que no impide responder
+type WarningCode string
+
+const (
+	WarningUnavailableItems         WarningCode = "UnavailableItems"         // Items no disponibles que nunca son "best"
+	WarningUnavailableItemsExcluded WarningCode = "UnavailableItemsExcluded" // Items no disponibles quitados de la comparación
+)
+
+// Warning representa un aviso de la comparación y los items a los que afecta
+type Warning struct {
+	Code    WarningCode `json:"code"`
+	Message string      `json:"message"`
+	Items   []string    `json:"items,omitempty"`
 }
 
 // Metadata contiene metadatos adicionales de la comparación
 type Metadata struct {
-	Order           []string      `json:"order"`
-	RequestedFields *[]string     `json:"requested_fields,omitempty"`
-	ResolvedFields  []string      `json:"resolved_fields"`
-	ComparePolicy   ComparePolicy `json:"compare_policy"`
-	Currency        string        `json:"currency"`
-	Version         string        `json:"version"`
+	Order           []string            `json:"order"`
+	RequestedFields *[]string           `json:"requested_fields,omitempty"`
+	ResolvedFields  []string            `json:"resolved_fields"`
+	ComparePolicy   ComparePolicy       `json:"compare_policy"`
+	Currency        string              `json:"currency"`
+	Conversion      *CurrencyConversion `json:"conversion,omitempty"` // Solo si algún precio se convirtió
+	Version         string              `json:"version"`
+	CatalogVersion  *CatalogVersion     `json:"catalog_version,omitempty"`
+	Variants        map[string][]string `json:"variants,omitempty"` // Padre solicitado → variantes en las que se expandió
+	Summary         string              `json:"summary,omitempty"`  // Resumen legible, traducido al idioma de la respuesta
+	Locale          string              `json:"locale,omitempty"`   // Idioma negociado con Accept-Language
 }
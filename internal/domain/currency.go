@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// Money representa un importe en una moneda (código ISO 4217, ej. "MXN")
+type Money struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+// ExchangeRates es una tabla de tipos de cambio: cuántas unidades de cada moneda vale una unidad de Base
+type ExchangeRates struct {
+	Base      string             `json:"base"`
+	UpdatedAt time.Time          `json:"updated_at"`
+	Rates     map[string]float64 `json:"rates"`
+}
+
+// Rate retorna el tipo de cambio para convertir de from a to, pasando por la moneda base
+func (r *ExchangeRates) Rate(from, to string) (float64, bool) {
+	if from == to {
+		return 1, true
+	}
+	fromRate, fromOK := r.rateOf(from)
+	toRate, toOK := r.rateOf(to)
+	if !fromOK || !toOK {
+		return 0, false
+	}
+	return toRate / fromRate, true
+}
+
+func (r *ExchangeRates) rateOf(currency string) (float64, bool) {
+	if currency == r.Base {
+		return 1, true
+	}
+	rate, exists := r.Rates[currency]
+	return rate, exists && rate > 0
+}
+
+// CurrencyConversion describe las conversiones aplicadas a una comparación
+type CurrencyConversion struct {
+	RatesUpdatedAt time.Time          `json:"rates_updated_at"` // Fecha de la tabla de tipos de cambio usada
+	Rates          map[string]float64 `json:"rates"`            // Moneda original → tipo de cambio aplicado
+}
+
+// NormalizeCurrency normaliza un código de moneda ("mxn" → "MXN"); retorna "" si no es un código ISO 4217 válido
+func NormalizeCurrency(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return ""
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return ""
+		}
+	}
+	return code
+}
+
+// RoundMoney redondea un importe convertido a centavos
+func RoundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
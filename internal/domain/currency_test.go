@@ -0,0 +1,26 @@
+package domain
+
+import "testing"
+
+func TestExchangeRates_Rate(t *testing.T) {
+	rates := &ExchangeRates{Base: "USD", Rates: map[string]float64{"MXN": 20, "BRL": 5}}
+
+	tests := []struct {
+		from, to string
+		expected float64
+		ok       bool
+	}{
+		{from: "USD", to: "USD", expected: 1, ok: true},
+		{from: "USD", to: "MXN", expected: 20, ok: true},
+		{from: "MXN", to: "USD", expected: 0.05, ok: true},
+		{from: "MXN", to: "BRL", expected: 0.25, ok: true},
+		{from: "EUR", to: "USD", ok: false},
+	}
+
+	for _, tt := range tests {
+		rate, ok := rates.Rate(tt.from, tt.to)
+		if ok != tt.ok || rate != tt.expected {
+			t.Errorf("Rate(%s, %s) = %v, %v; want %v, %v", tt.from, tt.to, rate, ok, tt.expected, tt.ok)
+		}
+	}
+}
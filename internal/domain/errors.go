@@ -12,6 +12,14 @@ const (
 	ErrorCodeMissingField   ErrorCode = "MissingField"
 	ErrorCodeInvalidRequest ErrorCode = "InvalidRequest"
 	ErrorCodeConflict       ErrorCode = "Conflict"
+
+	ErrorCodeCatalogVersionNotFound ErrorCode = "CatalogVersionNotFound"
+	ErrorCodeUnknownTenant          ErrorCode = "UnknownTenant"
+	ErrorCodeUnsupportedCurrency    ErrorCode = "UnsupportedCurrency"
+	ErrorCodeUnknownCache           ErrorCode = "UnknownCache"
+	ErrorCodeUnauthorized           ErrorCode = "Unauthorized"
+
+	ErrorCodeInternal ErrorCode = "InternalError"
 )
 
 // ErrorResponse representa la respuesta de error de la API
@@ -25,9 +33,11 @@ type ErrorResponse struct {
 // HTTPStatusCode retorna el código HTTP apropiado para cada error
 func (e ErrorCode) HTTPStatusCode() int {
 	switch e {
-	case ErrorCodeIdNotFound:
+	case ErrorCodeIdNotFound, ErrorCodeCatalogVersionNotFound, ErrorCodeUnknownTenant, ErrorCodeUnknownCache:
 		return http.StatusNotFound
-	case ErrorCodeAtLeastTwoIds, ErrorCodeUnknownField:
+	case ErrorCodeUnauthorized:
+		return http.StatusUnauthorized
+	case ErrorCodeAtLeastTwoIds, ErrorCodeUnknownField, ErrorCodeUnsupportedCurrency:
 		return http.StatusUnprocessableEntity
 	case ErrorCodeMissingField, ErrorCodeInvalidRequest:
 		return http.StatusBadRequest
@@ -41,6 +41,31 @@ func TestErrorCode_HTTPStatusCode(t *testing.T) {
 			code:     ErrorCodeConflict,
 			expected: http.StatusConflict,
 		},
+		{
+			name:     "CatalogVersionNotFound returns 404",
+			code:     ErrorCodeCatalogVersionNotFound,
+			expected: http.StatusNotFound,
+		},
+		{
+			name:     "UnknownTenant returns 404",
+			code:     ErrorCodeUnknownTenant,
+			expected: http.StatusNotFound,
+		},
+		{
+			name:     "UnsupportedCurrency returns 422",
+			code:     ErrorCodeUnsupportedCurrency,
+			expected: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "UnknownCache returns 404",
+			code:     ErrorCodeUnknownCache,
+			expected: http.StatusNotFound,
+		},
+		{
+			name:     "Unauthorized returns 401",
+			code:     ErrorCodeUnauthorized,
+			expected: http.StatusUnauthorized,
+		},
 		{
 			name:     "Unknown error code returns 500",
 			code:     ErrorCode("UNKNOWN_CODE"),
@@ -0,0 +1,48 @@
+package domain
+
+// FacetType indica cómo se agregan los valores de una especificación
+type FacetType string
+
+const (
+	FacetTypeCategorical FacetType = "categorical" // Conteo por valor (ej. switch_type, wireless)
+	FacetTypeNumeric     FacetType = "numeric"     // Rango e histograma (ej. sensor_dpi, screen_size)
+)
+
+// FacetValue representa un valor de una faceta categórica y cuántos items lo tienen
+type FacetValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// FacetBucket representa un intervalo del histograma de una faceta numérica.
+// Los intervalos son [Min, Max) excepto el último, que incluye Max.
+type FacetBucket struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int     `json:"count"`
+}
+
+// Facet contiene la distribución de una especificación sobre un conjunto de items
+type Facet struct {
+	Field   string        `json:"field"` // Ruta usable en los filtros (ej. "specifications.wireless")
+	Type    FacetType     `json:"type"`
+	Unit    string        `json:"unit,omitempty"`
+	Count   int           `json:"count"` // Items que tienen la especificación
+	Values  []FacetValue  `json:"values,omitempty"`
+	Min     *float64      `json:"min,omitempty"`
+	Max     *float64      `json:"max,omitempty"`
+	Buckets []FacetBucket `json:"buckets,omitempty"`
+}
+
+// FacetQuery representa la solicitud de facetas
+type FacetQuery struct {
+	Filter  ItemFilter
+	Fields  []string // Especificaciones a incluir (vacío = todas)
+	Buckets int      // Intervalos de los histogramas numéricos
+}
+
+// FacetSet contiene las facetas de los items que cumplen el filtro
+type FacetSet struct {
+	Total  int     `json:"total"` // Items que cumplen el filtro
+	Facets []Facet `json:"facets"`
+}
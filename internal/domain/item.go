@@ -1,12 +1,200 @@
 package domain
 
+import "strings"
+
 // Item representa un producto en el catálogo
 type Item struct {
-	ID             string                 `json:"id"`
-	Name           string                 `json:"name"`
-	ImageURL       string                 `json:"image_url"`
-	Description    string                 `json:"description"`
-	Price          float64                `json:"price"`
-	Rating         float64                `json:"rating"`
-	Specifications map[string]interface{} `json:"specifications"`
+	ID             string                     `json:"id"`
+	Name           string                     `json:"name"`
+	ImageURL       string                     `json:"image_url"`
+	Description    string                     `json:"description"`
+	Price          float64                    `json:"price"`
+	Currency       string                     `json:"currency,omitempty"`       // Moneda del precio; vacía usa la moneda de la tienda
+	OriginalPrice  *Money                     `json:"original_price,omitempty"` // Precio antes de convertirlo a la moneda de la respuesta
+	Rating         float64                    `json:"rating"`
+	Availability   *Availability              `json:"availability,omitempty"` // Sin datos se considera disponible
+	Specifications map[string]interface{}     `json:"specifications"`
+	Translations   map[string]ItemTranslation `json:"translations,omitempty"` // Locale (ej. "es-MX") → contenido traducido
+	ParentID       string                     `json:"parent_id,omitempty"`    // Solo en variantes: el producto del que heredan
+	Variants       []string                   `json:"variants,omitempty"`     // Solo en padres: IDs de sus variantes, derivado del catálogo
+	PriceTrend     *PriceTrend                `json:"price_trend,omitempty"`  // Derivado del historial, no viene del catálogo
+}
+
+// ItemTranslation contiene el contenido de un item en un idioma.
+// Los campos vacíos usan el siguiente idioma de la cadena de fallback.
+type ItemTranslation struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Localize resuelve el nombre y la descripción con el primer idioma de locales que los traduce;
+// sin traducción se conserva el contenido base. El item resultante no incluye las traducciones.
+func (i Item) Localize(locales []string) Item {
+	name, description := "", ""
+	for _, locale := range locales {
+		translation := i.Translations[locale]
+		if name == "" {
+			name = translation.Name
+		}
+		if description == "" {
+			description = translation.Description
+		}
+	}
+	if name != "" {
+		i.Name = name
+	}
+	if description != "" {
+		i.Description = description
+	}
+	i.Translations = nil
+	return i
+}
+
+// IsUnavailable indica si el item ya no se puede comprar (descontinuado)
+func (i Item) IsUnavailable() bool {
+	return i.Availability != nil && i.Availability.Status == Discontinued
+}
+
+// IsVariant indica si el item es una variante de otro producto
+func (i Item) IsVariant() bool {
+	return i.ParentID != ""
+}
+
+// InheritFrom materializa una variante a partir de su padre: los campos vacíos
+// (nombre, imagen, descripción, moneda, disponibilidad, precio y rating en cero) se toman del padre y las
+// especificaciones del padre se combinan con las de la variante, que tienen prioridad.
+// Las traducciones del nombre y la descripción solo se heredan junto con el campo base.
+func (i Item) InheritFrom(parent Item) Item {
+	inheritsName, inheritsDescription := i.Name == "", i.Description == ""
+
+	if inheritsName {
+		i.Name = parent.Name
+	}
+	if i.ImageURL == "" {
+		i.ImageURL = parent.ImageURL
+	}
+	if inheritsDescription {
+		i.Description = parent.Description
+	}
+	if i.Price == 0 {
+		i.Price = parent.Price
+	}
+	if i.Currency == "" {
+		i.Currency = parent.Currency
+	}
+	if i.Availability == nil {
+		i.Availability = parent.Availability
+	}
+	if i.Rating == 0 {
+		i.Rating = parent.Rating
+	}
+
+	specs := make(map[string]interface{}, len(parent.Specifications)+len(i.Specifications))
+	for key, value := range parent.Specifications {
+		specs[key] = value
+	}
+	for key, value := range i.Specifications {
+		specs[key] = value
+	}
+	i.Specifications = specs
+
+	if (inheritsName || inheritsDescription) && len(parent.Translations) > 0 {
+		translations := make(map[string]ItemTranslation, len(parent.Translations)+len(i.Translations))
+		for locale, translation := range i.Translations {
+			translations[locale] = translation
+		}
+		for locale, inherited := range parent.Translations {
+			translation := translations[locale]
+			if inheritsName && translation.Name == "" {
+				translation.Name = inherited.Name
+			}
+			if inheritsDescription && translation.Description == "" {
+				translation.Description = inherited.Description
+			}
+			if translation != (ItemTranslation{}) {
+				translations[locale] = translation
+			}
+		}
+		i.Translations = translations
+	}
+
+	return i
+}
+
+// FieldValue extrae el valor de un campo del item a partir de su ruta.
+// Soporta campos raíz (ej. "price"), campos derivados (ej. "price_trend.low_30d")
+// y especificaciones (ej. "specifications.buttons"); retorna nil si el campo no existe.
+func (i Item) FieldValue(fieldPath string) interface{} {
+	parts := strings.Split(fieldPath, ".")
+
+	if len(parts) == 1 {
+		// Campo root
+		switch parts[0] {
+		case "price":
+			return i.Price
+		case "rating":
+			return i.Rating
+		case "name":
+			return i.Name
+		case "description":
+			return i.Description
+		case "image_url":
+			return i.ImageURL
+		default:
+			return nil
+		}
+	}
+
+	if len(parts) == 2 && parts[0] == "price_trend" {
+		// Campo derivado del historial de precios
+		if i.PriceTrend == nil {
+			return nil
+		}
+		switch parts[1] {
+		case "low_30d":
+			return i.PriceTrend.Low30d
+		case "change_pct":
+			return i.PriceTrend.ChangePct
+		case "at_lowest":
+			return i.PriceTrend.AtLowest
+		default:
+			return nil
+		}
+	}
+
+	if len(parts) == 2 && parts[0] == "availability" {
+		// Campos de disponibilidad; sin datos no son comparables
+		if i.Availability == nil {
+			return nil
+		}
+		switch parts[1] {
+		case "status":
+			return string(i.Availability.Status)
+		case "in_stock":
+			return i.Availability.Status == InStock
+		case "estimated_ship_date":
+			if i.Availability.EstimatedShipDate == "" {
+				return nil
+			}
+			return i.Availability.EstimatedShipDate
+		default:
+			return nil
+		}
+	}
+
+	if len(parts) == 2 && parts[0] == "specifications" {
+		// Campo anidado en specifications
+		if val, exists := i.Specifications[parts[1]]; exists {
+			// Extraer el valor numérico si es un objeto con "value"
+			if mapVal, ok := val.(map[string]interface{}); ok {
+				if numVal, hasValue := mapVal["value"]; hasValue {
+					return numVal
+				}
+			}
+			return val
+		}
+		return nil
+	}
+
+	return nil
 }
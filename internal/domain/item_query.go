@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ItemFilter representa los filtros sobre campos del item (mismas rutas que en la comparación)
+type ItemFilter struct {
+	Equals map[string][]string `json:"equals,omitempty"` // Ruta → valores aceptados (cualquiera de ellos)
+	Min    map[string]float64  `json:"min,omitempty"`    // Ruta → valor mínimo (inclusive)
+	Max    map[string]float64  `json:"max,omitempty"`    // Ruta → valor máximo (inclusive)
+}
+
+// IsEmpty indica si el filtro no tiene condiciones
+func (f ItemFilter) IsEmpty() bool {
+	return len(f.Equals) == 0 && len(f.Min) == 0 && len(f.Max) == 0
+}
+
+// Matches indica si el item cumple todas las condiciones del filtro
+func (f ItemFilter) Matches(item Item) bool {
+	for field, accepted := range f.Equals {
+		value := item.FieldValue(field)
+		if value == nil {
+			return false
+		}
+		formatted := FormatFieldValue(value)
+		matched := false
+		for _, candidate := range accepted {
+			if strings.EqualFold(formatted, candidate) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	for field, min := range f.Min {
+		number, ok := NumericFieldValue(item.FieldValue(field))
+		if !ok || number < min {
+			return false
+		}
+	}
+
+	for field, max := range f.Max {
+		number, ok := NumericFieldValue(item.FieldValue(field))
+		if !ok || number > max {
+			return false
+		}
+	}
+
+	return true
+}
+
+// FormatFieldValue convierte el valor de un campo a su forma textual (ej. para filtros o facetas)
+func FormatFieldValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// NumericFieldValue convierte el valor de un campo a float64 cuando es numérico
+func NumericFieldValue(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// ItemSort define el orden del listado de items
+type ItemSort string
+
+const (
+	SortByID         ItemSort = "id"
+	SortByPriceAsc   ItemSort = "price"
+	SortByPriceDesc  ItemSort = "-price"
+	SortByRatingAsc  ItemSort = "rating"
+	SortByRatingDesc ItemSort = "-rating"
+)
+
+// IsValid indica si el orden es soportado
+func (s ItemSort) IsValid() bool {
+	switch s {
+	case SortByID, SortByPriceAsc, SortByPriceDesc, SortByRatingAsc, SortByRatingDesc:
+		return true
+	default:
+		return false
+	}
+}
+
+// ItemListQuery representa la solicitud de listado paginado de items
+type ItemListQuery struct {
+	Filter ItemFilter
+	Sort   ItemSort
+	Cursor string // Opaco, tomado de ItemPage.NextCursor
+	Limit  int
+}
+
+// ItemPage representa una página del listado de items
+type ItemPage struct {
+	Items      []Item   `json:"items"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+	Sort       ItemSort `json:"sort"`  // Orden efectivo
+	Limit      int      `json:"limit"` // Tamaño de página efectivo
+}
+
+// SearchHit representa un item encontrado por la búsqueda de texto y su relevancia
+type SearchHit struct {
+	Item  Item    `json:"item"`
+	Score float64 `json:"score"` // Mayor es más relevante
+}
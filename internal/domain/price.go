@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+// PricePoint representa el precio de un item a partir de un momento dado
+type PricePoint struct {
+	Price          float64   `json:"price"`
+	RecordedAt     time.Time `json:"recorded_at"`
+	CatalogVersion string    `json:"catalog_version,omitempty"`
+}
+
+// PriceTrend contiene los campos derivados del historial de precios de un item
+type PriceTrend struct {
+	Low30d    float64 `json:"low_30d"`    // Precio más bajo de los últimos 30 días (incluye el actual)
+	ChangePct float64 `json:"change_pct"` // Variación porcentual contra el precio de hace 30 días
+	AtLowest  bool    `json:"at_lowest"`  // El precio actual es el más bajo de los últimos 30 días
+}
+
+// PriceHistory contiene la evolución del precio de un item
+type PriceHistory struct {
+	ItemID string       `json:"item_id"`
+	Points []PricePoint `json:"points"`
+	Trend  *PriceTrend  `json:"trend,omitempty"`
+}
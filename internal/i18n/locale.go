@@ -0,0 +1,183 @@
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLocale is the locale of the catalog base content and of the messages written in code
+const DefaultLocale = "en"
+
+// Canonical normalizes a BCP 47 tag: lower-case language, upper-case region ("es_mx" → "es-MX").
+// Returns "" for a malformed tag.
+func Canonical(tag string) string {
+	parts := strings.Split(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"), "-")
+	if len(parts[0]) < 2 || len(parts[0]) > 3 || !isLetters(parts[0]) {
+		return ""
+	}
+
+	canonical := strings.ToLower(parts[0])
+	for _, part := range parts[1:] {
+		switch {
+		case part == "" || len(part) > 8:
+			return ""
+		case len(part) == 2 && isLetters(part):
+			canonical += "-" + strings.ToUpper(part) // Region
+		default:
+			canonical += "-" + strings.ToLower(part) // Script or variant
+		}
+	}
+	return canonical
+}
+
+// Language returns the language subtag of a canonical tag ("es-MX" → "es")
+func Language(tag string) string {
+	language, _, _ := strings.Cut(tag, "-")
+	return language
+}
+
+func isLetters(s string) bool {
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// Negotiator resolves the locale of a request among the supported ones
+type Negotiator struct {
+	defaultLocale string
+	supported     []string // Canonical, in configuration order
+}
+
+// NewNegotiator validates the supported locales. The default locale is always supported.
+func NewNegotiator(defaultLocale string, supported []string) (*Negotiator, error) {
+	n := &Negotiator{defaultLocale: Canonical(defaultLocale)}
+	if n.defaultLocale == "" {
+		return nil, fmt.Errorf("invalid default locale %q", defaultLocale)
+	}
+
+	seen := map[string]bool{n.defaultLocale: true}
+	n.supported = append(n.supported, n.defaultLocale)
+	for _, tag := range supported {
+		if strings.TrimSpace(tag) == "" {
+			continue
+		}
+		canonical := Canonical(tag)
+		if canonical == "" {
+			return nil, fmt.Errorf("invalid supported locale %q", tag)
+		}
+		if !seen[canonical] {
+			seen[canonical] = true
+			n.supported = append(n.supported, canonical)
+		}
+	}
+	return n, nil
+}
+
+// Default returns the default locale
+func (n *Negotiator) Default() string {
+	return n.defaultLocale
+}
+
+// Supported returns the supported locales, the default first
+func (n *Negotiator) Supported() []string {
+	return n.supported
+}
+
+// Negotiate picks the supported locale that best matches an Accept-Language header
+// and returns its fallback chain: the locale, its bare language and the default locale
+// (e.g. "pt-BR" → [pt-BR pt en]). Ranges are tried by quality; each one matches a
+// supported locale exactly, then by language. Without a match the chain is just the default.
+func (n *Negotiator) Negotiate(acceptLanguage string) []string {
+	resolved := n.defaultLocale
+	for _, tag := range parseAcceptLanguage(acceptLanguage) {
+		if match := n.match(tag); match != "" {
+			resolved = match
+			break
+		}
+	}
+	return n.chain(resolved)
+}
+
+// match returns the supported locale for a requested tag, or "" if none matches
+func (n *Negotiator) match(tag string) string {
+	if tag == "*" {
+		return n.defaultLocale
+	}
+
+	for _, supported := range n.supported {
+		if supported == tag {
+			return supported
+		}
+	}
+
+	// Same language, preferring the bare language ("es-AR" → "es" before "es-MX")
+	language := Language(tag)
+	for _, supported := range n.supported {
+		if supported == language {
+			return supported
+		}
+	}
+	for _, supported := range n.supported {
+		if Language(supported) == language {
+			return supported
+		}
+	}
+	return ""
+}
+
+func (n *Negotiator) chain(locale string) []string {
+	chain := []string{locale}
+	if language := Language(locale); language != locale {
+		chain = append(chain, language)
+	}
+	if chain[len(chain)-1] != n.defaultLocale && locale != n.defaultLocale {
+		chain = append(chain, n.defaultLocale)
+	}
+	return chain
+}
+
+// parseAcceptLanguage returns the canonical ranges of the header sorted by quality.
+// Ranges with q=0 or malformed are dropped; ties keep the order of the header.
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag     string
+		quality float64
+	}
+
+	ranges := make([]weighted, 0)
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			q, err := strconv.ParseFloat(value, 64)
+			if err != nil || q < 0 || q > 1 {
+				continue
+			}
+			quality = q
+		}
+		if quality == 0 {
+			continue
+		}
+
+		tag = strings.TrimSpace(tag)
+		if tag != "*" {
+			tag = Canonical(tag)
+		}
+		if tag != "" {
+			ranges = append(ranges, weighted{tag: tag, quality: quality})
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].quality > ranges[j].quality })
+
+	tags := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		tags = append(tags, r.tag)
+	}
+	return tags
+}
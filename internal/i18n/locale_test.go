@@ -0,0 +1,65 @@
+package i18n
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	tests := map[string]string{
+		"es-mx":      "es-MX",
+		"PT_br":      "pt-BR",
+		"en":         "en",
+		"zh-Hant-TW": "zh-hant-TW",
+		"e":          "",
+		"es-":        "",
+		"1a":         "",
+	}
+	for tag, expected := range tests {
+		if got := Canonical(tag); got != expected {
+			t.Errorf("Canonical(%q) = %q, want %q", tag, got, expected)
+		}
+	}
+}
+
+func TestNegotiator_Negotiate(t *testing.T) {
+	negotiator, err := NewNegotiator("en", []string{"es-MX", "es", "pt-BR"})
+	if err != nil {
+		t.Fatalf("NewNegotiator() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		header   string
+		expected []string
+	}{
+		{name: "No header", header: "", expected: []string{"en"}},
+		{name: "Exact match", header: "pt-BR", expected: []string{"pt-BR", "pt", "en"}},
+		{name: "Case-insensitive", header: "es-mx", expected: []string{"es-MX", "es", "en"}},
+		{name: "Bare language preferred", header: "es-AR", expected: []string{"es", "en"}},
+		{name: "Same language, other region", header: "pt-PT", expected: []string{"pt-BR", "pt", "en"}},
+		{name: "Quality order", header: "fr;q=0.9, pt-BR;q=0.5, es-MX;q=0.8", expected: []string{"es-MX", "es", "en"}},
+		{name: "Unsupported falls through", header: "fr, de;q=0.8, pt;q=0.1", expected: []string{"pt-BR", "pt", "en"}},
+		{name: "q=0 excludes", header: "pt-BR;q=0, es", expected: []string{"es", "en"}},
+		{name: "Wildcard is the default", header: "fr, *;q=0.5", expected: []string{"en"}},
+		{name: "Nothing supported", header: "fr, de", expected: []string{"en"}},
+		{name: "Malformed ranges ignored", header: "-, es-MX;q=abc, pt-BR", expected: []string{"pt-BR", "pt", "en"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := negotiator.Negotiate(tt.header); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Negotiate(%q) = %v, want %v", tt.header, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewNegotiator_InvalidLocale(t *testing.T) {
+	if _, err := NewNegotiator("en", []string{"es-MX", "not a tag"}); err == nil {
+		t.Error("Expected an error for a malformed supported locale")
+	}
+	if _, err := NewNegotiator("", nil); err == nil {
+		t.Error("Expected an error for an empty default locale")
+	}
+}
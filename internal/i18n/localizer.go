@@ -0,0 +1,115 @@
+package i18n
+
+import (
+	"context"
+
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+)
+
+// Bundle negotiates the locale of every request and localizes its responses
+type Bundle struct {
+	negotiator *Negotiator
+	messages   *Messages
+}
+
+// NewBundle loads the message catalogs and validates the supported locales
+func NewBundle(defaultLocale string, supported []string) (*Bundle, error) {
+	negotiator, err := NewNegotiator(defaultLocale, supported)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := LoadMessages()
+	if err != nil {
+		return nil, err
+	}
+	return &Bundle{negotiator: negotiator, messages: messages}, nil
+}
+
+// Supported returns the supported locales, the default first
+func (b *Bundle) Supported() []string {
+	return b.negotiator.Supported()
+}
+
+// Localizer returns the localizer of a request from its Accept-Language header
+func (b *Bundle) Localizer(acceptLanguage string) *Localizer {
+	return &Localizer{
+		chain:         b.negotiator.Negotiate(acceptLanguage),
+		defaultLocale: b.negotiator.Default(),
+		messages:      b.messages,
+	}
+}
+
+// Localizer translates the content of one response along a fallback chain
+type Localizer struct {
+	chain         []string
+	defaultLocale string
+	messages      *Messages
+}
+
+// Locale returns the negotiated locale
+func (l *Localizer) Locale() string {
+	return l.chain[0]
+}
+
+// Chain returns the fallback chain, the negotiated locale first
+func (l *Localizer) Chain() []string {
+	return l.chain
+}
+
+// Message translates an English message
+func (l *Localizer) Message(message string) string {
+	if l.messages == nil {
+		return message
+	}
+	return l.messages.Translate(l.chain, message)
+}
+
+// Error returns a copy of the error with its message translated. nil stays nil.
+func (l *Localizer) Error(errResp *domain.ErrorResponse) *domain.ErrorResponse {
+	if errResp == nil {
+		return nil
+	}
+	localized := *errResp
+	localized.Message = l.Message(errResp.Message)
+	return &localized
+}
+
+// Item resolves the translated content of an item.
+// The base content of the catalog is in the default locale, so the chain stops there.
+func (l *Localizer) Item(item domain.Item) domain.Item {
+	return item.Localize(l.contentChain())
+}
+
+// Items localizes every item into a new slice
+func (l *Localizer) Items(items []domain.Item) []domain.Item {
+	localized := make([]domain.Item, len(items))
+	for i, item := range items {
+		localized[i] = l.Item(item)
+	}
+	return localized
+}
+
+func (l *Localizer) contentChain() []string {
+	for i, locale := range l.chain {
+		if locale == l.defaultLocale {
+			return l.chain[:i]
+		}
+	}
+	return l.chain
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the localizer
+func NewContext(ctx context.Context, l *Localizer) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the localizer of the request, or one that keeps
+// the default (English) content when the locale was not negotiated
+func FromContext(ctx context.Context) *Localizer {
+	if l, ok := ctx.Value(contextKey{}).(*Localizer); ok {
+		return l
+	}
+	return &Localizer{chain: []string{DefaultLocale}, defaultLocale: DefaultLocale}
+}
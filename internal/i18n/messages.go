@@ -0,0 +1,140 @@
+package i18n
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// placeholder matches the {0}, {1}... markers of a message key
+var placeholder = regexp.MustCompile(`\{(\d+)\}`)
+
+// Messages holds the translations of the API messages (errors and summaries) per locale.
+// Messages are written in English in the code and the English text is the key, like a gettext msgid.
+// A key may contain placeholders ({0}, {1}...) that match any text of the message
+// and are copied verbatim into the translation (e.g. IDs or field names).
+type Messages struct {
+	exact    map[string]map[string]string // Locale → English message → translation
+	patterns map[string][]messagePattern  // Locale → keys with placeholders
+}
+
+type messagePattern struct {
+	expr        *regexp.Regexp
+	groups      []string // Placeholder of every capture group, in order
+	translation string
+}
+
+// LoadMessages loads the catalogs embedded in locales/, one JSON object per locale (e.g. pt-BR.json)
+func LoadMessages() (*Messages, error) {
+	names, err := fs.Glob(localeFiles, "locales/*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Messages{
+		exact:    make(map[string]map[string]string),
+		patterns: make(map[string][]messagePattern),
+	}
+	for _, name := range names {
+		locale := Canonical(strings.TrimSuffix(path.Base(name), ".json"))
+		if locale == "" {
+			return nil, fmt.Errorf("invalid locale file name %s", name)
+		}
+
+		content, err := localeFiles.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		var entries map[string]string
+		if err := json.Unmarshal(content, &entries); err != nil {
+			return nil, fmt.Errorf("invalid message catalog %s: %w", name, err)
+		}
+		if err := m.add(locale, entries); err != nil {
+			return nil, fmt.Errorf("invalid message catalog %s: %w", name, err)
+		}
+	}
+
+	return m, nil
+}
+
+func (m *Messages) add(locale string, entries map[string]string) error {
+	exact := make(map[string]string)
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys) // Deterministic pattern order
+
+	for _, key := range keys {
+		translation := entries[key]
+		markers := placeholder.FindAllString(key, -1)
+		if len(markers) == 0 {
+			exact[key] = translation
+			continue
+		}
+
+		for _, marker := range placeholder.FindAllString(translation, -1) {
+			if !strings.Contains(key, marker) {
+				return fmt.Errorf("translation of %q uses unknown placeholder %s", key, marker)
+			}
+		}
+
+		// Literal text around the placeholders, each placeholder a lazy group
+		expr := "^"
+		last := 0
+		for _, loc := range placeholder.FindAllStringIndex(key, -1) {
+			expr += regexp.QuoteMeta(key[last:loc[0]]) + "(.+?)"
+			last = loc[1]
+		}
+		expr += regexp.QuoteMeta(key[last:]) + "$"
+
+		m.patterns[locale] = append(m.patterns[locale], messagePattern{
+			expr:        regexp.MustCompile(expr),
+			groups:      markers,
+			translation: translation,
+		})
+	}
+	m.exact[locale] = exact
+	return nil
+}
+
+// Translate returns the message in the first locale of the chain that translates it,
+// or the message itself (English) when none does
+func (m *Messages) Translate(chain []string, message string) string {
+	for _, locale := range chain {
+		if translation, exists := m.exact[locale][message]; exists {
+			return translation
+		}
+		for _, pattern := range m.patterns[locale] {
+			values := pattern.expr.FindStringSubmatch(message)
+			if values == nil {
+				continue
+			}
+			translation := pattern.translation
+			for i, marker := range pattern.groups {
+				translation = strings.ReplaceAll(translation, marker, values[i+1])
+			}
+			return translation
+		}
+	}
+	return message
+}
+
+// Locales returns the locales that have a message catalog, sorted
+func (m *Messages) Locales() []string {
+	locales := make([]string, 0, len(m.exact))
+	for locale := range m.exact {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
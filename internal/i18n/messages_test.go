@@ -0,0 +1,109 @@
+package i18n
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+)
+
+func TestMessages_Translate(t *testing.T) {
+	messages, err := LoadMessages()
+	if err != nil {
+		t.Fatalf("LoadMessages() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		chain    []string
+		message  string
+		expected string
+	}{
+		{name: "Exact", chain: []string{"es-MX", "es", "en"}, message: "Product not found.", expected: "Producto no encontrado."},
+		{name: "Region catalog", chain: []string{"pt-BR", "pt", "en"}, message: "Product not found.", expected: "Produto não encontrado."},
+		{name: "Placeholder", chain: []string{"es", "en"}, message: "Unknown tenant 'acme'.", expected: "Tienda desconocida 'acme'."},
+		{
+			name:     "Several placeholders",
+			chain:    []string{"pt-BR", "en"},
+			message:  "Compared 3 products on 7 fields; mouse-1 is best on 4 of them.",
+			expected: "Foram comparados 3 produtos em 7 campos; mouse-1 é o melhor em 4 deles.",
+		},
+		{name: "Untranslated message", chain: []string{"es", "en"}, message: "Something new.", expected: "Something new."},
+		{name: "Default locale", chain: []string{"en"}, message: "Product not found.", expected: "Product not found."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := messages.Translate(tt.chain, tt.message); got != tt.expected {
+				t.Errorf("Translate() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMessages_CatalogsTranslateTheSameMessages(t *testing.T) {
+	keys := make(map[string][]string)
+	for _, name := range []string{"locales/es.json", "locales/pt-BR.json"} {
+		var entries map[string]string
+		content, err := localeFiles.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(content, &entries); err != nil {
+			t.Fatal(err)
+		}
+		for key := range entries {
+			keys[name] = append(keys[name], key)
+		}
+		sort.Strings(keys[name])
+	}
+
+	if !reflect.DeepEqual(keys["locales/es.json"], keys["locales/pt-BR.json"]) {
+		t.Error("Expected es and pt-BR to translate the same messages")
+	}
+}
+
+func TestLocalizer(t *testing.T) {
+	bundle, err := NewBundle("en", []string{"es-MX", "pt-BR"})
+	if err != nil {
+		t.Fatalf("NewBundle() error = %v", err)
+	}
+
+	item := domain.Item{
+		ID:          "m1",
+		Name:        "Mouse",
+		Description: "Wireless mouse",
+		Translations: map[string]domain.ItemTranslation{
+			"es-MX": {Name: "Ratón"},
+			"es":    {Name: "Ratón", Description: "Ratón inalámbrico"},
+			"en":    {Name: "Ignored: the base content is English"},
+		},
+	}
+
+	localizer := bundle.Localizer("es-MX,es;q=0.9")
+	if localizer.Locale() != "es-MX" {
+		t.Fatalf("Locale() = %s, want es-MX", localizer.Locale())
+	}
+	localized := localizer.Item(item)
+	if localized.Name != "Ratón" || localized.Description != "Ratón inalámbrico" || localized.Translations != nil {
+		t.Errorf("Expected the es-MX name and the es description, got %+v", localized)
+	}
+
+	// pt-BR has no translation: the base content is kept
+	localized = bundle.Localizer("pt-BR").Item(item)
+	if localized.Name != "Mouse" || localized.Description != "Wireless mouse" {
+		t.Errorf("Expected the base content, got %+v", localized)
+	}
+
+	localized = bundle.Localizer("").Item(item)
+	if localized.Name != "Mouse" {
+		t.Errorf("Expected the base name for the default locale, got %s", localized.Name)
+	}
+
+	errResp := bundle.Localizer("pt").Error(&domain.ErrorResponse{ErrorCode: domain.ErrorCodeIdNotFound, Message: "Product not found."})
+	if errResp.Message != "Produto não encontrado." || errResp.ErrorCode != domain.ErrorCodeIdNotFound {
+		t.Errorf("Expected a translated copy of the error, got %+v", errResp)
+	}
+}
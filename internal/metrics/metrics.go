@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "product_comparison"
+
+// Cache statuses of a comparison request, also sent in the Cache-Status header
+const (
+	CacheHit       = "hit"       // Served from the request cache
+	CacheStale     = "stale"     // Served from the request cache past its soft TTL, while it is recomputed
+	CacheMiss      = "miss"      // Computed by this request
+	CacheCoalesced = "coalesced" // Missed, and shared the comparison computed for a concurrent request with the same key
+	CacheBypass    = "bypass"    // Pinned to a past catalog version, never cached
+)
+
+// CompareRequests counts the comparison requests by cache status.
+// Coalesced requests are the recomputations saved when a popular comparison expires.
+var CompareRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "compare_requests_total",
+	Help:      "Comparison requests by request cache status (hit, stale, miss, coalesced or bypass).",
+}, []string{"cache_status"})
+
+// RegisterCacheMemory exposes the memory budget of an in-process cache ("request" or "idempotency"):
+// the bytes its entries take and the configured maximum
+func RegisterCacheMemory(cache string, used, max func() int64) {
+	labels := prometheus.Labels{"cache": cache}
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Name:        "cache_used_bytes",
+		Help:        "Bytes of serialized entries held by an in-process cache.",
+		ConstLabels: labels,
+	}, func() float64 { return float64(used()) })
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Name:        "cache_max_bytes",
+		Help:        "Memory budget of an in-process cache (CACHE_MAX_BYTES, IDEMPOTENCY_MAX_BYTES).",
+		ConstLabels: labels,
+	}, func() float64 { return float64(max()) })
+}
+
+// Handler serves every registered metric in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
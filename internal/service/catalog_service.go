@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+
+	"github.com/mmedinam1600/product-comparison-api/internal/data"
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"go.uber.org/zap"
+)
+
+// CatalogService define the contract for the catalog introspection use cases
+type CatalogService interface {
+	// Versions returns the retained catalog versions (newest first) and the live one.
+	// Both are empty when the catalog source is not versioned.
+	Versions(ctx context.Context) ([]domain.CatalogVersion, *domain.CatalogVersion)
+
+	// Changes returns the item changes between consecutive retained versions, newest first.
+	// With since, returns a single diff from that version to the live one.
+	Changes(ctx context.Context, since string) ([]domain.CatalogDiff, *domain.ErrorResponse)
+}
+
+// CatalogServiceImpl implements CatalogService
+type CatalogServiceImpl struct {
+	repo   data.CatalogRepository
+	logger *zap.Logger
+}
+
+// NewCatalogService creates a new instance of the service
+func NewCatalogService(repo data.CatalogRepository, logger *zap.Logger) *CatalogServiceImpl {
+	return &CatalogServiceImpl{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Versions implements CatalogService.Versions
+func (s *CatalogServiceImpl) Versions(ctx context.Context) ([]domain.CatalogVersion, *domain.CatalogVersion) {
+	versioned, ok := s.repo.(data.VersionedCatalog)
+	if !ok {
+		return []domain.CatalogVersion{}, nil
+	}
+
+	current := versioned.CurrentSnapshot().Version
+	return versioned.Versions(), &current
+}
+
+// Changes implements CatalogService.Changes
+func (s *CatalogServiceImpl) Changes(ctx context.Context, since string) ([]domain.CatalogDiff, *domain.ErrorResponse) {
+	versioned, ok := s.repo.(data.VersionedCatalog)
+	if !ok {
+		if since != "" {
+			return nil, &domain.ErrorResponse{
+				ErrorCode: domain.ErrorCodeCatalogVersionNotFound,
+				Message:   "The catalog source does not keep versions.",
+			}
+		}
+		return []domain.CatalogDiff{}, nil
+	}
+
+	current := versioned.CurrentSnapshot()
+
+	if since != "" {
+		from, found := versioned.SnapshotByVersion(since)
+		if !found {
+			return nil, &domain.ErrorResponse{
+				ErrorCode: domain.ErrorCodeCatalogVersionNotFound,
+				Message:   "Catalog version '" + since + "' is not retained.",
+			}
+		}
+		return []domain.CatalogDiff{data.DiffSnapshots(from, current)}, nil
+	}
+
+	// Versions are newest first: diff every version against the one before it
+	versions := versioned.Versions()
+	changes := make([]domain.CatalogDiff, 0, len(versions))
+	for i := 0; i+1 < len(versions); i++ {
+		newer, foundNewer := versioned.SnapshotByVersion(versions[i].ID)
+		older, foundOlder := versioned.SnapshotByVersion(versions[i+1].ID)
+		if !foundNewer || !foundOlder {
+			// Evicted by a publish that happened meanwhile
+			continue
+		}
+		changes = append(changes, data.DiffSnapshots(older, newer))
+	}
+
+	return changes, nil
+}
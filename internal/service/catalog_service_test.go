@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mmedinam1600/product-comparison-api/internal/data"
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"go.uber.org/zap"
+)
+
+func TestCatalogService_Changes(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	v1, _ := data.NewSnapshot([]domain.Item{{ID: "a", Price: 1}, {ID: "b", Price: 2}}, base)
+	v2, _ := data.NewSnapshot([]domain.Item{{ID: "a", Price: 5}, {ID: "b", Price: 2}}, base.Add(time.Hour))
+	v3, _ := data.NewSnapshot([]domain.Item{{ID: "a", Price: 5}, {ID: "c", Price: 3}}, base.Add(2*time.Hour))
+
+	svc := NewCatalogService(&MockVersionedCatalog{snapshots: []*data.Snapshot{v1, v2, v3}}, zap.NewNop())
+
+	changes, errResp := svc.Changes(context.Background(), "")
+	if errResp != nil {
+		t.Fatalf("Unexpected error: %v", errResp)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 diffs, got %d", len(changes))
+	}
+	if changes[0].ToVersion != v3.Version.ID || !reflect.DeepEqual(changes[0].Added, []string{"c"}) {
+		t.Errorf("Expected newest diff first with c added, got %+v", changes[0])
+	}
+	if !reflect.DeepEqual(changes[1].Changed, []string{"a"}) {
+		t.Errorf("Expected a changed in the oldest diff, got %+v", changes[1])
+	}
+
+	// since aggregates everything up to the live version
+	changes, errResp = svc.Changes(context.Background(), v1.Version.ID)
+	if errResp != nil {
+		t.Fatalf("Unexpected error: %v", errResp)
+	}
+	diff := changes[0]
+	if !reflect.DeepEqual(diff.AffectedIDs(), []string{"c", "b", "a"}) {
+		t.Errorf("Expected c added, b removed and a changed, got %+v", diff)
+	}
+
+	_, errResp = svc.Changes(context.Background(), "unknown")
+	if errResp == nil || errResp.ErrorCode != domain.ErrorCodeCatalogVersionNotFound {
+		t.Errorf("Expected CATALOG_VERSION_NOT_FOUND, got %v", errResp)
+	}
+}
@@ -6,8 +6,9 @@ import (
 	"encoding/hex"
 	"fmt"
 	"sort"
-	"strings"
+	"time"
 
+	"github.com/goccy/go-json"
 	"github.com/mmedinam1600/product-comparison-api/internal/data"
 	"github.com/mmedinam1600/product-comparison-api/internal/domain"
 	"github.com/mmedinam1600/product-comparison-api/internal/service/strategy"
@@ -19,36 +20,107 @@ type CompareService interface {
 	// Compare executes the product comparison
 	Compare(ctx context.Context, req domain.CompareRequest) (domain.CompareResult, domain.Metadata, *domain.ErrorResponse)
 
-	// GenerateCacheKey generates a unique key for caching based on the IDs
-	GenerateCacheKey(ids []string) string
+	// GenerateCacheKey generates the cache key of a request, covering every input that changes its result
+	GenerateCacheKey(req domain.CompareRequest) string
 }
 
 // CompareServiceImpl implements CompareService
 type CompareServiceImpl struct {
-	repo       data.CatalogRepository
-	strategies map[string]strategy.Interface
-	logger     *zap.Logger
+	repo         data.CatalogRepository
+	strategies   map[string]strategy.Interface
+	priceHistory *data.PriceHistory       // Optional, enables the price_trend.* fields
+	metrics      strategy.MetricOverrides // Optional, replaces default field metrics
+	rates        data.ExchangeRateSource  // Optional, enables comparing prices in other currencies
+	currency     string                   // Currency of the prices that do not declare one
+	logger       *zap.Logger
+}
+
+// DefaultCurrency is the currency of the catalog prices when none is configured
+const DefaultCurrency = "USD"
+
+// defaultStrategy is the strategy applied to every comparison
+const defaultStrategy = "at_least_two"
+
+// Option configures an optional collaborator of the compare service
+type Option func(*CompareServiceImpl)
+
+// WithPriceHistory derives the price_trend.* comparison fields from the price history
+func WithPriceHistory(history *data.PriceHistory) Option {
+	return func(s *CompareServiceImpl) {
+		s.priceHistory = history
+	}
+}
+
+// WithMetricOverrides replaces the default metric of some fields (e.g. per storefront)
+func WithMetricOverrides(overrides strategy.MetricOverrides) Option {
+	return func(s *CompareServiceImpl) {
+		s.metrics = overrides
+	}
+}
+
+// WithCurrency sets the currency of the catalog prices that do not declare one,
+// which is also the currency of the responses by default
+func WithCurrency(currency string) Option {
+	return func(s *CompareServiceImpl) {
+		s.currency = currency
+	}
+}
+
+// WithExchangeRates converts prices in other currencies with the table of the source
+func WithExchangeRates(rates data.ExchangeRateSource) Option {
+	return func(s *CompareServiceImpl) {
+		s.rates = rates
+	}
 }
 
 // NewCompareService creates a new instance of the service
-func NewCompareService(repo data.CatalogRepository, logger *zap.Logger) *CompareServiceImpl {
+func NewCompareService(repo data.CatalogRepository, logger *zap.Logger, opts ...Option) *CompareServiceImpl {
+	service := &CompareServiceImpl{
+		repo:     repo,
+		currency: DefaultCurrency,
+		logger:   logger,
+	}
+	for _, opt := range opts {
+		opt(service)
+	}
+
 	// Register available strategies
-	strategies := make(map[string]strategy.Interface)
-	atLeastTwo := strategy.NewAtLeastTwo()
-	strategies[atLeastTwo.Name()] = atLeastTwo
+	service.strategies = make(map[string]strategy.Interface)
+	atLeastTwo := strategy.NewAtLeastTwo().WithMetricOverrides(service.metrics)
+	service.strategies[atLeastTwo.Name()] = atLeastTwo
 
-	return &CompareServiceImpl{
-		repo:       repo,
-		strategies: strategies,
-		logger:     logger,
-	}
+	return service
 }
 
 // Compare implements CompareService.Compare
 func (s *CompareServiceImpl) Compare(ctx context.Context, req domain.CompareRequest) (domain.CompareResult, domain.Metadata, *domain.ErrorResponse) {
-	// === STEP 1: Validate IDs ===
-	// Validate that there are at least 2 unique IDs
-	uniqueIDs := s.getUniqueIDs(req.Ids)
+	// Same canonical fields as the cache key, so equivalent requests get the same result
+	req.Fields = domain.CanonicalFields(req.Fields)
+
+	// === STEP 1: Resolve the catalog version ===
+	catalog, catalogVersion, errResp := s.resolveCatalog(req)
+	if errResp != nil {
+		return domain.CompareResult{}, domain.Metadata{}, errResp
+	}
+
+	policy := req.Unavailable
+	if policy == "" {
+		policy = domain.UnavailableFlag
+	}
+	if !policy.IsValid() {
+		return domain.CompareResult{}, domain.Metadata{}, &domain.ErrorResponse{
+			ErrorCode: domain.ErrorCodeInvalidRequest,
+			Message:   fmt.Sprintf("Invalid unavailable policy '%s'. Use flag, exclude or include.", policy),
+		}
+	}
+
+	// === STEP 2: Expand parents into their variants and validate IDs ===
+	// Validate that there are at least 2 unique IDs after the expansion
+	ids, expanded, err := s.expandVariants(ctx, catalog, req.Ids)
+	if err != nil {
+		return domain.CompareResult{}, domain.Metadata{}, s.readFailed(err)
+	}
+	uniqueIDs := s.getUniqueIDs(ids)
 	if len(uniqueIDs) < 2 {
 		return domain.CompareResult{}, domain.Metadata{}, &domain.ErrorResponse{
 			ErrorCode: domain.ErrorCodeAtLeastTwoIds,
@@ -56,10 +128,15 @@ func (s *CompareServiceImpl) Compare(ctx context.Context, req domain.CompareRequ
 		}
 	}
 
-	s.logger.Debug("validating IDs", zap.Int("unique_count", len(uniqueIDs)))
+	s.logger.Debug("validating IDs",
+		zap.Int("unique_count", len(uniqueIDs)),
+		zap.Int("expanded_parents", len(expanded)),
+	)
 
-	// === STEP 2: Resolve items from the repository ===
-	items, missingIDs := s.repo.GetByIDs(ctx, uniqueIDs)
+	items, missingIDs, err := catalog.GetByIDs(ctx, uniqueIDs)
+	if err != nil {
+		return domain.CompareResult{}, domain.Metadata{}, s.readFailed(err)
+	}
 
 	if len(missingIDs) > 0 {
 		s.logger.Warn("some IDs not found", zap.Strings("missing", missingIDs))
@@ -72,9 +149,56 @@ func (s *CompareServiceImpl) Compare(ctx context.Context, req domain.CompareRequ
 
 	s.logger.Debug("items resolved", zap.Int("count", len(items)))
 
+	// Derived fields are computed as of the moment the compared catalog was live
+	trendAt := time.Now()
+	if req.AsOf != nil {
+		trendAt = *req.AsOf
+	} else if req.CatalogVersion != nil {
+		trendAt = catalogVersion.CreatedAt
+	}
+	items = s.withPriceTrends(items, trendAt)
+
+	// Prices (and derived prices) are converted before the diff, so the best price ranks comparable amounts
+	currency, conversion, errResp := s.convertPrices(items, req.Currency)
+	if errResp != nil {
+		return domain.CompareResult{}, domain.Metadata{}, errResp
+	}
+
+	// Discontinued items are removed, or kept but never chosen as best (see STEP 5)
+	available, unavailableIDs := splitUnavailable(items)
+	order := uniqueIDs
+	var warnings []domain.Warning
+	if len(unavailableIDs) > 0 {
+		switch policy {
+		case domain.UnavailableExclude:
+			if len(available) < 2 {
+				return domain.CompareResult{}, domain.Metadata{}, &domain.ErrorResponse{
+					ErrorCode: domain.ErrorCodeAtLeastTwoIds,
+					Message:   "At least 2 available products are required.",
+				}
+			}
+			items = available
+			order = make([]string, len(items))
+			for i, item := range items {
+				order[i] = item.ID
+			}
+			warnings = append(warnings, domain.Warning{
+				Code:    domain.WarningUnavailableItemsExcluded,
+				Message: "Unavailable products were excluded from the comparison.",
+				Items:   unavailableIDs,
+			})
+		case domain.UnavailableFlag:
+			warnings = append(warnings, domain.Warning{
+				Code:    domain.WarningUnavailableItems,
+				Message: "Unavailable products are never marked as best.",
+				Items:   unavailableIDs,
+			})
+		}
+	}
+
 	// === STEP 3: Select and apply strategy ===
 	// For now, we only use "at_least_two"
-	strategyName := "at_least_two"
+	strategyName := defaultStrategy
 	strat, exists := s.strategies[strategyName]
 	if !exists {
 		s.logger.Error("strategy not found", zap.String("strategy", strategyName))
@@ -119,6 +243,25 @@ func (s *CompareServiceImpl) Compare(ctx context.Context, req domain.CompareRequ
 		}
 	}
 
+	// Flagged items keep their values, but the best ones are chosen among the available items
+	if policy == domain.UnavailableFlag && len(unavailableIDs) > 0 {
+		eligible, err := strat.ComputeDiff(ctx, available, resolvedFields)
+		if err != nil {
+			s.logger.Error("failed to compute diff", zap.Error(err))
+			return domain.CompareResult{}, domain.Metadata{}, &domain.ErrorResponse{
+				ErrorCode: domain.ErrorCodeInvalidRequest,
+				Message:   "Failed to compute differences.",
+			}
+		}
+		for field, fieldDiff := range diff {
+			fieldDiff.Best = eligible[field].Best
+			if fieldDiff.Best == nil {
+				fieldDiff.Best = []string{}
+			}
+			diff[field] = fieldDiff
+		}
+	}
+
 	// === STEP 6: Calculate comparability score ===
 	// baseCandidate: if the client sent fields → use those; if not → use resolvedFields
 	var baseCandidate []string
@@ -146,15 +289,20 @@ func (s *CompareServiceImpl) Compare(ctx context.Context, req domain.CompareRequ
 	}
 
 	metadata := domain.Metadata{
-		Order:           uniqueIDs,
+		Order:           order,
 		RequestedFields: req.Fields,
 		ResolvedFields:  resolvedFields,
 		ComparePolicy: domain.ComparePolicy{
 			EffectiveMode:      strat.Name(),
 			ComparabilityScore: comparabilityScore,
+			Warnings:           warnings,
 		},
-		Currency: "USD",
-		Version:  "1.0",
+		Currency:       currency,
+		Conversion:     conversion,
+		Version:        "1.0",
+		CatalogVersion: catalogVersion,
+		Variants:       expanded,
+		Summary:        summarize(items, resolvedFields, diff),
 	}
 
 	s.logger.Info("comparison completed successfully",
@@ -165,15 +313,262 @@ func (s *CompareServiceImpl) Compare(ctx context.Context, req domain.CompareRequ
 	return result, metadata, nil
 }
 
-// GenerateCacheKey generates a cache key based on the ordered IDs
-func (s *CompareServiceImpl) GenerateCacheKey(ids []string) string {
-	// Get unique and ordered IDs
-	uniqueIDs := s.getUniqueIDs(ids)
-	sort.Strings(uniqueIDs)
+// convertPrices converts, in place, the price and price_trend.low_30d of every item to the
+// requested currency (the service currency when empty). Converted items keep their original price.
+// Returns the currency of the response and the conversion applied, nil when nothing was converted.
+func (s *CompareServiceImpl) convertPrices(items []domain.Item, requested string) (string, *domain.CurrencyConversion, *domain.ErrorResponse) {
+	target := s.currency
+	if requested != "" {
+		if target = domain.NormalizeCurrency(requested); target == "" {
+			return "", nil, &domain.ErrorResponse{
+				ErrorCode: domain.ErrorCodeUnsupportedCurrency,
+				Message:   fmt.Sprintf("Invalid currency '%s'.", requested),
+			}
+		}
+	}
+
+	// One table for the whole comparison, even if it reloads meanwhile
+	var rates *domain.ExchangeRates
+	if s.rates != nil {
+		rates = s.rates.Current()
+	}
+
+	var conversion *domain.CurrencyConversion
+	for i := range items {
+		item := &items[i]
+		from := item.Currency
+		if from == "" {
+			from = s.currency
+		}
+		item.Currency = target
+		if from == target {
+			continue
+		}
+
+		rate, ok := 0.0, false
+		if rates != nil {
+			rate, ok = rates.Rate(from, target)
+		}
+		if !ok {
+			return "", nil, &domain.ErrorResponse{
+				ErrorCode: domain.ErrorCodeUnsupportedCurrency,
+				Message:   fmt.Sprintf("No exchange rate from %s to %s.", from, target),
+			}
+		}
+
+		item.OriginalPrice = &domain.Money{Amount: item.Price, Currency: from}
+		item.Price = domain.RoundMoney(item.Price * rate)
+		if item.PriceTrend != nil {
+			trend := *item.PriceTrend
+			trend.Low30d = domain.RoundMoney(trend.Low30d * rate)
+			item.PriceTrend = &trend
+		}
+
+		if conversion == nil {
+			conversion = &domain.CurrencyConversion{RatesUpdatedAt: rates.UpdatedAt, Rates: make(map[string]float64)}
+		}
+		conversion.Rates[from] = rate
+	}
+
+	return target, conversion, nil
+}
+
+// splitUnavailable returns the items that can still be bought and the IDs of the ones that cannot
+func splitUnavailable(items []domain.Item) ([]domain.Item, []string) {
+	available := make([]domain.Item, 0, len(items))
+	var unavailableIDs []string
+	for _, item := range items {
+		if item.IsUnavailable() {
+			unavailableIDs = append(unavailableIDs, item.ID)
+		} else {
+			available = append(available, item)
+		}
+	}
+	return available, unavailableIDs
+}
+
+// summarize describes the comparison in one sentence, naming the item that is best on most fields.
+// Messages are written in English; the HTTP layer translates them (see i18n).
+func summarize(items []domain.Item, fields []string, diff map[string]domain.DiffField) string {
+	wins := make(map[string]int)
+	for _, field := range diff {
+		// A field where every item ties decides nothing
+		if len(field.Best) == 0 || len(field.Best) == len(items) {
+			continue
+		}
+		for _, id := range field.Best {
+			wins[id]++
+		}
+	}
+
+	leader, most, tied := "", 0, false
+	for _, item := range items {
+		switch count := wins[item.ID]; {
+		case count > most:
+			leader, most, tied = item.ID, count, false
+		case count == most && count > 0:
+			tied = true
+		}
+	}
+
+	if leader == "" || tied {
+		return fmt.Sprintf("Compared %d products on %d fields.", len(items), len(fields))
+	}
+	return fmt.Sprintf("Compared %d products on %d fields; %s is best on %d of them.", len(items), len(fields), leader, most)
+}
+
+// expandVariants replaces the IDs of parent items by the IDs of their variants, in place.
+// Returns the expanded IDs and, per expanded parent, its variant IDs (nil when nothing expanded).
+func (s *CompareServiceImpl) expandVariants(ctx context.Context, catalog data.CatalogRepository, ids []string) ([]string, map[string][]string, error) {
+	var expanded map[string][]string
+	result := make([]string, 0, len(ids))
+
+	for _, id := range ids {
+		variants, isParent, err := catalog.GetVariants(ctx, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !isParent {
+			result = append(result, id)
+			continue
+		}
+
+		variantIDs := make([]string, 0, len(variants))
+		for _, variant := range variants {
+			variantIDs = append(variantIDs, variant.ID)
+		}
+		if expanded == nil {
+			expanded = make(map[string][]string)
+		}
+		expanded[id] = variantIDs
+		result = append(result, variantIDs...)
+	}
+
+	return result, expanded, nil
+}
+
+// readFailed logs a catalog read failure and reports it as an internal error, never as missing items
+func (s *CompareServiceImpl) readFailed(err error) *domain.ErrorResponse {
+	s.logger.Error("failed to read the catalog", zap.Error(err))
+	return &domain.ErrorResponse{
+		ErrorCode: domain.ErrorCodeInternal,
+		Message:   "Failed to read the catalog.",
+	}
+}
+
+// resolveCatalog selects the catalog the request is compared against: the pinned snapshot
+// (catalog_version or as_of) or the live one. The version is nil when the source is not versioned.
+func (s *CompareServiceImpl) resolveCatalog(req domain.CompareRequest) (data.CatalogRepository, *domain.CatalogVersion, *domain.ErrorResponse) {
+	if req.CatalogVersion != nil && req.AsOf != nil {
+		return nil, nil, &domain.ErrorResponse{
+			ErrorCode: domain.ErrorCodeInvalidRequest,
+			Message:   "Use either catalog_version or as_of, not both.",
+		}
+	}
+
+	if !req.PinsCatalog() {
+		catalog, version := liveCatalog(s.repo)
+		return catalog, version, nil
+	}
+
+	versioned, ok := s.repo.(data.VersionedCatalog)
+	if !ok {
+		return nil, nil, &domain.ErrorResponse{
+			ErrorCode: domain.ErrorCodeCatalogVersionNotFound,
+			Message:   "Catalog versions are not available for this catalog source.",
+		}
+	}
+
+	var snapshot *data.Snapshot
+	if req.CatalogVersion != nil {
+		snapshot, ok = versioned.SnapshotByVersion(*req.CatalogVersion)
+	} else {
+		snapshot, ok = versioned.SnapshotAt(*req.AsOf)
+	}
+
+	if !ok {
+		s.logger.Info("catalog version not retained",
+			zap.Stringp("catalog_version", req.CatalogVersion),
+			zap.Timep("as_of", req.AsOf),
+		)
+		return nil, nil, &domain.ErrorResponse{
+			ErrorCode: domain.ErrorCodeCatalogVersionNotFound,
+			Message:   "The requested catalog version is not available.",
+		}
+	}
+
+	version := snapshot.Version
+	return snapshot, &version, nil
+}
+
+// withPriceTrends attaches the price trend of each item when the price history is enabled
+func (s *CompareServiceImpl) withPriceTrends(items []domain.Item, at time.Time) []domain.Item {
+	if s.priceHistory == nil {
+		return items
+	}
+
+	for i := range items {
+		items[i].PriceTrend = s.priceHistory.Trend(items[i].ID, items[i].Price, at)
+	}
+	return items
+}
+
+// cacheKeyInput holds, in canonical form, every input that changes a comparison
+type cacheKeyInput struct {
+	IDs            []string   `json:"ids"`
+	Fields         *[]string  `json:"fields"`
+	Strategy       string     `json:"strategy"`
+	Metrics        []string   `json:"metrics,omitempty"`
+	Currency       string     `json:"currency"`
+	RatesUpdatedAt *time.Time `json:"rates_updated_at,omitempty"`
+	Unavailable    string     `json:"unavailable"`
+	CatalogVersion string     `json:"catalog_version,omitempty"`
+	AsOf           string     `json:"as_of,omitempty"`
+}
+
+// GenerateCacheKey hashes the canonical form of the request: unique sorted IDs and fields,
+// strategy, metric overrides, target currency (and exchange-rate table when converting to
+// another currency), unavailable policy and pinned catalog version.
+// The live catalog version is left out on purpose: a reload evicts only the comparisons of
+// the changed items, and a new exchange-rate table the converted ones, so the others survive it.
+// The locale is not hashed here either: callers namespace the key by locale, since each entry
+// holds the body encoded in one language.
+func (s *CompareServiceImpl) GenerateCacheKey(req domain.CompareRequest) string {
+	// Unique sorted IDs, canonical fields, normalized currency and explicit unavailable policy
+	canonical := req.Canonical()
+
+	input := cacheKeyInput{
+		IDs:         canonical.Ids,
+		Fields:      canonical.Fields,
+		Strategy:    defaultStrategy,
+		Currency:    s.currency,
+		Unavailable: string(canonical.Unavailable),
+	}
+
+	for field, metric := range s.metrics {
+		input.Metrics = append(input.Metrics, field+"="+string(metric))
+	}
+	sort.Strings(input.Metrics)
+
+	if canonical.Currency != "" {
+		input.Currency = canonical.Currency // An invalid one is kept apart, it fails anyway
+	}
+	if input.Currency != s.currency && s.rates != nil {
+		if rates := s.rates.Current(); rates != nil {
+			input.RatesUpdatedAt = &rates.UpdatedAt
+		}
+	}
+
+	switch {
+	case req.CatalogVersion != nil:
+		input.CatalogVersion = *req.CatalogVersion
+	case req.AsOf != nil:
+		input.AsOf = req.AsOf.UTC().Format(time.RFC3339Nano)
+	}
 
 	// Generate SHA-256 hash
-	joinedIDs := strings.Join(uniqueIDs, ",")
-	hash := sha256.Sum256([]byte(joinedIDs))
+	encoded, _ := json.Marshal(input)
+	hash := sha256.Sum256(encoded)
 	return hex.EncodeToString(hash[:])
 }
 
@@ -2,9 +2,17 @@ package service
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
 	"testing"
+	"time"
 
+	"github.com/mmedinam1600/product-comparison-api/internal/data"
 	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"github.com/mmedinam1600/product-comparison-api/internal/service/strategy"
 	"go.uber.org/zap"
 )
 
@@ -14,7 +22,11 @@ type MockCatalogRepository struct {
 	shouldError bool
 }
 
-func (m *MockCatalogRepository) GetByIDs(ctx context.Context, ids []string) ([]domain.Item, []string) {
+func (m *MockCatalogRepository) GetByIDs(ctx context.Context, ids []string) ([]domain.Item, []string, error) {
+	if m.shouldError {
+		return nil, nil, errors.New("catalog unavailable")
+	}
+
 	found := []domain.Item{}
 	missing := []string{}
 
@@ -26,15 +38,34 @@ func (m *MockCatalogRepository) GetByIDs(ctx context.Context, ids []string) ([]d
 		}
 	}
 
-	return found, missing
+	return found, missing, nil
 }
 
-func (m *MockCatalogRepository) GetAll(ctx context.Context) []domain.Item {
+func (m *MockCatalogRepository) GetAll(ctx context.Context) ([]domain.Item, error) {
+	if m.shouldError {
+		return nil, errors.New("catalog unavailable")
+	}
+
 	items := make([]domain.Item, 0, len(m.items))
 	for _, item := range m.items {
 		items = append(items, item)
 	}
-	return items
+	return items, nil
+}
+
+func (m *MockCatalogRepository) GetVariants(ctx context.Context, id string) ([]domain.Item, bool, error) {
+	if m.shouldError {
+		return nil, false, errors.New("catalog unavailable")
+	}
+
+	variants := []domain.Item{}
+	for _, item := range m.items {
+		if item.ParentID == id {
+			variants = append(variants, item)
+		}
+	}
+	sort.Slice(variants, func(i, j int) bool { return variants[i].ID < variants[j].ID })
+	return variants, len(variants) > 0, nil
 }
 
 func TestNewCompareService(t *testing.T) {
@@ -77,7 +108,7 @@ func TestCompareService_GenerateCacheKey(t *testing.T) {
 		{
 			name:     "Same IDs in different order produce same key",
 			ids:      []string{"a", "b", "c"},
-			expected: service.GenerateCacheKey([]string{"c", "a", "b"}),
+			expected: service.GenerateCacheKey(domain.CompareRequest{Ids: []string{"c", "a", "b"}}),
 		},
 		{
 			name: "Duplicate IDs produce same key as unique",
@@ -91,15 +122,19 @@ func TestCompareService_GenerateCacheKey(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			key1 := service.GenerateCacheKey(tt.ids)
+			key1 := service.GenerateCacheKey(domain.CompareRequest{Ids: tt.ids})
 
 			// Verify key is not empty
 			if key1 == "" {
 				t.Error("Expected non-empty cache key")
 			}
 
+			if tt.expected != "" && key1 != tt.expected {
+				t.Errorf("Expected key %s, got %s", tt.expected, key1)
+			}
+
 			// Verify key is deterministic
-			key2 := service.GenerateCacheKey(tt.ids)
+			key2 := service.GenerateCacheKey(domain.CompareRequest{Ids: tt.ids})
 			if key1 != key2 {
 				t.Errorf("Expected same key for same input, got %s and %s", key1, key2)
 			}
@@ -112,6 +147,124 @@ func TestCompareService_GenerateCacheKey(t *testing.T) {
 	}
 }
 
+func TestCompareService_GenerateCacheKey_CoversEveryInput(t *testing.T) {
+	logger := zap.NewNop()
+	fields := func(f ...string) *[]string { return &f }
+	version := func(v string) *string { return &v }
+	asOf := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	ids := []string{"a", "b"}
+
+	service := NewCompareService(&MockCatalogRepository{}, logger)
+	base := service.GenerateCacheKey(domain.CompareRequest{Ids: ids})
+
+	different := []struct {
+		name string
+		req  domain.CompareRequest
+	}{
+		{name: "A subset of fields", req: domain.CompareRequest{Ids: ids, Fields: fields("price")}},
+		{name: "Another subset of fields", req: domain.CompareRequest{Ids: ids, Fields: fields("rating")}},
+		{name: "Other currency", req: domain.CompareRequest{Ids: ids, Currency: "MXN"}},
+		{name: "Other unavailable policy", req: domain.CompareRequest{Ids: ids, Unavailable: domain.UnavailableExclude}},
+		{name: "Pinned catalog version", req: domain.CompareRequest{Ids: ids, CatalogVersion: version("v1")}},
+		{name: "Pinned instant", req: domain.CompareRequest{Ids: ids, AsOf: &asOf}},
+	}
+	seen := map[string]string{base: "base"}
+	for _, tt := range different {
+		key := service.GenerateCacheKey(tt.req)
+		if other, exists := seen[key]; exists {
+			t.Errorf("%s: same key as %s", tt.name, other)
+		}
+		seen[key] = tt.name
+	}
+
+	equivalent := []struct {
+		name string
+		req  domain.CompareRequest
+	}{
+		{name: "Empty fields list", req: domain.CompareRequest{Ids: ids, Fields: fields()}},
+		{name: "Default currency", req: domain.CompareRequest{Ids: ids, Currency: "usd"}},
+		{name: "Default unavailable policy", req: domain.CompareRequest{Ids: ids, Unavailable: domain.UnavailableFlag}},
+	}
+	for _, tt := range equivalent {
+		if key := service.GenerateCacheKey(tt.req); key != base {
+			t.Errorf("%s: expected the key of the plain request", tt.name)
+		}
+	}
+
+	// Fields are canonical: order, duplicates and blanks do not matter
+	sorted := service.GenerateCacheKey(domain.CompareRequest{Ids: ids, Fields: fields("price", "rating")})
+	if key := service.GenerateCacheKey(domain.CompareRequest{Ids: ids, Fields: fields("rating", " price", "rating", "")}); key != sorted {
+		t.Error("Expected equivalent field lists to share the key")
+	}
+
+	// Metric overrides change the best items, so storefront settings are part of the key
+	overridden := NewCompareService(&MockCatalogRepository{}, logger,
+		WithMetricOverrides(strategy.MetricOverrides{"price": domain.HigherIsBetter}))
+	if overridden.GenerateCacheKey(domain.CompareRequest{Ids: ids}) == base {
+		t.Error("Expected metric overrides to change the key")
+	}
+	otherCurrency := NewCompareService(&MockCatalogRepository{}, logger, WithCurrency("MXN"))
+	if otherCurrency.GenerateCacheKey(domain.CompareRequest{Ids: ids}) == base {
+		t.Error("Expected the catalog currency to change the key")
+	}
+}
+
+func TestCompareService_GenerateCacheKey_SurvivesReloads(t *testing.T) {
+	v1, _ := data.NewSnapshot([]domain.Item{{ID: "a", Price: 1}, {ID: "b", Price: 2}}, time.Now())
+	v2, _ := data.NewSnapshot([]domain.Item{{ID: "a", Price: 3}, {ID: "b", Price: 2}}, time.Now())
+	catalog := &MockVersionedCatalog{snapshots: []*data.Snapshot{v1}}
+	rates := &staticRates{&domain.ExchangeRates{Base: "USD", Rates: map[string]float64{"MXN": 18}, UpdatedAt: time.Now()}}
+	service := NewCompareService(catalog, zap.NewNop(), WithExchangeRates(rates))
+	plain := domain.CompareRequest{Ids: []string{"a", "b"}}
+	converted := domain.CompareRequest{Ids: []string{"a", "b"}, Currency: "MXN"}
+
+	plainKey, convertedKey := service.GenerateCacheKey(plain), service.GenerateCacheKey(converted)
+	catalog.snapshots = append(catalog.snapshots, v2)
+	if service.GenerateCacheKey(plain) != plainKey {
+		t.Error("Expected a new live catalog version to keep the key, the reload evicts the changed items")
+	}
+
+	rates.rates = &domain.ExchangeRates{Base: "USD", Rates: map[string]float64{"MXN": 19}, UpdatedAt: time.Now().Add(time.Minute)}
+	if service.GenerateCacheKey(plain) != plainKey {
+		t.Error("Expected new exchange rates to keep the key of a request without conversion")
+	}
+	if service.GenerateCacheKey(converted) == convertedKey {
+		t.Error("Expected new exchange rates to change the key of a converted request")
+	}
+}
+
+// A comparison cached for some fields must never answer a request for other fields
+func TestCompareService_CacheKey_NoCrossFieldPoisoning(t *testing.T) {
+	repo := &MockCatalogRepository{
+		items: map[string]domain.Item{
+			"a": {ID: "a", Price: 10, Rating: 4.0},
+			"b": {ID: "b", Price: 20, Rating: 4.5},
+		},
+	}
+	service := NewCompareService(repo, zap.NewNop())
+	ctx := context.Background()
+	fields := []string{"price"}
+
+	responses := map[string][]string{}
+	for _, req := range []domain.CompareRequest{
+		{Ids: []string{"a", "b"}},
+		{Ids: []string{"b", "a"}, Fields: &fields},
+	} {
+		_, metadata, errResp := service.Compare(ctx, req)
+		if errResp != nil {
+			t.Fatalf("Unexpected error: %v", errResp.Message)
+		}
+		key := service.GenerateCacheKey(req)
+		if cached, exists := responses[key]; exists && !reflect.DeepEqual(cached, metadata.ResolvedFields) {
+			t.Fatalf("Key %s shared by results with fields %v and %v", key, cached, metadata.ResolvedFields)
+		}
+		responses[key] = metadata.ResolvedFields
+	}
+	if len(responses) != 2 {
+		t.Errorf("Expected 2 cache entries, got %d", len(responses))
+	}
+}
+
 func TestCompareService_Compare_ValidateIDs(t *testing.T) {
 	logger := zap.NewNop()
 	repo := &MockCatalogRepository{
@@ -211,6 +364,23 @@ func TestCompareService_Compare_MissingIDs(t *testing.T) {
 	}
 }
 
+// A catalog that cannot be read is a server error, not a list of missing products
+func TestCompareService_Compare_CatalogError(t *testing.T) {
+	service := NewCompareService(&MockCatalogRepository{shouldError: true}, zap.NewNop())
+
+	_, _, errResp := service.Compare(context.Background(), domain.CompareRequest{Ids: []string{"id1", "id2"}})
+
+	if errResp == nil {
+		t.Fatal("Expected error for unreadable catalog")
+	}
+	if errResp.ErrorCode != domain.ErrorCodeInternal || errResp.ErrorCode.HTTPStatusCode() != http.StatusInternalServerError {
+		t.Errorf("Expected ErrorCodeInternal (500), got %v", errResp.ErrorCode)
+	}
+	if len(errResp.MissingIDs) != 0 {
+		t.Errorf("Expected no missing IDs, got %v", errResp.MissingIDs)
+	}
+}
+
 func TestCompareService_Compare_UnknownFields(t *testing.T) {
 	logger := zap.NewNop()
 	repo := &MockCatalogRepository{
@@ -426,3 +596,516 @@ func TestCompareService_GetUniqueIDs(t *testing.T) {
 		})
 	}
 }
+
+// MockVersionedCatalog serves a fixed list of snapshots, the last one being live
+type MockVersionedCatalog struct {
+	snapshots []*data.Snapshot
+}
+
+var _ data.VersionedCatalog = (*MockVersionedCatalog)(nil)
+
+func (m *MockVersionedCatalog) OnPublish(fn data.PublishListener) {}
+
+func (m *MockVersionedCatalog) GetByIDs(ctx context.Context, ids []string) ([]domain.Item, []string, error) {
+	return m.CurrentSnapshot().GetByIDs(ctx, ids)
+}
+
+func (m *MockVersionedCatalog) GetAll(ctx context.Context) ([]domain.Item, error) {
+	return m.CurrentSnapshot().GetAll(ctx)
+}
+
+func (m *MockVersionedCatalog) GetVariants(ctx context.Context, id string) ([]domain.Item, bool, error) {
+	return m.CurrentSnapshot().GetVariants(ctx, id)
+}
+
+func (m *MockVersionedCatalog) CurrentSnapshot() *data.Snapshot {
+	return m.snapshots[len(m.snapshots)-1]
+}
+
+func (m *MockVersionedCatalog) SnapshotByVersion(id string) (*data.Snapshot, bool) {
+	for _, snapshot := range m.snapshots {
+		if snapshot.Version.ID == id {
+			return snapshot, true
+		}
+	}
+	return nil, false
+}
+
+func (m *MockVersionedCatalog) SnapshotAt(at time.Time) (*data.Snapshot, bool) {
+	for i := len(m.snapshots) - 1; i >= 0; i-- {
+		if !m.snapshots[i].Version.CreatedAt.After(at) {
+			return m.snapshots[i], true
+		}
+	}
+	return nil, false
+}
+
+func (m *MockVersionedCatalog) Versions() []domain.CatalogVersion {
+	versions := []domain.CatalogVersion{}
+	for i := len(m.snapshots) - 1; i >= 0; i-- {
+		versions = append(versions, m.snapshots[i].Version)
+	}
+	return versions
+}
+
+func TestCompareService_Compare_CatalogVersion(t *testing.T) {
+	logger := zap.NewNop()
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	yesterday, _ := data.NewSnapshot([]domain.Item{
+		{ID: "id1", Name: "Item 1", Price: 10.0, Rating: 4.0},
+		{ID: "id2", Name: "Item 2", Price: 20.0, Rating: 4.5},
+	}, base)
+	today, _ := data.NewSnapshot([]domain.Item{
+		{ID: "id1", Name: "Item 1", Price: 30.0, Rating: 4.0},
+		{ID: "id2", Name: "Item 2", Price: 20.0, Rating: 4.5},
+	}, base.Add(24*time.Hour))
+
+	service := NewCompareService(&MockVersionedCatalog{snapshots: []*data.Snapshot{yesterday, today}}, logger)
+	ctx := context.Background()
+
+	versionID := yesterday.Version.ID
+	asOf := base.Add(time.Hour)
+	tooOld := base.Add(-time.Hour)
+	unknown := "unknown-version"
+
+	tests := []struct {
+		name            string
+		request         domain.CompareRequest
+		expectedVersion string
+		expectedPrice   float64
+		expectedError   domain.ErrorCode
+	}{
+		{
+			name:            "Live catalog by default",
+			request:         domain.CompareRequest{Ids: []string{"id1", "id2"}},
+			expectedVersion: today.Version.ID,
+			expectedPrice:   30.0,
+		},
+		{
+			name:            "Pinned by catalog_version",
+			request:         domain.CompareRequest{Ids: []string{"id1", "id2"}, CatalogVersion: &versionID},
+			expectedVersion: yesterday.Version.ID,
+			expectedPrice:   10.0,
+		},
+		{
+			name:            "Pinned by as_of",
+			request:         domain.CompareRequest{Ids: []string{"id1", "id2"}, AsOf: &asOf},
+			expectedVersion: yesterday.Version.ID,
+			expectedPrice:   10.0,
+		},
+		{
+			name:          "as_of older than retained versions",
+			request:       domain.CompareRequest{Ids: []string{"id1", "id2"}, AsOf: &tooOld},
+			expectedError: domain.ErrorCodeCatalogVersionNotFound,
+		},
+		{
+			name:          "Unknown catalog_version",
+			request:       domain.CompareRequest{Ids: []string{"id1", "id2"}, CatalogVersion: &unknown},
+			expectedError: domain.ErrorCodeCatalogVersionNotFound,
+		},
+		{
+			name:          "Both catalog_version and as_of",
+			request:       domain.CompareRequest{Ids: []string{"id1", "id2"}, CatalogVersion: &versionID, AsOf: &asOf},
+			expectedError: domain.ErrorCodeInvalidRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, metadata, errResp := service.Compare(ctx, tt.request)
+
+			if tt.expectedError != "" {
+				if errResp == nil || errResp.ErrorCode != tt.expectedError {
+					t.Fatalf("Expected error %v, got %v", tt.expectedError, errResp)
+				}
+				return
+			}
+			if errResp != nil {
+				t.Fatalf("Expected no error, got: %v", errResp.Message)
+			}
+
+			if metadata.CatalogVersion == nil || metadata.CatalogVersion.ID != tt.expectedVersion {
+				t.Errorf("Expected catalog version %v, got %v", tt.expectedVersion, metadata.CatalogVersion)
+			}
+			if result.Diff["price"].Values["id1"] != tt.expectedPrice {
+				t.Errorf("Expected price %v for id1, got %v", tt.expectedPrice, result.Diff["price"].Values["id1"])
+			}
+		})
+	}
+}
+
+func TestCompareService_Compare_PinnedWithoutVersionedCatalog(t *testing.T) {
+	repo := &MockCatalogRepository{
+		items: map[string]domain.Item{
+			"id1": {ID: "id1", Price: 10.0},
+			"id2": {ID: "id2", Price: 20.0},
+		},
+	}
+	service := NewCompareService(repo, zap.NewNop())
+
+	version := "any"
+	_, metadata, errResp := service.Compare(context.Background(), domain.CompareRequest{Ids: []string{"id1", "id2"}})
+	if errResp != nil || metadata.CatalogVersion != nil {
+		t.Errorf("Expected live comparison without catalog version, got %v / %v", errResp, metadata.CatalogVersion)
+	}
+
+	_, _, errResp = service.Compare(context.Background(), domain.CompareRequest{Ids: []string{"id1", "id2"}, CatalogVersion: &version})
+	if errResp == nil || errResp.ErrorCode != domain.ErrorCodeCatalogVersionNotFound {
+		t.Errorf("Expected CatalogVersionNotFound, got %v", errResp)
+	}
+}
+
+func TestCompareService_Compare_PriceTrend(t *testing.T) {
+	logger := zap.NewNop()
+	now := time.Now().UTC()
+
+	history, _ := data.NewPriceHistory("", 0, logger)
+	previous, _ := data.NewSnapshot([]domain.Item{
+		{ID: "id1", Price: 50.0},
+		{ID: "id2", Price: 40.0},
+	}, now.Add(-10*24*time.Hour))
+	current, _ := data.NewSnapshot([]domain.Item{
+		{ID: "id1", Price: 40.0},
+		{ID: "id2", Price: 45.0},
+	}, now.Add(-time.Hour))
+	history.Record(previous)
+	history.Record(current)
+
+	service := NewCompareService(
+		&MockVersionedCatalog{snapshots: []*data.Snapshot{previous, current}},
+		logger,
+		WithPriceHistory(history),
+	)
+
+	result, _, errResp := service.Compare(context.Background(), domain.CompareRequest{Ids: []string{"id1", "id2"}})
+	if errResp != nil {
+		t.Fatalf("Expected no error, got: %v", errResp.Message)
+	}
+
+	atLowest := result.Diff["price_trend.at_lowest"]
+	if len(atLowest.Best) != 1 || atLowest.Best[0] != "id1" {
+		t.Errorf("Expected id1 to be the only item at its lowest price, got %v", atLowest.Best)
+	}
+
+	change := result.Diff["price_trend.change_pct"]
+	if change.Values["id1"] != -20.0 || change.Values["id2"] != 12.5 {
+		t.Errorf("Expected change_pct -20 and 12.5, got %v", change.Values)
+	}
+	if len(change.Best) != 1 || change.Best[0] != "id1" {
+		t.Errorf("Expected id1 to have the best price change, got %v", change.Best)
+	}
+}
+
+func TestCompareService_Compare_ExpandsParents(t *testing.T) {
+	logger := zap.NewNop()
+	repo := &MockCatalogRepository{
+		items: map[string]domain.Item{
+			"m":       {ID: "m", Name: "Mouse", Price: 25, Rating: 4.5, Variants: []string{"m-black", "m-white"}},
+			"m-black": {ID: "m-black", ParentID: "m", Name: "Mouse", Price: 25, Rating: 4.5},
+			"m-white": {ID: "m-white", ParentID: "m", Name: "Mouse", Price: 30, Rating: 4.5},
+			"k":       {ID: "k", Name: "Keyboard", Price: 80, Rating: 4.0},
+		},
+	}
+	service := NewCompareService(repo, logger)
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		ids           []string
+		expectedOrder []string
+		expectedError domain.ErrorCode
+	}{
+		{name: "A parent alone compares its variants", ids: []string{"m"}, expectedOrder: []string{"m-black", "m-white"}},
+		{name: "Parent next to another item", ids: []string{"k", "m"}, expectedOrder: []string{"k", "m-black", "m-white"}},
+		{name: "Specific variants", ids: []string{"m-white", "k"}, expectedOrder: []string{"m-white", "k"}},
+		{name: "Parent and one of its variants", ids: []string{"m-white", "m"}, expectedOrder: []string{"m-white", "m-black"}},
+		{name: "A plain item alone", ids: []string{"k"}, expectedError: domain.ErrorCodeAtLeastTwoIds},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, metadata, errResp := service.Compare(ctx, domain.CompareRequest{Ids: tt.ids})
+
+			if tt.expectedError != "" {
+				if errResp == nil || errResp.ErrorCode != tt.expectedError {
+					t.Fatalf("Expected error %s, got %v", tt.expectedError, errResp)
+				}
+				return
+			}
+			if errResp != nil {
+				t.Fatalf("Unexpected error: %v", errResp.Message)
+			}
+
+			if !reflect.DeepEqual(metadata.Order, tt.expectedOrder) {
+				t.Errorf("Order = %v, want %v", metadata.Order, tt.expectedOrder)
+			}
+			if len(result.Items) != len(tt.expectedOrder) {
+				t.Errorf("Expected %d items, got %d", len(tt.expectedOrder), len(result.Items))
+			}
+
+			_, expandedParent := metadata.Variants["m"]
+			if expandedParent != slices.Contains(tt.ids, "m") {
+				t.Errorf("Unexpected variants metadata: %v", metadata.Variants)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	items := []domain.Item{{ID: "a"}, {ID: "b"}}
+	fields := []string{"price", "rating", "specifications.wireless"}
+
+	tests := []struct {
+		name     string
+		diff     map[string]domain.DiffField
+		expected string
+	}{
+		{
+			name: "Leader",
+			diff: map[string]domain.DiffField{
+				"price":                   {Best: []string{"a"}},
+				"rating":                  {Best: []string{"a"}},
+				"specifications.wireless": {Best: []string{"b"}},
+			},
+			expected: "Compared 2 products on 3 fields; a is best on 2 of them.",
+		},
+		{
+			name: "Ties decide nothing",
+			diff: map[string]domain.DiffField{
+				"price":                   {Best: []string{"a"}},
+				"rating":                  {Best: []string{"b"}},
+				"specifications.wireless": {Best: []string{"a", "b"}},
+			},
+			expected: "Compared 2 products on 3 fields.",
+		},
+		{name: "No metrics", diff: map[string]domain.DiffField{"price": {Best: []string{}}}, expected: "Compared 2 products on 3 fields."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := summarize(items, fields, tt.diff); got != tt.expected {
+				t.Errorf("summarize() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// staticRates is an ExchangeRateSource with a fixed table
+type staticRates struct {
+	rates *domain.ExchangeRates
+}
+
+func (s staticRates) Current() *domain.ExchangeRates {
+	return s.rates
+}
+
+func TestCompareService_Compare_ConvertsCurrencies(t *testing.T) {
+	logger := zap.NewNop()
+	updatedAt := time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+	repo := &MockCatalogRepository{
+		items: map[string]domain.Item{
+			"us": {ID: "us", Name: "US", Price: 100},
+			"mx": {ID: "mx", Name: "MX", Price: 1500, Currency: "MXN"},
+		},
+	}
+	service := NewCompareService(repo, logger, WithExchangeRates(staticRates{&domain.ExchangeRates{
+		Base:      "USD",
+		UpdatedAt: updatedAt,
+		Rates:     map[string]float64{"MXN": 17.0, "BRL": 5.0},
+	}}))
+	ctx := context.Background()
+
+	tests := []struct {
+		name             string
+		currency         string
+		expectedCurrency string
+		expectedPrices   map[string]float64
+		expectedRates    map[string]float64
+		expectedBest     string
+		expectedError    domain.ErrorCode
+	}{
+		{
+			name:             "Catalog currency by default",
+			expectedCurrency: "USD",
+			expectedPrices:   map[string]float64{"us": 100, "mx": 88.24},
+			expectedRates:    map[string]float64{"MXN": 1.0 / 17.0},
+			expectedBest:     "mx",
+		},
+		{
+			name:             "Requested currency, case-insensitive",
+			currency:         "brl",
+			expectedCurrency: "BRL",
+			expectedPrices:   map[string]float64{"us": 500, "mx": 441.18},
+			expectedRates:    map[string]float64{"USD": 5.0, "MXN": 5.0 / 17.0},
+			expectedBest:     "mx",
+		},
+		{name: "Invalid currency", currency: "euro", expectedError: domain.ErrorCodeUnsupportedCurrency},
+		{name: "Currency without rate", currency: "EUR", expectedError: domain.ErrorCodeUnsupportedCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, metadata, errResp := service.Compare(ctx, domain.CompareRequest{Ids: []string{"us", "mx"}, Currency: tt.currency})
+
+			if tt.expectedError != "" {
+				if errResp == nil || errResp.ErrorCode != tt.expectedError {
+					t.Fatalf("Expected error %s, got %v", tt.expectedError, errResp)
+				}
+				return
+			}
+			if errResp != nil {
+				t.Fatalf("Unexpected error: %v", errResp.Message)
+			}
+
+			if metadata.Currency != tt.expectedCurrency {
+				t.Errorf("Currency = %s, want %s", metadata.Currency, tt.expectedCurrency)
+			}
+			if metadata.Conversion == nil || !metadata.Conversion.RatesUpdatedAt.Equal(updatedAt) {
+				t.Fatalf("Expected the conversion metadata, got %+v", metadata.Conversion)
+			}
+			if !reflect.DeepEqual(metadata.Conversion.Rates, tt.expectedRates) {
+				t.Errorf("Rates = %v, want %v", metadata.Conversion.Rates, tt.expectedRates)
+			}
+
+			for _, item := range result.Items {
+				if item.Price != tt.expectedPrices[item.ID] || item.Currency != tt.expectedCurrency {
+					t.Errorf("%s: price %v %s, want %v %s", item.ID, item.Price, item.Currency, tt.expectedPrices[item.ID], tt.expectedCurrency)
+				}
+				// Only converted items carry their original price
+				original := repo.items[item.ID]
+				originalCurrency := original.Currency
+				if originalCurrency == "" {
+					originalCurrency = DefaultCurrency
+				}
+				if originalCurrency == tt.expectedCurrency {
+					if item.OriginalPrice != nil {
+						t.Errorf("%s: unexpected original price %+v", item.ID, item.OriginalPrice)
+					}
+				} else if item.OriginalPrice == nil || *item.OriginalPrice != (domain.Money{Amount: original.Price, Currency: originalCurrency}) {
+					t.Errorf("%s: original price = %+v", item.ID, item.OriginalPrice)
+				}
+			}
+
+			// The best price is computed on the converted amounts (1500 MXN < 100 USD)
+			if best := result.Diff["price"].Best; len(best) != 1 || best[0] != tt.expectedBest {
+				t.Errorf("Best price = %v, want [%s]", best, tt.expectedBest)
+			}
+		})
+	}
+}
+
+func TestCompareService_Compare_WithoutExchangeRates(t *testing.T) {
+	repo := &MockCatalogRepository{
+		items: map[string]domain.Item{
+			"us":  {ID: "us", Price: 100},
+			"mx":  {ID: "mx", Price: 1500, Currency: "MXN"},
+			"us2": {ID: "us2", Price: 90},
+		},
+	}
+	service := NewCompareService(repo, zap.NewNop())
+	ctx := context.Background()
+
+	// Same currency: nothing to convert
+	_, metadata, errResp := service.Compare(ctx, domain.CompareRequest{Ids: []string{"us", "us2"}, Currency: "usd"})
+	if errResp != nil || metadata.Currency != "USD" || metadata.Conversion != nil {
+		t.Errorf("Expected an unconverted USD comparison, got %+v (%v)", metadata, errResp)
+	}
+
+	_, _, errResp = service.Compare(ctx, domain.CompareRequest{Ids: []string{"us", "mx"}})
+	if errResp == nil || errResp.ErrorCode != domain.ErrorCodeUnsupportedCurrency {
+		t.Errorf("Expected UnsupportedCurrency, got %v", errResp)
+	}
+}
+
+func TestCompareService_Compare_Unavailable(t *testing.T) {
+	logger := zap.NewNop()
+	repo := &MockCatalogRepository{
+		items: map[string]domain.Item{
+			"old":   {ID: "old", Price: 10, Rating: 4.0, Availability: &domain.Availability{Status: domain.Discontinued}},
+			"cheap": {ID: "cheap", Price: 20, Rating: 4.5, Availability: &domain.Availability{Status: domain.InStock}},
+			"late":  {ID: "late", Price: 30, Rating: 4.8, Availability: &domain.Availability{Status: domain.Backorder, EstimatedShipDate: "2026-11-02"}},
+		},
+	}
+	service := NewCompareService(repo, logger)
+	ctx := context.Background()
+
+	tests := []struct {
+		name            string
+		ids             []string
+		policy          domain.UnavailablePolicy
+		expectedOrder   []string
+		expectedBest    string
+		expectedWarning domain.WarningCode
+		expectedError   domain.ErrorCode
+	}{
+		{
+			name:            "Flagged by default: compared but never best",
+			ids:             []string{"old", "cheap", "late"},
+			expectedOrder:   []string{"old", "cheap", "late"},
+			expectedBest:    "cheap",
+			expectedWarning: domain.WarningUnavailableItems,
+		},
+		{
+			name:            "Excluded",
+			ids:             []string{"old", "cheap", "late"},
+			policy:          domain.UnavailableExclude,
+			expectedOrder:   []string{"cheap", "late"},
+			expectedBest:    "cheap",
+			expectedWarning: domain.WarningUnavailableItemsExcluded,
+		},
+		{
+			name:          "Included",
+			ids:           []string{"old", "cheap"},
+			policy:        domain.UnavailableInclude,
+			expectedOrder: []string{"old", "cheap"},
+			expectedBest:  "old",
+		},
+		{
+			name:          "Nothing unavailable",
+			ids:           []string{"cheap", "late"},
+			expectedOrder: []string{"cheap", "late"},
+			expectedBest:  "cheap",
+		},
+		{name: "Excluding leaves one item", ids: []string{"old", "cheap"}, policy: domain.UnavailableExclude, expectedError: domain.ErrorCodeAtLeastTwoIds},
+		{name: "Invalid policy", ids: []string{"old", "cheap"}, policy: "hide", expectedError: domain.ErrorCodeInvalidRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, metadata, errResp := service.Compare(ctx, domain.CompareRequest{Ids: tt.ids, Unavailable: tt.policy})
+
+			if tt.expectedError != "" {
+				if errResp == nil || errResp.ErrorCode != tt.expectedError {
+					t.Fatalf("Expected error %s, got %v", tt.expectedError, errResp)
+				}
+				return
+			}
+			if errResp != nil {
+				t.Fatalf("Unexpected error: %v", errResp.Message)
+			}
+
+			if !reflect.DeepEqual(metadata.Order, tt.expectedOrder) || len(result.Items) != len(tt.expectedOrder) {
+				t.Errorf("Order = %v with %d items, want %v", metadata.Order, len(result.Items), tt.expectedOrder)
+			}
+			if best := result.Diff["price"].Best; len(best) != 1 || best[0] != tt.expectedBest {
+				t.Errorf("Best price = %v, want [%s]", best, tt.expectedBest)
+			}
+			// Flagged items keep their values
+			if _, hasValue := result.Diff["price"].Values["old"]; hasValue != slices.Contains(tt.expectedOrder, "old") {
+				t.Errorf("Unexpected price values %v", result.Diff["price"].Values)
+			}
+
+			warnings := metadata.ComparePolicy.Warnings
+			if tt.expectedWarning == "" {
+				if len(warnings) != 0 {
+					t.Errorf("Expected no warnings, got %v", warnings)
+				}
+				return
+			}
+			if len(warnings) != 1 || warnings[0].Code != tt.expectedWarning || !reflect.DeepEqual(warnings[0].Items, []string{"old"}) {
+				t.Errorf("Expected warning %s for [old], got %v", tt.expectedWarning, warnings)
+			}
+		})
+	}
+}
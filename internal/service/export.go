@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"io"
+	"sort"
+
+	"github.com/mmedinam1600/product-comparison-api/internal/data"
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"go.uber.org/zap"
+)
+
+// exportContentTypes maps every export format to its media type
+var exportContentTypes = map[data.FeedFormat]string{
+	data.FeedFormatJSON:   "application/json; charset=utf-8",
+	data.FeedFormatNDJSON: "application/x-ndjson",
+	data.FeedFormatCSV:    "text/csv; charset=utf-8",
+}
+
+// CatalogExport is a set of catalog items pinned to one version, written on demand
+type CatalogExport struct {
+	Format      string                 // "json" | "ndjson" | "csv"
+	ContentType string                 // Media type of the written feed
+	Version     *domain.CatalogVersion // nil when the catalog source is not versioned
+	Count       int                    // Number of exported items
+
+	format data.FeedFormat
+	items  []domain.Item
+}
+
+// Write writes the items in the export format. The feed can be imported back as is.
+func (e *CatalogExport) Write(w io.Writer) error {
+	return data.ExportFeed(w, e.format, e.items)
+}
+
+// Export implements ItemService.Export. Items are the same the comparison engine reads
+// (variants already inherit from their parent), sorted by ID, with their translations.
+func (s *ItemServiceImpl) Export(ctx context.Context, format string, filter domain.ItemFilter) (*CatalogExport, *domain.ErrorResponse) {
+	if format == "" {
+		format = string(data.FeedFormatJSON)
+	}
+	feedFormat, err := data.ParseFeedFormat(format, "")
+	if err != nil {
+		return nil, &domain.ErrorResponse{
+			ErrorCode: domain.ErrorCodeInvalidRequest,
+			Message:   "Unsupported format. Use json, ndjson or csv.",
+		}
+	}
+
+	catalog, version := liveCatalog(s.repo)
+	all, err := catalog.GetAll(ctx)
+	if err != nil {
+		return nil, s.readFailed(err)
+	}
+	items := filterItems(all, filter)
+
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+
+	s.logger.Debug("catalog exported",
+		zap.String("format", string(feedFormat)),
+		zap.Int("items", len(items)),
+	)
+
+	return &CatalogExport{
+		Format:      string(feedFormat),
+		ContentType: exportContentTypes[feedFormat],
+		Version:     version,
+		Count:       len(items),
+		format:      feedFormat,
+		items:       items,
+	}, nil
+}
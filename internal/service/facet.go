@@ -0,0 +1,143 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+)
+
+const (
+	// DefaultFacetBuckets is the number of histogram buckets when the client does not send one
+	DefaultFacetBuckets = 5
+	// MaxFacetBuckets caps the histogram buckets
+	MaxFacetBuckets = 20
+)
+
+// facetAccumulator collects the values of one specification across the items
+type facetAccumulator struct {
+	unit        string
+	count       int
+	numbers     []float64
+	categorical map[string]int
+}
+
+// buildFacets aggregates the specifications of the items. A specification is numeric when
+// every value is a number; otherwise its values are counted as categories.
+func buildFacets(items []domain.Item, fields []string, buckets int) []domain.Facet {
+	wanted := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		wanted[strings.TrimPrefix(field, "specifications.")] = true
+	}
+
+	accumulators := make(map[string]*facetAccumulator)
+	for _, item := range items {
+		for key, raw := range item.Specifications {
+			if len(wanted) > 0 && !wanted[key] {
+				continue
+			}
+
+			value := item.FieldValue("specifications." + key)
+			switch value.(type) {
+			case map[string]interface{}, []interface{}, nil:
+				// Nested objects and lists are not faceted
+				continue
+			}
+
+			acc, exists := accumulators[key]
+			if !exists {
+				acc = &facetAccumulator{categorical: make(map[string]int)}
+				accumulators[key] = acc
+			}
+			if wrapped, ok := raw.(map[string]interface{}); ok && acc.unit == "" {
+				if unit, ok := wrapped["unit"].(string); ok {
+					acc.unit = unit
+				}
+			}
+
+			acc.count++
+			if number, ok := domain.NumericFieldValue(value); ok {
+				acc.numbers = append(acc.numbers, number)
+			}
+			acc.categorical[domain.FormatFieldValue(value)]++
+		}
+	}
+
+	keys := make([]string, 0, len(accumulators))
+	for key := range accumulators {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	facets := make([]domain.Facet, 0, len(keys))
+	for _, key := range keys {
+		acc := accumulators[key]
+		facet := domain.Facet{
+			Field: "specifications." + key,
+			Unit:  acc.unit,
+			Count: acc.count,
+		}
+
+		if len(acc.numbers) == acc.count {
+			facet.Type = domain.FacetTypeNumeric
+			low, high, histogram := numericHistogram(acc.numbers, buckets)
+			facet.Min, facet.Max, facet.Buckets = &low, &high, histogram
+		} else {
+			facet.Type = domain.FacetTypeCategorical
+			facet.Values = categoricalValues(acc.categorical)
+		}
+
+		facets = append(facets, facet)
+	}
+
+	return facets
+}
+
+// categoricalValues sorts the value counts, most frequent first
+func categoricalValues(counts map[string]int) []domain.FacetValue {
+	values := make([]domain.FacetValue, 0, len(counts))
+	for value, count := range counts {
+		values = append(values, domain.FacetValue{Value: value, Count: count})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Count != values[j].Count {
+			return values[i].Count > values[j].Count
+		}
+		return values[i].Value < values[j].Value
+	})
+	return values
+}
+
+// numericHistogram splits [min, max] in equal-width buckets.
+// A single bucket is returned when every value is the same.
+func numericHistogram(numbers []float64, buckets int) (float64, float64, []domain.FacetBucket) {
+	low, high := numbers[0], numbers[0]
+	for _, number := range numbers[1:] {
+		low = min(low, number)
+		high = max(high, number)
+	}
+
+	if low == high {
+		return low, high, []domain.FacetBucket{{Min: low, Max: high, Count: len(numbers)}}
+	}
+
+	width := (high - low) / float64(buckets)
+	histogram := make([]domain.FacetBucket, buckets)
+	for i := range histogram {
+		// Rounded so the bounds read cleanly (e.g. 0.1776 instead of 0.17759999999999998)
+		histogram[i].Min = math.Round((low+width*float64(i))*1e6) / 1e6
+		histogram[i].Max = math.Round((low+width*float64(i+1))*1e6) / 1e6
+	}
+	histogram[0].Min, histogram[buckets-1].Max = low, high
+
+	for _, number := range numbers {
+		i := int((number - low) / width)
+		if i >= buckets {
+			i = buckets - 1
+		}
+		histogram[i].Count++
+	}
+
+	return low, high, histogram
+}
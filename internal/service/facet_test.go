@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"go.uber.org/zap"
+)
+
+func newFacetRepo() *MockCatalogRepository {
+	return &MockCatalogRepository{
+		items: map[string]domain.Item{
+			"k1": {ID: "k1", Price: 50, Specifications: map[string]interface{}{"switch_type": "red", "wireless": true, "sensor_dpi": 1000.0}},
+			"k2": {ID: "k2", Price: 80, Specifications: map[string]interface{}{"switch_type": "blue", "wireless": true, "sensor_dpi": 2000.0}},
+			"k3": {ID: "k3", Price: 90, Specifications: map[string]interface{}{"switch_type": "red", "wireless": false, "sensor_dpi": 5000.0}},
+			"m1": {ID: "m1", Price: 20, Specifications: map[string]interface{}{
+				"screen_size": map[string]interface{}{"value": 27.0, "unit": "in"},
+			}},
+		},
+	}
+}
+
+func findFacet(facets []domain.Facet, field string) *domain.Facet {
+	for i := range facets {
+		if facets[i].Field == field {
+			return &facets[i]
+		}
+	}
+	return nil
+}
+
+func TestItemService_Facets(t *testing.T) {
+	svc := NewItemService(newFacetRepo(), nil, zap.NewNop())
+
+	set, _, errResp := svc.Facets(context.Background(), domain.FacetQuery{Buckets: 2})
+	if errResp != nil {
+		t.Fatalf("Unexpected error: %v", errResp)
+	}
+	if set.Total != 4 || len(set.Facets) != 4 {
+		t.Fatalf("Expected 4 items and 4 facets, got %d and %d", set.Total, len(set.Facets))
+	}
+
+	switchType := findFacet(set.Facets, "specifications.switch_type")
+	if switchType == nil || switchType.Type != domain.FacetTypeCategorical {
+		t.Fatalf("Expected a categorical switch_type facet, got %v", switchType)
+	}
+	if switchType.Values[0] != (domain.FacetValue{Value: "red", Count: 2}) {
+		t.Errorf("Expected red first with 2 items, got %v", switchType.Values)
+	}
+
+	wireless := findFacet(set.Facets, "specifications.wireless")
+	if wireless == nil || wireless.Type != domain.FacetTypeCategorical || len(wireless.Values) != 2 {
+		t.Errorf("Expected a categorical wireless facet with 2 values, got %v", wireless)
+	}
+
+	dpi := findFacet(set.Facets, "specifications.sensor_dpi")
+	if dpi == nil || dpi.Type != domain.FacetTypeNumeric {
+		t.Fatalf("Expected a numeric sensor_dpi facet, got %v", dpi)
+	}
+	if *dpi.Min != 1000 || *dpi.Max != 5000 {
+		t.Errorf("Expected range 1000-5000, got %v-%v", *dpi.Min, *dpi.Max)
+	}
+	if len(dpi.Buckets) != 2 || dpi.Buckets[0].Count != 2 || dpi.Buckets[1].Count != 1 {
+		t.Errorf("Expected buckets with 2 and 1 items, got %v", dpi.Buckets)
+	}
+
+	screen := findFacet(set.Facets, "specifications.screen_size")
+	if screen == nil || screen.Unit != "in" || len(screen.Buckets) != 1 {
+		t.Errorf("Expected a single-bucket screen_size facet in inches, got %v", screen)
+	}
+}
+
+func TestItemService_Facets_FilterAndFields(t *testing.T) {
+	svc := NewItemService(newFacetRepo(), nil, zap.NewNop())
+
+	set, _, _ := svc.Facets(context.Background(), domain.FacetQuery{
+		Filter: domain.ItemFilter{Equals: map[string][]string{"specifications.wireless": {"true"}}},
+		Fields: []string{"switch_type"},
+	})
+
+	if set.Total != 2 || len(set.Facets) != 1 {
+		t.Fatalf("Expected 2 items and only the switch_type facet, got %d and %v", set.Total, set.Facets)
+	}
+	if len(set.Facets[0].Values) != 2 {
+		t.Errorf("Expected red and blue counted once each, got %v", set.Facets[0].Values)
+	}
+}
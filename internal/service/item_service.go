@@ -0,0 +1,371 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/mmedinam1600/product-comparison-api/internal/data"
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultListLimit is the page size used when the client does not send one
+	DefaultListLimit = 20
+	// MaxListLimit caps the page size
+	MaxListLimit = 100
+)
+
+// ItemService define the contract for the item read use cases
+type ItemService interface {
+	// Get returns a single item of the live catalog
+	Get(ctx context.Context, id string) (domain.Item, *domain.CatalogVersion, *domain.ErrorResponse)
+
+	// List returns a page of the live catalog, filtered and sorted
+	List(ctx context.Context, query domain.ItemListQuery) (domain.ItemPage, *domain.CatalogVersion, *domain.ErrorResponse)
+
+	// Facets returns the distribution of the specifications of the items that match the filter
+	Facets(ctx context.Context, query domain.FacetQuery) (domain.FacetSet, *domain.CatalogVersion, *domain.ErrorResponse)
+
+	// Search returns the items of the live catalog that match a free text query, best first
+	Search(ctx context.Context, query string, limit int) ([]domain.SearchHit, *domain.CatalogVersion, *domain.ErrorResponse)
+
+	// PriceHistory returns the recorded prices of an item and its current trend
+	PriceHistory(ctx context.Context, id string) (domain.PriceHistory, *domain.ErrorResponse)
+
+	// Export returns the items of the live catalog that match the filter, ready to be written in a feed format
+	Export(ctx context.Context, format string, filter domain.ItemFilter) (*CatalogExport, *domain.ErrorResponse)
+}
+
+// ItemServiceImpl implements ItemService
+type ItemServiceImpl struct {
+	repo         data.CatalogRepository
+	priceHistory *data.PriceHistory // nil when the catalog source is not versioned
+	logger       *zap.Logger
+}
+
+// NewItemService creates a new instance of the service
+func NewItemService(repo data.CatalogRepository, priceHistory *data.PriceHistory, logger *zap.Logger) *ItemServiceImpl {
+	return &ItemServiceImpl{
+		repo:         repo,
+		priceHistory: priceHistory,
+		logger:       logger,
+	}
+}
+
+// listCursor is the keyset position encoded in the opaque cursor
+type listCursor struct {
+	Sort  domain.ItemSort `json:"s"`
+	Value float64         `json:"v,omitempty"`
+	ID    string          `json:"id"`
+}
+
+// Get implements ItemService.Get
+func (s *ItemServiceImpl) Get(ctx context.Context, id string) (domain.Item, *domain.CatalogVersion, *domain.ErrorResponse) {
+	catalog, version := liveCatalog(s.repo)
+
+	items, _, err := catalog.GetByIDs(ctx, []string{id})
+	if err != nil {
+		return domain.Item{}, nil, s.readFailed(err)
+	}
+	if len(items) == 0 {
+		return domain.Item{}, nil, &domain.ErrorResponse{
+			ErrorCode:  domain.ErrorCodeIdNotFound,
+			Message:    "Product not found.",
+			MissingIDs: []string{id},
+		}
+	}
+
+	item := items[0]
+	if s.priceHistory != nil {
+		item.PriceTrend = s.priceHistory.Trend(item.ID, item.Price, time.Now())
+	}
+
+	return item, version, nil
+}
+
+// List implements ItemService.List using keyset pagination: the cursor holds the sort value
+// and ID of the last item returned, so pages stay stable while the client walks them.
+func (s *ItemServiceImpl) List(ctx context.Context, query domain.ItemListQuery) (domain.ItemPage, *domain.CatalogVersion, *domain.ErrorResponse) {
+	if query.Sort == "" {
+		query.Sort = domain.SortByID
+	}
+	if !query.Sort.IsValid() {
+		return domain.ItemPage{}, nil, &domain.ErrorResponse{
+			ErrorCode: domain.ErrorCodeInvalidRequest,
+			Message:   "Unsupported sort. Use id, price, -price, rating or -rating.",
+		}
+	}
+	if query.Limit <= 0 {
+		query.Limit = DefaultListLimit
+	}
+	if query.Limit > MaxListLimit {
+		query.Limit = MaxListLimit
+	}
+
+	var after *listCursor
+	if query.Cursor != "" {
+		cursor, err := decodeListCursor(query.Cursor)
+		if err != nil || cursor.Sort != query.Sort {
+			return domain.ItemPage{}, nil, &domain.ErrorResponse{
+				ErrorCode: domain.ErrorCodeInvalidRequest,
+				Message:   "Invalid cursor for this listing.",
+			}
+		}
+		after = &cursor
+	}
+
+	catalog, version := liveCatalog(s.repo)
+
+	// === STEP 1: Filter ===
+	all, err := catalog.GetAll(ctx)
+	if err != nil {
+		return domain.ItemPage{}, nil, s.readFailed(err)
+	}
+	items := filterItems(all, query.Filter)
+
+	// === STEP 2: Sort by (value, id) so the order is total ===
+	less := itemLess(query.Sort)
+	sort.Slice(items, func(i, j int) bool { return less(items[i], items[j]) })
+
+	// === STEP 3: Skip up to the cursor ===
+	start := 0
+	if after != nil {
+		position := domain.Item{ID: after.ID}
+		setSortValue(&position, query.Sort, after.Value)
+		start = sort.Search(len(items), func(i int) bool { return less(position, items[i]) })
+	}
+
+	end := start + query.Limit
+	if end > len(items) {
+		end = len(items)
+	}
+
+	page := domain.ItemPage{
+		Items: items[start:end],
+		Sort:  query.Sort,
+		Limit: query.Limit,
+	}
+	if end < len(items) {
+		last := page.Items[len(page.Items)-1]
+		page.NextCursor = encodeListCursor(listCursor{
+			Sort:  query.Sort,
+			Value: sortValue(last, query.Sort),
+			ID:    last.ID,
+		})
+	}
+
+	s.logger.Debug("items listed",
+		zap.Int("matched", len(items)),
+		zap.Int("returned", len(page.Items)),
+		zap.String("sort", string(query.Sort)),
+	)
+
+	return page, version, nil
+}
+
+// Facets implements ItemService.Facets over the same snapshot GetAll returns
+func (s *ItemServiceImpl) Facets(ctx context.Context, query domain.FacetQuery) (domain.FacetSet, *domain.CatalogVersion, *domain.ErrorResponse) {
+	if query.Buckets <= 0 {
+		query.Buckets = DefaultFacetBuckets
+	}
+	if query.Buckets > MaxFacetBuckets {
+		query.Buckets = MaxFacetBuckets
+	}
+
+	catalog, version := liveCatalog(s.repo)
+	all, err := catalog.GetAll(ctx)
+	if err != nil {
+		return domain.FacetSet{}, nil, s.readFailed(err)
+	}
+	items := filterItems(all, query.Filter)
+
+	set := domain.FacetSet{
+		Total:  len(items),
+		Facets: buildFacets(items, query.Fields, query.Buckets),
+	}
+
+	s.logger.Debug("item facets computed",
+		zap.Int("matched", set.Total),
+		zap.Int("facets", len(set.Facets)),
+	)
+
+	return set, version, nil
+}
+
+// Search implements ItemService.Search.
+// Repositories without their own index are indexed on every call.
+func (s *ItemServiceImpl) Search(ctx context.Context, query string, limit int) ([]domain.SearchHit, *domain.CatalogVersion, *domain.ErrorResponse) {
+	if strings.TrimSpace(query) == "" {
+		return nil, nil, &domain.ErrorResponse{
+			ErrorCode: domain.ErrorCodeInvalidRequest,
+			Message:   "Query parameter q is required.",
+		}
+	}
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	if limit > MaxListLimit {
+		limit = MaxListLimit
+	}
+
+	var index *data.SearchIndex
+	if searchable, ok := s.repo.(data.Searchable); ok {
+		var err error
+		if index, err = searchable.SearchIndex(ctx); err != nil {
+			return nil, nil, s.readFailed(err)
+		}
+	}
+	if index == nil {
+		items, err := s.repo.GetAll(ctx)
+		if err != nil {
+			return nil, nil, s.readFailed(err)
+		}
+		index = data.NewSearchIndex(items)
+	}
+
+	hits := index.Search(query, limit)
+	if s.priceHistory != nil {
+		now := time.Now()
+		for i := range hits {
+			hits[i].Item.PriceTrend = s.priceHistory.Trend(hits[i].Item.ID, hits[i].Item.Price, now)
+		}
+	}
+
+	s.logger.Debug("items searched",
+		zap.String("query", query),
+		zap.Int("hits", len(hits)),
+	)
+
+	return hits, index.Version(), nil
+}
+
+// PriceHistory implements ItemService.PriceHistory.
+// Items removed from the catalog still report their history, without a trend.
+func (s *ItemServiceImpl) PriceHistory(ctx context.Context, id string) (domain.PriceHistory, *domain.ErrorResponse) {
+	items, _, err := s.repo.GetByIDs(ctx, []string{id})
+	if err != nil {
+		return domain.PriceHistory{}, s.readFailed(err)
+	}
+
+	var points []domain.PricePoint
+	hasHistory := false
+	if s.priceHistory != nil {
+		points, hasHistory = s.priceHistory.History(id)
+	}
+
+	if len(items) == 0 && !hasHistory {
+		return domain.PriceHistory{}, &domain.ErrorResponse{
+			ErrorCode:  domain.ErrorCodeIdNotFound,
+			Message:    "Product not found.",
+			MissingIDs: []string{id},
+		}
+	}
+
+	history := domain.PriceHistory{
+		ItemID: id,
+		Points: points,
+	}
+	if history.Points == nil {
+		history.Points = []domain.PricePoint{}
+	}
+	if len(items) > 0 && s.priceHistory != nil {
+		history.Trend = s.priceHistory.Trend(id, items[0].Price, time.Now())
+	}
+
+	return history, nil
+}
+
+// liveCatalog pins the live snapshot of a versioned repository, so a whole request reads
+// one consistent version. Other repositories are returned as is, without version.
+func liveCatalog(repo data.CatalogRepository) (data.CatalogRepository, *domain.CatalogVersion) {
+	versioned, ok := repo.(data.VersionedCatalog)
+	if !ok {
+		return repo, nil
+	}
+
+	snapshot := versioned.CurrentSnapshot()
+	version := snapshot.Version
+	return snapshot, &version
+}
+
+// filterItems keeps the items that match the filter
+func filterItems(items []domain.Item, filter domain.ItemFilter) []domain.Item {
+	if filter.IsEmpty() {
+		return items
+	}
+
+	matched := make([]domain.Item, 0, len(items))
+	for _, item := range items {
+		if filter.Matches(item) {
+			matched = append(matched, item)
+		}
+	}
+	return matched
+}
+
+// itemLess returns the ordering of a sort, ties broken by ID
+func itemLess(order domain.ItemSort) func(a, b domain.Item) bool {
+	descending := order == domain.SortByPriceDesc || order == domain.SortByRatingDesc
+
+	return func(a, b domain.Item) bool {
+		va, vb := sortValue(a, order), sortValue(b, order)
+		if va != vb {
+			if descending {
+				return va > vb
+			}
+			return va < vb
+		}
+		return a.ID < b.ID
+	}
+}
+
+// sortValue returns the numeric sort key of an item (0 when sorting by ID)
+func sortValue(item domain.Item, order domain.ItemSort) float64 {
+	switch order {
+	case domain.SortByPriceAsc, domain.SortByPriceDesc:
+		return item.Price
+	case domain.SortByRatingAsc, domain.SortByRatingDesc:
+		return item.Rating
+	default:
+		return 0
+	}
+}
+
+// setSortValue is the inverse of sortValue, used to rebuild the cursor position
+func setSortValue(item *domain.Item, order domain.ItemSort, value float64) {
+	switch order {
+	case domain.SortByPriceAsc, domain.SortByPriceDesc:
+		item.Price = value
+	case domain.SortByRatingAsc, domain.SortByRatingDesc:
+		item.Rating = value
+	}
+}
+
+func encodeListCursor(cursor listCursor) string {
+	encoded, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+func decodeListCursor(raw string) (listCursor, error) {
+	var cursor listCursor
+	decoded, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return cursor, err
+	}
+	err = json.Unmarshal(decoded, &cursor)
+	return cursor, err
+}
+
+// readFailed logs a catalog read failure and reports it as an internal error, never as a missing item
+func (s *ItemServiceImpl) readFailed(err error) *domain.ErrorResponse {
+	s.logger.Error("failed to read the catalog", zap.Error(err))
+	return &domain.ErrorResponse{
+		ErrorCode: domain.ErrorCodeInternal,
+		Message:   "Failed to read the catalog.",
+	}
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"go.uber.org/zap"
+)
+
+func newListRepo() *MockCatalogRepository {
+	return &MockCatalogRepository{
+		items: map[string]domain.Item{
+			"a": {ID: "a", Name: "A", Price: 30, Rating: 4.0, Specifications: map[string]interface{}{"wireless": true}},
+			"b": {ID: "b", Name: "B", Price: 10, Rating: 4.5, Specifications: map[string]interface{}{"wireless": false}},
+			"c": {ID: "c", Name: "C", Price: 20, Rating: 3.5, Specifications: map[string]interface{}{"wireless": true}},
+			"d": {ID: "d", Name: "D", Price: 20, Rating: 5.0, Specifications: map[string]interface{}{"wireless": true}},
+		},
+	}
+}
+
+func TestItemService_Get(t *testing.T) {
+	svc := NewItemService(newListRepo(), nil, zap.NewNop())
+
+	item, _, errResp := svc.Get(context.Background(), "b")
+	if errResp != nil {
+		t.Fatalf("Unexpected error: %v", errResp)
+	}
+	if item.ID != "b" {
+		t.Errorf("Expected item b, got %s", item.ID)
+	}
+
+	_, _, errResp = svc.Get(context.Background(), "missing")
+	if errResp == nil || errResp.ErrorCode != domain.ErrorCodeIdNotFound {
+		t.Errorf("Expected ID_NOT_FOUND, got %v", errResp)
+	}
+}
+
+func TestItemService_List(t *testing.T) {
+	wireless := domain.ItemFilter{Equals: map[string][]string{"specifications.wireless": {"true"}}}
+
+	tests := []struct {
+		name     string
+		query    domain.ItemListQuery
+		expected []string
+	}{
+		{name: "Default sort by ID", query: domain.ItemListQuery{}, expected: []string{"a", "b", "c", "d"}},
+		{name: "Price ascending, ties by ID", query: domain.ItemListQuery{Sort: domain.SortByPriceAsc}, expected: []string{"b", "c", "d", "a"}},
+		{name: "Rating descending", query: domain.ItemListQuery{Sort: domain.SortByRatingDesc}, expected: []string{"d", "b", "a", "c"}},
+		{name: "Specification filter", query: domain.ItemListQuery{Filter: wireless}, expected: []string{"a", "c", "d"}},
+		{
+			name:     "Price range",
+			query:    domain.ItemListQuery{Filter: domain.ItemFilter{Min: map[string]float64{"price": 15}, Max: map[string]float64{"price": 25}}},
+			expected: []string{"c", "d"},
+		},
+	}
+
+	svc := NewItemService(newListRepo(), nil, zap.NewNop())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, _, errResp := svc.List(context.Background(), tt.query)
+			if errResp != nil {
+				t.Fatalf("Unexpected error: %v", errResp)
+			}
+			if len(page.Items) != len(tt.expected) {
+				t.Fatalf("Expected %d items, got %d", len(tt.expected), len(page.Items))
+			}
+			for i, id := range tt.expected {
+				if page.Items[i].ID != id {
+					t.Errorf("Position %d: expected %s, got %s", i, id, page.Items[i].ID)
+				}
+			}
+		})
+	}
+}
+
+func TestItemService_List_CursorPagination(t *testing.T) {
+	svc := NewItemService(newListRepo(), nil, zap.NewNop())
+	query := domain.ItemListQuery{Sort: domain.SortByPriceDesc, Limit: 3}
+
+	first, _, errResp := svc.List(context.Background(), query)
+	if errResp != nil {
+		t.Fatalf("Unexpected error: %v", errResp)
+	}
+	if len(first.Items) != 3 || first.NextCursor == "" {
+		t.Fatalf("Expected a full first page with cursor, got %d items", len(first.Items))
+	}
+
+	query.Cursor = first.NextCursor
+	second, _, errResp := svc.List(context.Background(), query)
+	if errResp != nil {
+		t.Fatalf("Unexpected error: %v", errResp)
+	}
+	if len(second.Items) != 1 || second.Items[0].ID != "b" {
+		t.Errorf("Expected last page with item b, got %v", second.Items)
+	}
+	if second.NextCursor != "" {
+		t.Error("Expected no cursor on the last page")
+	}
+
+	// A cursor is bound to the sort it was issued for
+	query.Sort = domain.SortByRatingAsc
+	_, _, errResp = svc.List(context.Background(), query)
+	if errResp == nil || errResp.ErrorCode != domain.ErrorCodeInvalidRequest {
+		t.Errorf("Expected INVALID_REQUEST for a cursor of another sort, got %v", errResp)
+	}
+}
+
+func TestItemService_Search(t *testing.T) {
+	repo := &MockCatalogRepository{
+		items: map[string]domain.Item{
+			"1": {ID: "1", Name: "Pro Mouse Logitech 3"},
+			"2": {ID: "2", Name: "Pro Mouse HP 2"},
+		},
+	}
+	svc := NewItemService(repo, nil, zap.NewNop())
+
+	hits, _, errResp := svc.Search(context.Background(), "logitech mouse", 0)
+	if errResp != nil {
+		t.Fatalf("Unexpected error: %v", errResp)
+	}
+	if len(hits) != 1 || hits[0].Item.ID != "1" {
+		t.Errorf("Expected only item 1, got %v", hits)
+	}
+
+	_, _, errResp = svc.Search(context.Background(), " ", 0)
+	if errResp == nil || errResp.ErrorCode != domain.ErrorCodeInvalidRequest {
+		t.Errorf("Expected INVALID_REQUEST for an empty query, got %v", errResp)
+	}
+}
+
+func TestItemService_Export(t *testing.T) {
+	svc := NewItemService(newListRepo(), nil, zap.NewNop())
+	ctx := context.Background()
+	wireless := domain.ItemFilter{Equals: map[string][]string{"specifications.wireless": {"true"}}}
+
+	export, errResp := svc.Export(ctx, "", wireless)
+	if errResp != nil {
+		t.Fatalf("Unexpected error: %v", errResp)
+	}
+	if export.Format != "json" || export.ContentType != "application/json; charset=utf-8" || export.Version != nil {
+		t.Errorf("Unexpected export %+v", export)
+	}
+
+	var buf strings.Builder
+	if err := export.Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	var items []domain.Item
+	if err := json.Unmarshal([]byte(buf.String()), &items); err != nil {
+		t.Fatalf("Expected a JSON array, got %q: %v", buf.String(), err)
+	}
+	ids := []string{}
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	if export.Count != 3 || !reflect.DeepEqual(ids, []string{"a", "c", "d"}) {
+		t.Errorf("Expected the filtered items sorted by ID, got %v (count %d)", ids, export.Count)
+	}
+
+	export, errResp = svc.Export(ctx, "CSV", domain.ItemFilter{})
+	if errResp != nil || export.Format != "csv" || export.Count != 4 {
+		t.Errorf("Expected a CSV export of 4 items, got %+v (%v)", export, errResp)
+	}
+
+	_, errResp = svc.Export(ctx, "xml", domain.ItemFilter{})
+	if errResp == nil || errResp.ErrorCode != domain.ErrorCodeInvalidRequest {
+		t.Errorf("Expected InvalidRequest for an unknown format, got %v", errResp)
+	}
+}
+
+// A catalog that cannot be read is a server error, never an empty listing
+func TestItemService_CatalogError(t *testing.T) {
+	svc := NewItemService(&MockCatalogRepository{shouldError: true}, nil, zap.NewNop())
+	ctx := context.Background()
+
+	_, _, listErr := svc.List(ctx, domain.ItemListQuery{})
+	_, _, facetErr := svc.Facets(ctx, domain.FacetQuery{})
+	_, _, searchErr := svc.Search(ctx, "mouse", 0)
+	_, exportErr := svc.Export(ctx, "", domain.ItemFilter{})
+
+	for name, errResp := range map[string]*domain.ErrorResponse{"List": listErr, "Facets": facetErr, "Search": searchErr, "Export": exportErr} {
+		if errResp == nil || errResp.ErrorCode != domain.ErrorCodeInternal {
+			t.Errorf("%s: expected INTERNAL, got %v", name, errResp)
+		}
+	}
+}
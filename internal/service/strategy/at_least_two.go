@@ -5,19 +5,29 @@ import (
 	"fmt"
 	"reflect"
 	"sort"
-	"strings"
 
 	"github.com/mmedinam1600/product-comparison-api/internal/domain"
 )
 
+// priceTrendFields are the comparable fields derived from domain.PriceTrend
+var priceTrendFields = []string{"price_trend.low_30d", "price_trend.change_pct", "price_trend.at_lowest"}
+
 // AtLeastTwo is a strategy that compares fields present in at least 2 products
-type AtLeastTwo struct{}
+type AtLeastTwo struct {
+	metrics MetricOverrides // Optional, replaces the default metric of some fields
+}
 
 // NewAtLeastTwo creates a new instance of the AtLeastTwo strategy
 func NewAtLeastTwo() *AtLeastTwo {
 	return &AtLeastTwo{}
 }
 
+// WithMetricOverrides returns a copy of the strategy that uses the given metrics
+// before the defaults (e.g. a storefront where a heavier monitor is better)
+func (s *AtLeastTwo) WithMetricOverrides(overrides MetricOverrides) *AtLeastTwo {
+	return &AtLeastTwo{metrics: overrides}
+}
+
 // Name returns the identifier of this strategy
 func (s *AtLeastTwo) Name() string {
 	return "at_least_two"
@@ -41,6 +51,22 @@ func (s *AtLeastTwo) ResolveFields(items []domain.Item, requested *[]string) []s
 			allFieldsMap["rating"]++
 		}
 
+		// Price trend fields (derived from the price history, when available)
+		if item.PriceTrend != nil {
+			for _, trendField := range priceTrendFields {
+				allFieldsMap[trendField]++
+			}
+		}
+
+		// Availability fields (when the catalog declares them)
+		if item.Availability != nil {
+			allFieldsMap["availability.status"]++
+			allFieldsMap["availability.in_stock"]++
+			if item.Availability.EstimatedShipDate != "" {
+				allFieldsMap["availability.estimated_ship_date"]++
+			}
+		}
+
 		// Specifications fields (nested)
 		for specKey := range item.Specifications {
 			fieldPath := fmt.Sprintf("specifications.%s", specKey)
@@ -97,7 +123,7 @@ func (s *AtLeastTwo) ComputeDiff(ctx context.Context, items []domain.Item, resol
 		}
 
 		// Determine metric for this field
-		metric := GetMetricForField(fieldPath)
+		metric := s.metrics.MetricForField(fieldPath)
 
 		// Calculate the best(s) according to the metric
 		best := s.calculateBest(values, metric)
@@ -115,42 +141,7 @@ func (s *AtLeastTwo) ComputeDiff(ctx context.Context, items []domain.Item, resol
 // extractFieldValue extracts the value of a field from an item.
 // Supports root fields (e.g., "price") and nested fields (e.g., "specifications.buttons")
 func (s *AtLeastTwo) extractFieldValue(item domain.Item, fieldPath string) interface{} {
-	parts := strings.Split(fieldPath, ".")
-
-	if len(parts) == 1 {
-		// Campo root
-		switch parts[0] {
-		case "price":
-			return item.Price
-		case "rating":
-			return item.Rating
-		case "name":
-			return item.Name
-		case "description":
-			return item.Description
-		case "image_url":
-			return item.ImageURL
-		default:
-			return nil
-		}
-	}
-
-	if len(parts) == 2 && parts[0] == "specifications" {
-		// Nested field in specifications
-		specKey := parts[1]
-		if val, exists := item.Specifications[specKey]; exists {
-			// Extract the numeric value if it is an object with "value"
-			if mapVal, ok := val.(map[string]interface{}); ok {
-				if numVal, hasValue := mapVal["value"]; hasValue {
-					return numVal
-				}
-			}
-			return val
-		}
-		return nil
-	}
-
-	return nil
+	return item.FieldValue(fieldPath)
 }
 
 // calculateBest determines which items have the best value according to the metric
@@ -348,3 +348,86 @@ func TestAtLeastTwo_ExtractFieldValue(t *testing.T) {
 		})
 	}
 }
+
+func TestAtLeastTwo_PriceTrendFields(t *testing.T) {
+	strategy := NewAtLeastTwo()
+
+	items := []domain.Item{
+		{ID: "1", Price: 10.0, PriceTrend: &domain.PriceTrend{Low30d: 10.0, ChangePct: -5, AtLowest: true}},
+		{ID: "2", Price: 20.0, PriceTrend: &domain.PriceTrend{Low30d: 18.0, ChangePct: 3, AtLowest: false}},
+		{ID: "3", Price: 30.0},
+	}
+
+	resolved := strategy.ResolveFields(items, nil)
+	expected := []string{"price", "price_trend.at_lowest", "price_trend.change_pct", "price_trend.low_30d", "rating"}
+	if !reflect.DeepEqual(resolved, expected) {
+		t.Errorf("ResolveFields() = %v, want %v", resolved, expected)
+	}
+
+	diff, err := strategy.ComputeDiff(context.Background(), items, resolved)
+	if err != nil {
+		t.Fatalf("ComputeDiff() error = %v", err)
+	}
+
+	if got := diff["price_trend.at_lowest"].Best; !reflect.DeepEqual(got, []string{"1"}) {
+		t.Errorf("Expected at_lowest best = [1], got %v", got)
+	}
+	if got := diff["price_trend.low_30d"].Values["3"]; got != nil {
+		t.Errorf("Expected nil low_30d for an item without trend, got %v", got)
+	}
+}
+
+func TestAtLeastTwo_AvailabilityFields(t *testing.T) {
+	strategy := NewAtLeastTwo()
+
+	items := []domain.Item{
+		{ID: "1", Availability: &domain.Availability{Status: domain.InStock}},
+		{ID: "2", Availability: &domain.Availability{Status: domain.Backorder, EstimatedShipDate: "2026-11-02"}},
+		{ID: "3"},
+	}
+
+	resolved := strategy.ResolveFields(items, nil)
+	expected := []string{"availability.in_stock", "availability.status", "price", "rating"}
+	if !reflect.DeepEqual(resolved, expected) {
+		t.Errorf("ResolveFields() = %v, want %v", resolved, expected)
+	}
+
+	diff, err := strategy.ComputeDiff(context.Background(), items, resolved)
+	if err != nil {
+		t.Fatalf("ComputeDiff() error = %v", err)
+	}
+
+	inStock := diff["availability.in_stock"]
+	if inStock.Metric == nil || *inStock.Metric != domain.TrueIsBetter || !reflect.DeepEqual(inStock.Best, []string{"1"}) {
+		t.Errorf("Expected in_stock true_is_better with best [1], got %+v", inStock)
+	}
+	status := diff["availability.status"]
+	if status.Values["2"] != "backorder" || status.Values["3"] != nil {
+		t.Errorf("Unexpected status values %v", status.Values)
+	}
+}
+
+func TestAtLeastTwo_ComputeDiff_MetricOverrides(t *testing.T) {
+	items := []domain.Item{
+		{ID: "light", Specifications: map[string]interface{}{"weight": 1.0}},
+		{ID: "heavy", Specifications: map[string]interface{}{"weight": 3.0}},
+	}
+	resolved := []string{"specifications.weight"}
+
+	defaults, _ := NewAtLeastTwo().ComputeDiff(context.Background(), items, resolved)
+	if best := defaults["specifications.weight"].Best; !reflect.DeepEqual(best, []string{"light"}) {
+		t.Errorf("Expected the default metric to prefer the lighter item, got %v", best)
+	}
+
+	strategy := NewAtLeastTwo().WithMetricOverrides(MetricOverrides{
+		"specifications.weight": domain.HigherIsBetter,
+	})
+	overridden, _ := strategy.ComputeDiff(context.Background(), items, resolved)
+	field := overridden["specifications.weight"]
+	if field.Metric == nil || *field.Metric != domain.HigherIsBetter {
+		t.Fatalf("Expected the overridden metric, got %v", field.Metric)
+	}
+	if !reflect.DeepEqual(field.Best, []string{"heavy"}) {
+		t.Errorf("Expected the override to prefer the heavier item, got %v", field.Best)
+	}
+}
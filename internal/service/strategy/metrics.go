@@ -11,6 +11,10 @@ var fieldMetrics = map[string]domain.Metric{
 	// Root fields where lower is better
 	"price": domain.LowerIsBetter,
 
+	// Price trend fields: a lower 30-day low and a bigger drop are better
+	"price_trend.low_30d":    domain.LowerIsBetter,
+	"price_trend.change_pct": domain.LowerIsBetter,
+
 	// Root fields where higher is better
 	"rating": domain.HigherIsBetter,
 
@@ -28,6 +32,8 @@ var fieldMetrics = map[string]domain.Metric{
 	"specifications.wireless":         domain.TrueIsBetter,
 	"specifications.noise_cancelling": domain.TrueIsBetter,
 	"specifications.backlit":          domain.TrueIsBetter,
+	"price_trend.at_lowest":           domain.TrueIsBetter,
+	"availability.in_stock":           domain.TrueIsBetter,
 }
 
 // GetMetricForField returns the appropriate metric for a given field.
@@ -42,3 +48,17 @@ func GetMetricForField(fieldPath string) *domain.Metric {
 
 	return nil
 }
+
+// MetricOverrides maps field names to the metric that replaces their default one
+type MetricOverrides map[string]domain.Metric
+
+// MetricForField returns the override of a field, or its default metric when there is none
+func (o MetricOverrides) MetricForField(fieldPath string) *domain.Metric {
+	normalizedPath := strings.ToLower(strings.TrimSpace(fieldPath))
+
+	if metric, exists := o[normalizedPath]; exists {
+		return &metric
+	}
+
+	return GetMetricForField(normalizedPath)
+}
@@ -16,15 +16,75 @@ type Config struct {
 	AppEnv  string `env:"APP_ENV" envDefault:"local"`    // "local" | "prod"
 
 	// Data
-	DataFile string `env:"DATA_FILE" envDefault:"data/items.json"`
+	CatalogSource string `env:"CATALOG_SOURCE" envDefault:"file"` // "file" | "http" | "postgres"
+	DataFile      string `env:"DATA_FILE" envDefault:"data/items.json"`
+	CatalogFormat string `env:"CATALOG_FORMAT"` // "json" | "ndjson" | "csv"; inferred from DATA_FILE when empty
 
-	// Cache
-	CacheTTL  time.Duration `env:"CACHE_TTL" envDefault:"60s"`
-	CacheSize int64         `env:"CACHE_SIZE" envDefault:"1000"`
+	// Remote catalog (CATALOG_SOURCE=http), polled every CATALOG_RELOAD_INTERVAL
+	CatalogURL           string        `env:"CATALOG_URL"`
+	CatalogAuthorization string        `env:"CATALOG_AUTHORIZATION"` // Optional Authorization header value
+	CatalogHTTPTimeout   time.Duration `env:"CATALOG_HTTP_TIMEOUT" envDefault:"30s"`
+	CatalogMaxBackoff    time.Duration `env:"CATALOG_MAX_BACKOFF" envDefault:"10m"` // Longest wait between polls after failures
+
+	// Catalog versions
+	CatalogVersions       int           `env:"CATALOG_VERSIONS" envDefault:"10"`         // Snapshots kept for as-of comparisons
+	CatalogReloadInterval time.Duration `env:"CATALOG_RELOAD_INTERVAL" envDefault:"30s"` // 0 disables hot reload
+
+	// Price history
+	PriceHistoryFile      string        `env:"PRICE_HISTORY_FILE"`                         // Empty keeps the history in memory only
+	PriceHistoryRetention time.Duration `env:"PRICE_HISTORY_RETENTION" envDefault:"2160h"` // 90 days
+
+	// Tenants
+	TenantsFile  string `env:"TENANTS_FILE"`                           // Empty serves a single storefront from the settings above
+	TenantHeader string `env:"TENANT_HEADER" envDefault:"X-Tenant-ID"` // Header that names the tenant (the host is tried next)
+
+	// Localization: Accept-Language is negotiated among the supported locales
+	DefaultLocale    string   `env:"DEFAULT_LOCALE" envDefault:"en"` // Language of the catalog base content
+	SupportedLocales []string `env:"SUPPORTED_LOCALES" envSeparator:"," envDefault:"en,es-MX,pt-BR"`
+
+	// Currencies: prices are converted with the exchange-rate table of EXCHANGE_RATES_FILE
+	CatalogCurrency             string        `env:"CATALOG_CURRENCY" envDefault:"USD"` // Currency of prices that declare none
+	ExchangeRatesFile           string        `env:"EXCHANGE_RATES_FILE"`               // Empty disables currency conversion
+	ExchangeRatesReloadInterval time.Duration `env:"EXCHANGE_RATES_RELOAD_INTERVAL" envDefault:"30s"`
+
+	// PostgreSQL (CATALOG_SOURCE=postgres)
+	DatabaseURL       string        `env:"DATABASE_URL"`
+	DBMaxConns        int32         `env:"DB_MAX_CONNS" envDefault:"10"`
+	DBMinConns        int32         `env:"DB_MIN_CONNS" envDefault:"2"`
+	DBMaxConnLifetime time.Duration `env:"DB_MAX_CONN_LIFETIME" envDefault:"30m"`
+	DBMaxConnIdleTime time.Duration `env:"DB_MAX_CONN_IDLE_TIME" envDefault:"5m"`
+	DBMigrate         bool          `env:"DB_MIGRATE" envDefault:"true"`
+
+	// Cache: "memory" keeps the entries in each replica, "redis" shares them (and the idempotency keys) through REDIS_URL
+	CacheBackend      string        `env:"CACHE_BACKEND" envDefault:"memory"`
+	CacheTTL          time.Duration `env:"CACHE_TTL" envDefault:"60s"`            // Soft TTL: comparisons are fresh for this long
+	CacheHardTTL      time.Duration `env:"CACHE_HARD_TTL"`                        // Until then they are served stale while refreshed; unset (or not above CACHE_TTL) never serves stale
+	CacheMaxBytes     int64         `env:"CACHE_MAX_BYTES" envDefault:"67108864"` // Memory backend only: 64 MiB of encoded responses
+	CacheSize         int           `env:"CACHE_SIZE"`                            // Deprecated: entries are no longer counted, use CACHE_MAX_BYTES
+	CacheCompression  []string      `env:"CACHE_COMPRESSION" envSeparator:","`    // Codings cached responses are precompressed in: gzip, br
+	CacheSnapshotFile string        `env:"CACHE_SNAPSHOT_FILE"`                   // Memory backend only: both caches are saved there on shutdown and restored on boot
+	RedisURL          string        `env:"REDIS_URL" envDefault:"redis://localhost:6379/0"`
+	RedisKeyPrefix    string        `env:"REDIS_KEY_PREFIX" envDefault:"pca:"` // Lets several deployments share a server
+
+	// HTTP caching: Cache-Control of the v1 responses, overridable by route path with
+	// "path|value" pairs separated by ";" (e.g. "/api/v1/items/:id|public, max-age=60")
+	HTTPCacheControl       string            `env:"HTTP_CACHE_CONTROL" envDefault:"no-cache"`
+	HTTPCacheControlRoutes map[string]string `env:"HTTP_CACHE_CONTROL_ROUTES" envSeparator:";" envKeyValSeparator:"|"`
 
 	// Idempotency
-	IdempotencyTTL  time.Duration `env:"IDEMPOTENCY_TTL" envDefault:"5m"`
-	IdempotencySize int64         `env:"IDEMPOTENCY_SIZE" envDefault:"5000"`
+	IdempotencyTTL      time.Duration `env:"IDEMPOTENCY_TTL" envDefault:"5m"`
+	IdempotencyMaxBytes int64         `env:"IDEMPOTENCY_MAX_BYTES" envDefault:"16777216"` // Memory backend only: 16 MiB
+	IdempotencySize     int           `env:"IDEMPOTENCY_SIZE"`                            // Deprecated: entries are no longer counted, use IDEMPOTENCY_MAX_BYTES
+
+	// Cache warm-up: the most requested comparisons are precomputed at startup (before /api/ready)
+	// and after a catalog reload, bounded by count and time
+	WarmupFile         string        `env:"WARMUP_FILE"`                         // Empty keeps the counts in memory only (no startup warm-up)
+	WarmupMaxEntries   int           `env:"WARMUP_MAX_ENTRIES" envDefault:"100"` // 0 disables the warm-up
+	WarmupTimeout      time.Duration `env:"WARMUP_TIMEOUT" envDefault:"30s"`
+	WarmupSaveInterval time.Duration `env:"WARMUP_SAVE_INTERVAL" envDefault:"1m"` // Also saved on shutdown
+
+	// Admin API (/api/admin): cache stats and purges, authorized with "Authorization: Bearer <ADMIN_TOKEN>"
+	AdminToken string `env:"ADMIN_TOKEN"` // Empty disables the admin routes
 
 	// Server timeouts
 	ReadTimeout  time.Duration `env:"READ_TIMEOUT" envDefault:"10s"`
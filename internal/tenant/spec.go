@@ -0,0 +1,103 @@
+package tenant
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+	"github.com/mmedinam1600/product-comparison-api/internal/service/strategy"
+)
+
+// Spec describes a tenant in the tenants file
+type Spec struct {
+	ID               string                   `json:"id"`
+	Hosts            []string                 `json:"hosts,omitempty"`              // Hosts that select the tenant without header
+	DataFile         string                   `json:"data_file,omitempty"`          // Items file of the storefront
+	URL              string                   `json:"url,omitempty"`                // Catalog export URL, instead of data_file
+	Format           string                   `json:"format,omitempty"`             // "json" | "ndjson" | "csv"; inferred when empty
+	PriceHistoryFile string                   `json:"price_history_file,omitempty"` // Empty keeps the history in memory only
+	MetricOverrides  strategy.MetricOverrides `json:"metric_overrides,omitempty"`   // Field → metric replacing the default
+	Currency         string                   `json:"currency,omitempty"`           // Currency of prices that declare none; CATALOG_CURRENCY when empty
+}
+
+// Specs is the content of the tenants file
+type Specs struct {
+	Default string `json:"default,omitempty"` // Tenant used when the request names none
+	Tenants []Spec `json:"tenants"`
+}
+
+// LoadSpecs reads and validates the tenants file
+func LoadSpecs(path string) (Specs, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Specs{}, fmt.Errorf("failed to read tenants file: %w", err)
+	}
+
+	var specs Specs
+	if err := json.Unmarshal(content, &specs); err != nil {
+		return Specs{}, fmt.Errorf("failed to parse tenants file: %w", err)
+	}
+
+	if err := specs.Validate(); err != nil {
+		return Specs{}, err
+	}
+
+	// Field paths are matched in lower case
+	for i, spec := range specs.Tenants {
+		normalized := make(strategy.MetricOverrides, len(spec.MetricOverrides))
+		for field, metric := range spec.MetricOverrides {
+			normalized[strings.ToLower(strings.TrimSpace(field))] = metric
+		}
+		specs.Tenants[i].MetricOverrides = normalized
+		specs.Tenants[i].Currency = domain.NormalizeCurrency(spec.Currency)
+	}
+
+	return specs, nil
+}
+
+// Validate checks that every tenant has an ID and one catalog source, and that metrics are known
+func (s Specs) Validate() error {
+	if len(s.Tenants) == 0 {
+		return fmt.Errorf("tenants file declares no tenants")
+	}
+
+	seen := make(map[string]bool, len(s.Tenants))
+	for i, spec := range s.Tenants {
+		if spec.ID == "" {
+			return fmt.Errorf("tenant #%d: id is required", i+1)
+		}
+		if seen[spec.ID] {
+			return fmt.Errorf("tenant %q is declared twice", spec.ID)
+		}
+		seen[spec.ID] = true
+
+		if (spec.DataFile == "") == (spec.URL == "") {
+			return fmt.Errorf("tenant %q: exactly one of data_file or url is required", spec.ID)
+		}
+		if spec.Currency != "" && domain.NormalizeCurrency(spec.Currency) == "" {
+			return fmt.Errorf("tenant %q: invalid currency %q", spec.ID, spec.Currency)
+		}
+		for field, metric := range spec.MetricOverrides {
+			switch metric {
+			case domain.LowerIsBetter, domain.HigherIsBetter, domain.TrueIsBetter:
+			default:
+				return fmt.Errorf("tenant %q: unknown metric %q for field %q", spec.ID, metric, field)
+			}
+		}
+	}
+
+	if s.Default != "" && !seen[s.Default] {
+		return fmt.Errorf("default tenant %q is not declared", s.Default)
+	}
+	return nil
+}
+
+// CatalogSource returns the catalog source of the tenant: "http" when it has a URL, "file" otherwise
+func (s Spec) CatalogSource() string {
+	if s.URL != "" {
+		return "http"
+	}
+	return "file"
+}
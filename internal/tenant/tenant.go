@@ -0,0 +1,136 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/mmedinam1600/product-comparison-api/internal/data"
+	"github.com/mmedinam1600/product-comparison-api/internal/service"
+)
+
+// DefaultID is the tenant of a single-tenant deployment
+const DefaultID = "default"
+
+// Tenant is a storefront served by the deployment, with its own catalog and services
+type Tenant struct {
+	ID           string
+	Hosts        []string
+	Repo         data.CatalogRepository
+	PriceHistory *data.PriceHistory // nil when the catalog source is not versioned
+
+	Compare service.CompareService
+	Items   service.ItemService
+	Catalog service.CatalogService
+}
+
+// UnknownTenantError is returned when a request names a tenant that is not registered
+type UnknownTenantError struct {
+	ID string
+}
+
+func (e *UnknownTenantError) Error() string {
+	return fmt.Sprintf("unknown tenant %q", e.ID)
+}
+
+// Registry holds the tenants of the deployment, by ID and by host
+type Registry struct {
+	tenants   map[string]*Tenant
+	hosts     map[string]*Tenant
+	defaultID string
+}
+
+// NewRegistry creates the registry. defaultID (optional) is used for requests that name no tenant.
+func NewRegistry(tenants []*Tenant, defaultID string) (*Registry, error) {
+	registry := &Registry{
+		tenants:   make(map[string]*Tenant, len(tenants)),
+		hosts:     make(map[string]*Tenant),
+		defaultID: defaultID,
+	}
+
+	for _, t := range tenants {
+		if _, exists := registry.tenants[t.ID]; exists {
+			return nil, fmt.Errorf("duplicate tenant %q", t.ID)
+		}
+		registry.tenants[t.ID] = t
+
+		for _, host := range t.Hosts {
+			host = strings.ToLower(host)
+			if other, exists := registry.hosts[host]; exists {
+				return nil, fmt.Errorf("host %q is used by tenants %q and %q", host, other.ID, t.ID)
+			}
+			registry.hosts[host] = t
+		}
+	}
+
+	if defaultID != "" {
+		if _, exists := registry.tenants[defaultID]; !exists {
+			return nil, &UnknownTenantError{ID: defaultID}
+		}
+	}
+
+	return registry, nil
+}
+
+// Get returns a tenant by ID
+func (r *Registry) Get(id string) (*Tenant, error) {
+	if t, exists := r.tenants[id]; exists {
+		return t, nil
+	}
+	return nil, &UnknownTenantError{ID: id}
+}
+
+// Resolve finds the tenant of a request: the explicit ID (e.g. from a header) first,
+// then the host, then the default tenant. found is false when none applies.
+func (r *Registry) Resolve(id, host string) (t *Tenant, found bool, err error) {
+	if id != "" {
+		t, err := r.Get(id)
+		return t, err == nil, err
+	}
+
+	if hostname, _, splitErr := net.SplitHostPort(host); splitErr == nil {
+		host = hostname
+	}
+	if t, exists := r.hosts[strings.ToLower(host)]; exists {
+		return t, true, nil
+	}
+
+	if r.defaultID != "" {
+		return r.tenants[r.defaultID], true, nil
+	}
+	return nil, false, nil
+}
+
+// All returns the tenants sorted by ID
+func (r *Registry) All() []*Tenant {
+	tenants := make([]*Tenant, 0, len(r.tenants))
+	for _, t := range r.tenants {
+		tenants = append(tenants, t)
+	}
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].ID < tenants[j].ID })
+	return tenants
+}
+
+// Close releases the repositories backed by external resources (e.g. a connection pool)
+func (r *Registry) Close() {
+	for _, t := range r.tenants {
+		if closer, ok := t.Repo.(interface{ Close() }); ok {
+			closer.Close()
+		}
+	}
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the tenant
+func NewContext(ctx context.Context, t *Tenant) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext returns the tenant resolved for the request, if any
+func FromContext(ctx context.Context) (*Tenant, bool) {
+	t, ok := ctx.Value(contextKey{}).(*Tenant)
+	return t, ok
+}
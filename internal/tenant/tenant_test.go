@@ -0,0 +1,125 @@
+package tenant
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mmedinam1600/product-comparison-api/internal/domain"
+)
+
+func newTestRegistry(t *testing.T, defaultID string) *Registry {
+	t.Helper()
+	registry, err := NewRegistry([]*Tenant{
+		{ID: "acme", Hosts: []string{"shop.acme.com"}},
+		{ID: "globex", Hosts: []string{"Globex.example"}},
+	}, defaultID)
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+	return registry
+}
+
+func TestRegistry_Resolve(t *testing.T) {
+	tests := []struct {
+		name        string
+		defaultID   string
+		id          string
+		host        string
+		expected    string
+		expectFound bool
+		expectError bool
+	}{
+		{name: "Header wins over host", id: "globex", host: "shop.acme.com", expected: "globex", expectFound: true},
+		{name: "Host without port", host: "shop.acme.com", expected: "acme", expectFound: true},
+		{name: "Host with port, case-insensitive", host: "GLOBEX.example:8080", expected: "globex", expectFound: true},
+		{name: "Default tenant", defaultID: "acme", host: "localhost:8080", expected: "acme", expectFound: true},
+		{name: "No tenant and no default", host: "localhost:8080"},
+		{name: "Unknown tenant", defaultID: "acme", id: "initech", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := newTestRegistry(t, tt.defaultID)
+
+			got, found, err := registry.Resolve(tt.id, tt.host)
+
+			var unknown *UnknownTenantError
+			if tt.expectError {
+				if !errors.As(err, &unknown) || unknown.ID != tt.id {
+					t.Fatalf("Expected UnknownTenantError for %q, got %v", tt.id, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if found != tt.expectFound {
+				t.Fatalf("found = %v, want %v", found, tt.expectFound)
+			}
+			if found && got.ID != tt.expected {
+				t.Errorf("Resolve() = %s, want %s", got.ID, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewRegistry_Conflicts(t *testing.T) {
+	if _, err := NewRegistry([]*Tenant{{ID: "a"}, {ID: "a"}}, ""); err == nil {
+		t.Error("Expected an error for duplicate tenants")
+	}
+	if _, err := NewRegistry([]*Tenant{{ID: "a", Hosts: []string{"x"}}, {ID: "b", Hosts: []string{"X"}}}, ""); err == nil {
+		t.Error("Expected an error for a host shared by two tenants")
+	}
+	if _, err := NewRegistry([]*Tenant{{ID: "a"}}, "b"); err == nil {
+		t.Error("Expected an error for an unknown default tenant")
+	}
+}
+
+func TestLoadSpecs(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectError bool
+	}{
+		{
+			name:    "Valid file",
+			content: `{"default":"acme","tenants":[{"id":"acme","data_file":"acme.json","currency":"mxn","metric_overrides":{"Specifications.Weight":"higher_is_better"}}]}`,
+		},
+		{name: "No tenants", content: `{"tenants":[]}`, expectError: true},
+		{name: "Missing data file", content: `{"tenants":[{"id":"acme"}]}`, expectError: true},
+		{name: "Duplicate ID", content: `{"tenants":[{"id":"a","data_file":"a"},{"id":"a","data_file":"b"}]}`, expectError: true},
+		{name: "Unknown metric", content: `{"tenants":[{"id":"a","data_file":"a","metric_overrides":{"price":"cheaper"}}]}`, expectError: true},
+		{name: "Invalid currency", content: `{"tenants":[{"id":"a","data_file":"a","currency":"peso"}]}`, expectError: true},
+		{name: "Undeclared default", content: `{"default":"b","tenants":[{"id":"a","data_file":"a"}]}`, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tenants.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			specs, err := LoadSpecs(path)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			// Field paths are normalized to lower case
+			if metric := specs.Tenants[0].MetricOverrides["specifications.weight"]; metric != domain.HigherIsBetter {
+				t.Errorf("Expected normalized override, got %v", specs.Tenants[0].MetricOverrides)
+			}
+			if specs.Tenants[0].Currency != "MXN" {
+				t.Errorf("Expected normalized currency, got %q", specs.Tenants[0].Currency)
+			}
+		})
+	}
+}